@@ -11,6 +11,7 @@ type AuditResult struct {
 	Hosts           map[string]HostResult
 	ExtraHosts      []string
 	MissingHostType []string
+	FailedHosts     []FailedHost
 }
 
 func (r AuditResult) OK() bool {
@@ -20,7 +21,16 @@ func (r AuditResult) OK() bool {
 		}
 	}
 
-	return len(r.ExtraHosts) == 0 && len(r.MissingHostType) == 0
+	return len(r.ExtraHosts) == 0 &&
+		len(r.MissingHostType) == 0 &&
+		len(r.FailedHosts) == 0
+}
+
+type FailedHost struct {
+	Name  string
+	IP    string
+	Stage string
+	Error string
 }
 
 type HostResult struct {
@@ -49,26 +59,32 @@ type Port int
 
 type AuditInput map[string]manifest.Spec
 
-func Audit(db *sql.DB, m manifest.Manifest) (AuditResult, error) {
+func Audit(db *sql.DB, m manifest.Manifest, scanID int) (AuditResult, error) {
 	result := AuditResult{
 		Hosts: make(map[string]HostResult),
 	}
 
-	input, err := mapHostnameToSpec(db, m)
+	input, err := mapHostnameToSpec(db, m, scanID)
 	if err != nil {
 		return AuditResult{}, err
 	}
 
-	missing, extras, err := lookForMissingAndExtraHosts(db, m.Specs)
+	failed, err := lookForFailedHosts(db, scanID)
+	if err != nil {
+		return AuditResult{}, err
+	}
+
+	missing, extras, err := lookForMissingAndExtraHosts(db, m.Specs, failed, scanID)
 	if err != nil {
 		return AuditResult{}, err
 	}
 
 	result.ExtraHosts = extras
 	result.MissingHostType = missing
+	result.FailedHosts = failed
 
 	for host, spec := range input {
-		hostResult, err := auditHost(db, host, spec)
+		hostResult, err := auditHost(db, host, spec, scanID)
 		if err != nil {
 			return AuditResult{}, err
 		}
@@ -79,23 +95,23 @@ func Audit(db *sql.DB, m manifest.Manifest) (AuditResult, error) {
 	return result, nil
 }
 
-func auditHost(db *sql.DB, host string, spec manifest.Spec) (HostResult, error) {
-	missingProcs, err := lookForMissingProcesses(db, host, spec)
+func auditHost(db *sql.DB, host string, spec manifest.Spec, scanID int) (HostResult, error) {
+	missingProcs, err := lookForMissingProcesses(db, host, spec, scanID)
 	if err != nil {
 		return HostResult{}, err
 	}
 
-	missingPorts, err := lookForMissingPorts(db, host, spec)
+	missingPorts, err := lookForMissingPorts(db, host, spec, scanID)
 	if err != nil {
 		return HostResult{}, err
 	}
 
-	unexpectedPorts, err := findUnexpectedPorts(db, host, spec)
+	unexpectedPorts, err := findUnexpectedPorts(db, host, spec, scanID)
 	if err != nil {
 		return HostResult{}, err
 	}
 
-	mismatchedProcesses, err := verifyProcessUsers(db, host, spec)
+	mismatchedProcesses, err := verifyProcessUsers(db, host, spec, scanID)
 	if err != nil {
 		return HostResult{}, err
 	}
@@ -108,7 +124,7 @@ func auditHost(db *sql.DB, host string, spec manifest.Spec) (HostResult, error)
 	}, nil
 }
 
-func mapHostnameToSpec(db *sql.DB, m manifest.Manifest) (AuditInput, error) {
+func mapHostnameToSpec(db *sql.DB, m manifest.Manifest, scanID int) (AuditInput, error) {
 	input := AuditInput{}
 
 	for _, spec := range m.Specs {
@@ -116,7 +132,8 @@ func mapHostnameToSpec(db *sql.DB, m manifest.Manifest) (AuditInput, error) {
 			SELECT hosts.name
 			FROM hosts
 			WHERE hosts.name LIKE ? || '%'
-		`, spec.Prefix)
+				AND hosts.scan_id = ?
+		`, spec.Prefix, scanID)
 
 		if err != nil {
 			return AuditInput{}, err
@@ -139,14 +156,14 @@ func mapHostnameToSpec(db *sql.DB, m manifest.Manifest) (AuditInput, error) {
 	return input, nil
 }
 
-func findUnexpectedPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, error) {
+func findUnexpectedPorts(db *sql.DB, host string, spec manifest.Spec, scanID int) ([]Port, error) {
 	expectedPorts := spec.ExpectedPorts()
 
 	args := []interface{}{}
 	for _, port := range expectedPorts {
 		args = append(args, port)
 	}
-	args = append(args, host)
+	args = append(args, host, scanID)
 
 	rows, err := db.Query(`
 		SELECT ports.number, processes.name
@@ -159,6 +176,7 @@ func findUnexpectedPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, e
 			AND ports.state = "LISTEN"
 			AND ports.address != "127.0.0.1"
 			AND hosts.name = ?
+			AND hosts.scan_id = ?
 	`, args...)
 
 	if err != nil {
@@ -191,8 +209,39 @@ func inPlaceholder(count int) string {
 	return strings.Join(strings.Split(strings.Repeat("?", count), ""), ", ")
 }
 
-func lookForMissingAndExtraHosts(db *sql.DB, manifestHosts []manifest.Spec) ([]string, []string, error) {
-	rows, err := db.Query(`SELECT hosts.name FROM hosts`)
+func lookForFailedHosts(db *sql.DB, scanID int) ([]FailedHost, error) {
+	rows, err := db.Query(`
+		SELECT scan_failures.name, scan_failures.ip, scan_failures.stage, scan_failures.error
+		FROM scan_failures
+		WHERE scan_failures.scan_id = ?
+		ORDER BY scan_failures.name, scan_failures.ip
+	`, scanID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	failed := []FailedHost{}
+
+	for rows.Next() {
+		var host FailedHost
+
+		err := rows.Scan(&host.Name, &host.IP, &host.Stage, &host.Error)
+		if err != nil {
+			return nil, err
+		}
+
+		failed = append(failed, host)
+	}
+
+	return failed, nil
+}
+
+// Hosts which failed to scan still count towards their host type so that they
+// are not reported as missing as well as failed.
+func lookForMissingAndExtraHosts(db *sql.DB, manifestHosts []manifest.Spec, failedHosts []FailedHost, scanID int) ([]string, []string, error) {
+	rows, err := db.Query(`SELECT hosts.name FROM hosts WHERE hosts.scan_id = ?`, scanID)
 
 	if err != nil {
 		return nil, nil, err
@@ -230,6 +279,10 @@ func lookForMissingAndExtraHosts(db *sql.DB, manifestHosts []manifest.Spec) ([]s
 		}
 	}
 
+	for _, failedHost := range failedHosts {
+		reportHosts = append(reportHosts, failedHost.Name)
+	}
+
 	for _, manifestHost := range manifestHosts {
 		found := false
 
@@ -248,7 +301,7 @@ func lookForMissingAndExtraHosts(db *sql.DB, manifestHosts []manifest.Spec) ([]s
 	return missings, extras, nil
 }
 
-func verifyProcessUsers(db *sql.DB, host string, spec manifest.Spec) ([]MismatchedProcess, error) {
+func verifyProcessUsers(db *sql.DB, host string, spec manifest.Spec, scanID int) ([]MismatchedProcess, error) {
 	mismatched := []MismatchedProcess{}
 
 	for _, proc := range spec.Processes {
@@ -262,7 +315,8 @@ func verifyProcessUsers(db *sql.DB, host string, spec manifest.Spec) ([]Mismatch
 			WHERE processes.user != ?
 				AND processes.name = ?
 				AND hosts.name = ?
-		`, proc.User, proc.Command, host)
+				AND hosts.scan_id = ?
+		`, proc.User, proc.Command, host, scanID)
 
 		if err != nil {
 			return nil, err
@@ -290,7 +344,7 @@ func verifyProcessUsers(db *sql.DB, host string, spec manifest.Spec) ([]Mismatch
 	return mismatched, nil
 }
 
-func lookForMissingProcesses(db *sql.DB, host string, spec manifest.Spec) ([]string, error) {
+func lookForMissingProcesses(db *sql.DB, host string, spec manifest.Spec, scanID int) ([]string, error) {
 	missingCommands := []string{}
 
 	for _, command := range spec.ExpectedCommands() {
@@ -303,7 +357,8 @@ func lookForMissingProcesses(db *sql.DB, host string, spec manifest.Spec) ([]str
 					ON processes.host_id = hosts.id
 			WHERE processes.name = ?
 				AND hosts.name = ?
-		`, command, host).Scan(&count)
+				AND hosts.scan_id = ?
+		`, command, host, scanID).Scan(&count)
 
 		if err != nil {
 			return nil, err
@@ -317,7 +372,7 @@ func lookForMissingProcesses(db *sql.DB, host string, spec manifest.Spec) ([]str
 	return missingCommands, nil
 }
 
-func lookForMissingPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, error) {
+func lookForMissingPorts(db *sql.DB, host string, spec manifest.Spec, scanID int) ([]Port, error) {
 	missingPorts := []Port{}
 
 	for _, port := range spec.ExpectedPorts() {
@@ -332,7 +387,8 @@ func lookForMissingPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, e
 					ON processes.host_id = hosts.id
 			WHERE ports.number = ?
 				AND hosts.name = ?
-		`, port, host).Scan(&count)
+				AND hosts.scan_id = ?
+		`, port, host, scanID).Scan(&count)
 
 		if err != nil {
 			return nil, err
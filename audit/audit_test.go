@@ -18,6 +18,7 @@ import (
 var _ = Describe("Audit", func() {
 	var (
 		database *db.Database
+		scan     db.Scan
 		tmpdir   string
 
 		hosts scanner.ScanResult
@@ -34,8 +35,14 @@ var _ = Describe("Audit", func() {
 	})
 
 	JustBeforeEach(func() {
-		err := database.SaveReport("cf1", hosts)
+		var err error
+		scan, err = database.StartScan("scantron bosh-scan", "operator")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = database.SaveReport(scan.ID, "cf1", hosts)
 		Expect(err).NotTo(HaveOccurred())
+
+		Expect(database.FinishScan(scan.ID)).To(Succeed())
 	})
 
 	AfterEach(func() {
@@ -112,7 +119,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a results that says everything is ok", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeTrue())
@@ -183,7 +190,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing the extra or missing host", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
@@ -192,6 +199,92 @@ var _ = Describe("Audit", func() {
 			})
 		})
 
+		Context("when an earlier scan found other hosts", func() {
+			BeforeEach(func() {
+				mani = manifest.Manifest{
+					Specs: []manifest.Spec{
+						{
+							Prefix: "host1",
+						},
+					},
+				}
+
+				hosts = scanner.ScanResult{
+					JobResults: []scanner.JobResult{
+						{
+							Job: "host1",
+						},
+					},
+				}
+
+				earlierScan, err := database.StartScan("scantron bosh-scan", "operator")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = database.SaveReport(earlierScan.ID, "cf1", scanner.ScanResult{
+					JobResults: []scanner.JobResult{
+						{
+							Job: "host2",
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(database.FinishScan(earlierScan.ID)).To(Succeed())
+			})
+
+			It("only audits the given scan", func() {
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.OK()).To(BeTrue())
+				Expect(result.ExtraHosts).To(BeEmpty())
+			})
+		})
+
+		Context("when a host could not be scanned", func() {
+			BeforeEach(func() {
+				mani = manifest.Manifest{
+					Specs: []manifest.Spec{
+						{
+							Prefix: "host1",
+						},
+					},
+				}
+
+				hosts = scanner.ScanResult{
+					Failures: []scanner.FailureResult{
+						{
+							Job:   "host1-1234567",
+							IP:    "10.0.0.1",
+							Stage: scanner.StageUpload,
+							Error: "disaster",
+						},
+					},
+				}
+			})
+
+			It("returns a result showing the failed host", func() {
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.OK()).To(BeFalse())
+				Expect(result.FailedHosts).To(ConsistOf(audit.FailedHost{
+					Name:  "host1-1234567",
+					IP:    "10.0.0.1",
+					Stage: "upload",
+					Error: "disaster",
+				}))
+			})
+
+			It("does not also report the host type as missing", func() {
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.MissingHostType).To(BeEmpty())
+				Expect(result.ExtraHosts).To(BeEmpty())
+			})
+		})
+
 		Context("when there is a missing process in the report", func() {
 			BeforeEach(func() {
 				mani = manifest.Manifest{
@@ -236,7 +329,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing the missing process", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
@@ -312,7 +405,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing the unexpected port", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
@@ -321,7 +414,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing the missing port", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
@@ -369,7 +462,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing incorrect values", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, scan.ID)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
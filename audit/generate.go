@@ -9,10 +9,10 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
-func GenerateManifest(writer io.Writer, db *sql.DB) error {
+func GenerateManifest(writer io.Writer, db *sql.DB, scanID int) error {
 	m := manifest.Manifest{}
 
-	specs, err := getSpecsFor(db)
+	specs, err := getSpecsFor(db, scanID)
 	if err != nil {
 		return err
 	}
@@ -27,8 +27,8 @@ func GenerateManifest(writer io.Writer, db *sql.DB) error {
 	return err
 }
 
-func getSpecsFor(db *sql.DB) ([]manifest.Spec, error) {
-	rows, err := db.Query(`SELECT hosts.id, hosts.name FROM hosts`)
+func getSpecsFor(db *sql.DB, scanID int) ([]manifest.Spec, error) {
+	rows, err := db.Query(`SELECT hosts.id, hosts.name FROM hosts WHERE hosts.scan_id = ?`, scanID)
 
 	if err != nil {
 		return nil, err
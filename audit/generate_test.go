@@ -42,9 +42,11 @@ var _ = Describe("Generate", func() {
 	})
 
 	JustBeforeEach(func() {
-		err := database.SaveReport("cf1", hosts)
+		scan, err := database.StartScan("scantron bosh-scan", "operator")
 		Expect(err).NotTo(HaveOccurred())
-		err = audit.GenerateManifest(writer, database.DB())
+		err = database.SaveReport(scan.ID, "cf1", hosts)
+		Expect(err).NotTo(HaveOccurred())
+		err = audit.GenerateManifest(writer, database.DB(), scan.ID)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
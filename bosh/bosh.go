@@ -2,6 +2,7 @@ package bosh
 
 import (
 	"bufio"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
@@ -33,6 +34,14 @@ type TargetDeploymentImpl struct {
 	signer     ssh.Signer
 	deployment boshdir.Deployment
 	logger     scanlog.Logger
+
+	hostKeys             map[string]ssh.PublicKey
+	allowMissingHostKeys bool
+	gateways             []scantron.Gateway
+
+	// directorGateway holds the key, username and host key callback for a
+	// gateway which the director tells us to use.
+	directorGateway scantron.Gateway
 }
 
 func GetDeployments(
@@ -40,8 +49,19 @@ func GetDeployments(
 	caCertPath string,
 	deploymentNames []string,
 	boshURL string,
+	gateways []scantron.Gateway,
+	directorGateway scantron.Gateway,
+	allowMissingHostKeys bool,
 	logger scanlog.Logger) ([]TargetDeployment, error) {
 
+	// The generated deployment key is not set up on gateways so it cannot
+	// be used for them.
+	for _, gateway := range gateways {
+		if gateway.Key == nil {
+			return nil, fmt.Errorf("no gateway private key was given for SSH gateway %s", gateway.Address)
+		}
+	}
+
 	var caCert string
 
 	if caCertPath != "" {
@@ -90,6 +110,10 @@ func GetDeployments(
 			signer:     signer,
 			deployment: deployment,
 			logger:     logger,
+			gateways:   gateways,
+
+			directorGateway:      directorGateway,
+			allowMissingHostKeys: allowMissingHostKeys,
 		})
 	}
 
@@ -118,24 +142,109 @@ func (d *TargetDeploymentImpl) Setup() error {
 	d.logger.Debugf("About to setup SSH for deployment %s", d.Name())
 	slug := boshdir.NewAllOrInstanceGroupOrInstanceSlug("", "")
 
-	_, err := d.deployment.SetUpSSH(slug, d.sshOpts)
+	result, err := d.deployment.SetUpSSH(slug, d.sshOpts)
 	if err != nil {
 		return err
 	}
 
+	d.hostKeys, err = HostKeys(result)
+	if err != nil {
+		return err
+	}
+
+	if len(d.gateways) == 0 && result.GatewayHost != "" {
+		gateway, err := DirectorGateway(result, d.directorGateway)
+		if err != nil {
+			return err
+		}
+
+		d.logger.Debugf("Connecting through gateway %s@%s returned by the director", gateway.Username, gateway.Address)
+		d.gateways = []scantron.Gateway{gateway}
+	}
+
 	return nil
 }
 
+// DirectorGateway is the gateway which the director returned, using the
+// key, host key callback and, when one is given, the username of
+// defaults. The gateway is not set up by the director so the generated
+// deployment key cannot be used for it.
+func DirectorGateway(result boshdir.SSHResult, defaults scantron.Gateway) (scantron.Gateway, error) {
+	if defaults.Key == nil {
+		return scantron.Gateway{}, fmt.Errorf("director returned SSH gateway %s but no gateway private key was given", result.GatewayHost)
+	}
+
+	gateway := defaults
+	gateway.Address = result.GatewayHost
+
+	if gateway.Username == "" {
+		gateway.Username = result.GatewayUsername
+	}
+
+	return gateway, nil
+}
+
 func (d *TargetDeploymentImpl) ConnectTo(vm boshdir.VMInfo) remotemachine.RemoteMachine {
 	stemcells, _ := d.deployment.Stemcells()
+	address := BestAddress(vm.IPs)
+
+	if _, found := d.hostKeys[address]; !found {
+		if d.allowMissingHostKeys {
+			d.logger.Warnf("Director did not return a host key for %s; it will not be verified", address)
+		} else {
+			d.logger.Errorf("Director did not return a host key for %s; it will not be scanned", address)
+		}
+	}
+
+	hostKeyCallback := HostKeyCallback(d.hostKeys, address, d.allowMissingHostKeys)
+
 	return remotemachine.NewRemoteMachine(scantron.Machine{
-		Address:  BestAddress(vm.IPs),
+		Address:  address,
 		Username: d.sshOpts.Username,
 		Key:      d.signer,
 		OSName:   stemcells[0].Name(),
+
+		HostKeyCallback: hostKeyCallback,
+		Gateways:        d.gateways,
 	})
 }
 
+// HostKeyCallback pins the host key which the director returned for the
+// address. Without one the connection is refused, unless missing host keys
+// are allowed, in which case the callback is nil and the key is not checked.
+func HostKeyCallback(hostKeys map[string]ssh.PublicKey, address string, allowMissing bool) ssh.HostKeyCallback {
+	if key, found := hostKeys[address]; found {
+		return remotemachine.PinnedHostKeyCallback(key)
+	}
+
+	if allowMissing {
+		return nil
+	}
+
+	return func(string, net.Addr, ssh.PublicKey) error {
+		return fmt.Errorf("director did not return a host key for %s", address)
+	}
+}
+
+func HostKeys(result boshdir.SSHResult) (map[string]ssh.PublicKey, error) {
+	hostKeys := map[string]ssh.PublicKey{}
+
+	for _, host := range result.Hosts {
+		if host.HostPublicKey == "" {
+			continue
+		}
+
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(host.HostPublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse host key for %s: %s", host.Host, err)
+		}
+
+		hostKeys[host.Host] = key
+	}
+
+	return hostKeys, nil
+}
+
 func (d *TargetDeploymentImpl) Cleanup() error {
 	d.logger.Debugf("About to cleanup SSH for deployment %s", d.Name())
 	slug := boshdir.NewAllOrInstanceGroupOrInstanceSlug("", "")
@@ -1,10 +1,19 @@
 package bosh_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+
+	boshconfig "github.com/cloudfoundry/bosh-cli/cmd/config"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
 
+	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/bosh"
+	"github.com/pivotal-cf/scantron/scanlog"
 )
 
 var _ = Describe("BestAddress", func() {
@@ -34,3 +43,123 @@ var _ = Describe("BestAddress", func() {
 		}).To(Panic())
 	})
 })
+
+var _ = Describe("HostKeys", func() {
+	var hostKey ssh.PublicKey
+
+	BeforeEach(func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		hostKey, err = ssh.NewPublicKey(&key.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("maps the host keys returned by the director to their addresses", func() {
+		hostKeys, err := bosh.HostKeys(boshdir.SSHResult{
+			Hosts: []boshdir.Host{
+				{Host: "10.0.0.1", HostPublicKey: string(ssh.MarshalAuthorizedKey(hostKey))},
+				{Host: "10.0.0.2"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(hostKeys).To(HaveLen(1))
+		Expect(hostKeys["10.0.0.1"].Marshal()).To(Equal(hostKey.Marshal()))
+	})
+
+	It("returns an error if a host key cannot be parsed", func() {
+		_, err := bosh.HostKeys(boshdir.SSHResult{
+			Hosts: []boshdir.Host{
+				{Host: "10.0.0.1", HostPublicKey: "garbage"},
+			},
+		})
+		Expect(err).To(MatchError(ContainSubstring("10.0.0.1")))
+	})
+})
+
+var _ = Describe("GetDeployments", func() {
+	It("returns an error when a gateway has no private key", func() {
+		_, err := bosh.GetDeployments(
+			boshconfig.Creds{},
+			"",
+			[]string{"cf"},
+			"https://director.example.com:25555",
+			[]scantron.Gateway{{Address: "jumpbox.example.com", Username: "ubuntu"}},
+			scantron.Gateway{},
+			false,
+			scanlog.NewNopLogger(),
+		)
+		Expect(err).To(MatchError(ContainSubstring("jumpbox.example.com")))
+	})
+})
+
+var _ = Describe("HostKeyCallback", func() {
+	var hostKeys map[string]ssh.PublicKey
+
+	BeforeEach(func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		hostKey, err := ssh.NewPublicKey(&key.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		hostKeys = map[string]ssh.PublicKey{"10.0.0.1": hostKey}
+	})
+
+	It("pins the host key returned by the director", func() {
+		callback := bosh.HostKeyCallback(hostKeys, "10.0.0.1", false)
+		Expect(callback("10.0.0.1:22", nil, hostKeys["10.0.0.1"])).To(Succeed())
+	})
+
+	It("refuses to connect when the director returned no host key", func() {
+		callback := bosh.HostKeyCallback(hostKeys, "10.0.0.2", false)
+		Expect(callback("10.0.0.2:22", nil, hostKeys["10.0.0.1"])).To(MatchError(ContainSubstring("10.0.0.2")))
+	})
+
+	It("does not check the host key when missing host keys are allowed", func() {
+		Expect(bosh.HostKeyCallback(hostKeys, "10.0.0.2", true)).To(BeNil())
+	})
+})
+
+var _ = Describe("DirectorGateway", func() {
+	var key ssh.Signer
+
+	BeforeEach(func() {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		key, err = ssh.NewSignerFromKey(privateKey)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	result := boshdir.SSHResult{
+		GatewayHost:     "jumpbox.example.com",
+		GatewayUsername: "vcap",
+	}
+
+	It("uses the given key and host key callback", func() {
+		gateway, err := bosh.DirectorGateway(result, scantron.Gateway{
+			Key:             key,
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(gateway.Address).To(Equal("jumpbox.example.com"))
+		Expect(gateway.Username).To(Equal("vcap"))
+		Expect(gateway.Key).To(Equal(key))
+		Expect(gateway.HostKeyCallback).NotTo(BeNil())
+	})
+
+	It("prefers the given username", func() {
+		gateway, err := bosh.DirectorGateway(result, scantron.Gateway{Username: "jump", Key: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(gateway.Username).To(Equal("jump"))
+	})
+
+	It("returns an error when no key was given", func() {
+		_, err := bosh.DirectorGateway(result, scantron.Gateway{})
+		Expect(err).To(MatchError(ContainSubstring("jumpbox.example.com")))
+	})
+})
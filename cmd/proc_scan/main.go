@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/jessevdk/go-flags"
 	"github.com/pivotal-cf/scantron/filesystem"
+	"github.com/pivotal-cf/scantron/httpscan"
 	"github.com/pivotal-cf/scantron/ssh"
 	"github.com/pivotal-cf/scantron/tlsscan"
 	"log"
@@ -17,9 +18,10 @@ import (
 
 func main() {
 	var opts struct {
-		Debug       bool               `long:"debug" description:"Show debug logs in output"`
-		Context     string             `long:"context" description:"Log context"`
-		FileRegexes scantron.FileMatch `group:"File Content Check"`
+		Debug       bool                    `long:"debug" description:"Show debug logs in output"`
+		Context     string                  `long:"context" description:"Log context"`
+		FileRegexes scantron.FileMatch      `group:"File Content Check"`
+		TLSScan     scantron.TLSScanOptions `group:"TLS Scan"`
 	}
 
 	_, err := flags.Parse(&opts)
@@ -36,8 +38,11 @@ func main() {
 	)
 
 	processScanner := process.ProcessScanner{
-		SysRes:  &process.SystemResourceImpl{},
-		TlsScan: &tlsscan.TlsScannerImpl{},
+		SysRes:   &process.SystemResourceImpl{},
+		TlsScan:  &tlsscan.TlsScannerImpl{Options: opts.TLSScan},
+		HttpScan: &httpscan.HttpScannerImpl{},
+
+		HttpConcurrency: opts.TLSScan.Concurrency,
 	}
 
 	processes, err := processScanner.ScanProcesses(logger)
@@ -74,5 +79,12 @@ func main() {
 		SSHKeys:   sshKeys,
 	}
 
+	sshConfiguration, err := ssh.ScanAlgorithms("localhost:22")
+	if err != nil {
+		logger.Errorf("Failed to scan ssh algorithms: %s", err)
+	} else {
+		systemInfo.SSHConfiguration = &sshConfiguration
+	}
+
 	json.NewEncoder(os.Stdout).Encode(systemInfo)
 }
@@ -65,8 +65,13 @@ var _ = Describe("Main", func() {
 			Expect(err).NotTo(HaveOccurred())
 			defer database.Close()
 
-			err = database.SaveReport("cf1", hosts)
+			scan, err := database.StartScan("scantron bosh-scan", "operator")
 			Expect(err).NotTo(HaveOccurred())
+
+			err = database.SaveReport(scan.ID, "cf1", hosts)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(database.FinishScan(scan.ID)).To(Succeed())
 		})
 
 		AfterEach(func() {
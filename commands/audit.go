@@ -15,6 +15,7 @@ var AuditError = ExitStatusError{message: "audit mismatch", exitStatus: 3}
 type AuditCommand struct {
 	Database string `long:"database" description:"path to report database" value-name:"PATH" default:"./database.db"`
 	Manifest string `long:"manifest" description:"path to manifest" required:"true" value-name:"PATH"`
+	ScanID   int    `long:"scan-id" description:"ID of the scan to audit (defaults to the latest scan)" value-name:"ID"`
 }
 
 func (command *AuditCommand) Execute(args []string) error {
@@ -28,7 +29,12 @@ func (command *AuditCommand) Execute(args []string) error {
 		return err
 	}
 
-	report, err := audit.Audit(db.DB(), man)
+	scan, err := findScan(db, command.ScanID)
+	if err != nil {
+		return err
+	}
+
+	report, err := audit.Audit(db.DB(), man, scan.ID)
 	if err != nil {
 		return err
 	}
@@ -37,6 +43,16 @@ func (command *AuditCommand) Execute(args []string) error {
 }
 
 func ShowReport(output io.Writer, report audit.AuditResult) error {
+	if len(report.FailedHosts) > 0 {
+		fmt.Fprintln(output, "found hosts which could not be scanned:")
+
+		for _, host := range report.FailedHosts {
+			fmt.Fprintf(output, "%s (%s) failed to %s: %s\n", host.Name, host.IP, host.Stage, host.Error)
+		}
+
+		fmt.Fprintln(output)
+	}
+
 	if len(report.ExtraHosts) > 0 {
 		fmt.Fprintln(output, "found hosts in report that were not matched in the manifest:")
 
@@ -42,5 +42,24 @@ var _ = Describe("Audit", func() {
 				Expect(err).To(HaveOccurred())
 			})
 		})
+
+		Context("When a host could not be scanned", func() {
+			BeforeEach(func() {
+				auditReport = audit.AuditResult{
+					FailedHosts: []audit.FailedHost{
+						{
+							Name:  "host1",
+							IP:    "10.0.0.1",
+							Stage: "connect",
+							Error: "connection refused",
+						},
+					},
+				}
+			})
+
+			It("returns the audit error", func() {
+				Expect(err).To(Equal(commands.AuditError))
+			})
+		})
 	})
 })
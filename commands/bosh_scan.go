@@ -5,13 +5,14 @@ import (
 	boshconfig "github.com/cloudfoundry/bosh-cli/cmd/config"
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/bosh"
-	"github.com/pivotal-cf/scantron/db"
 	"github.com/pivotal-cf/scantron/scanlog"
 	"github.com/pivotal-cf/scantron/scanner"
 	"log"
 	"sync"
 )
 
+var BoshScanError = ExitStatusError{message: "machines could not be scanned", exitStatus: 3}
+
 type BoshScanCommand struct {
 	Director struct {
 		URL         string   `long:"director-url" description:"BOSH Director URL" value-name:"URL" required:"true"`
@@ -23,9 +24,16 @@ type BoshScanCommand struct {
 		ClientSecret string `long:"client-secret" description:"Password or UAA client secret" value-name:"CLIENT_SECRET"`
 	} `group:"Director & Deployment"`
 
-	FileRegexes scantron.FileMatch `group:"File Content Check"`
+	HostKeys HostKeyVerification `group:"Host Key Verification"`
+	Gateway  GatewayOptions      `group:"SSH Gateway"`
+
+	AllowMissingHostKeys bool `long:"allow-missing-host-keys" description:"Scan VMs which the director returned no host key for without verifying their host key"`
+
+	FileRegexes scantron.FileMatch      `group:"File Content Check"`
+	TLSScan     scantron.TLSScanOptions `group:"TLS Scan"`
 
 	Database string `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
+	Append   bool   `long:"append" description:"Add the scan to the database if it already exists"`
 }
 
 func (command *BoshScanCommand) Execute(args []string) error {
@@ -37,6 +45,21 @@ func (command *BoshScanCommand) Execute(args []string) error {
 
 	logger.Debugf("Requested deployments to scan: %v", command.Director.Deployments)
 
+	hostKeyCallback, err := command.HostKeys.Callback()
+	if err != nil {
+		log.Fatalf("unable to load known hosts: %s", err.Error())
+	}
+
+	gateways, err := command.Gateway.Gateways(nil, hostKeyCallback)
+	if err != nil {
+		log.Fatalf("unable to set up SSH gateways: %s", err.Error())
+	}
+
+	gatewayKey, err := command.Gateway.Key(nil)
+	if err != nil {
+		log.Fatalf("unable to read gateway private key: %s", err.Error())
+	}
+
 	deployments, err := bosh.GetDeployments(
 		boshconfig.Creds{
 			Client:       command.Director.Client,
@@ -45,6 +68,14 @@ func (command *BoshScanCommand) Execute(args []string) error {
 		command.Director.CACert,
 		command.Director.Deployments,
 		command.Director.URL,
+		gateways,
+		scantron.Gateway{
+			Username: command.Gateway.User,
+			Key:      gatewayKey,
+
+			HostKeyCallback: hostKeyCallback,
+		},
+		command.AllowMissingHostKeys,
 		logger,
 	)
 
@@ -52,12 +83,18 @@ func (command *BoshScanCommand) Execute(args []string) error {
 		log.Fatalf("failed to set up director: %s", err.Error())
 	}
 
-	db, err := db.CreateDatabase(command.Database)
+	db, err := openScanDatabase(command.Database, command.Append)
+	if err != nil {
+		log.Fatalf("failed to open database: %s", err.Error())
+	}
+
+	scan, err := startScan(db)
 	if err != nil {
-		log.Fatalf("failed to create database: %s", err.Error())
+		log.Fatalf("failed to start scan: %s", err.Error())
 	}
 
 	m := sync.Mutex{}
+	failed := false
 	wg := &sync.WaitGroup{}
 	wg.Add(len(deployments))
 	for _, d := range deployments {
@@ -66,25 +103,40 @@ func (command *BoshScanCommand) Execute(args []string) error {
 			defer wg.Done()
 
 			logger.Debugf("About to scan: %s", dep.Name())
-			results, err := scanner.Bosh(dep).Scan(&command.FileRegexes, logger)
-			if err != nil {
-				log.Fatalf("failed to scan: %s", err.Error())
-			}
+			results, err := scanner.Bosh(dep).Scan(&command.FileRegexes, &command.TLSScan, logger)
 
 			m.Lock()
 			defer m.Unlock()
-			err = db.SaveReport(dep.Name(), results)
+
+			if err != nil {
+				abandonScan(db, scan.ID, "failed to scan: %s", err.Error())
+			}
+
+			err = db.SaveReport(scan.ID, dep.Name(), results)
 			if err != nil {
-				log.Fatalf("failed to save to database: %s", err.Error())
+				abandonScan(db, scan.ID, "failed to save to database: %s", err.Error())
+			}
+
+			if len(results.Failures) > 0 {
+				failed = true
 			}
 		}(d)
 	}
 
 	wg.Wait()
 
+	err = db.FinishScan(scan.ID)
+	if err != nil {
+		abandonScan(db, scan.ID, "failed to save to database: %s", err.Error())
+	}
+
 	db.Close()
 
-	fmt.Println("Report is saved in SQLite3 database:", command.Database)
+	fmt.Printf("Report is saved in SQLite3 database: %s (scan %d)\n", command.Database, scan.ID)
+
+	if failed {
+		return BoshScanError
+	}
 
 	return nil
 }
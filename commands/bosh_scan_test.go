@@ -0,0 +1,135 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+var _ = Describe("BoshScan", func() {
+	var (
+		tmpdir, databasePath, caCertPath string
+
+		director *httptest.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "bosh-scan-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		director = httptest.NewTLSServer(fakeDirector("deployment", "127.0.0.1"))
+
+		caCertPath = filepath.Join(tmpdir, "ca.pem")
+		caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: director.Certificate().Raw})
+		err = ioutil.WriteFile(caCertPath, caCert, 0600)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		director.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	Context("when a machine cannot be scanned", func() {
+		It("records the failure and exits with an error", func() {
+			session := runCommand("bosh-scan",
+				"--database", databasePath,
+				"--director-url", director.URL,
+				"--ca-cert", caCertPath,
+				"--bosh-deployment", "deployment",
+			)
+			Expect(session).To(Exit(3))
+
+			database, err := db.OpenDatabase(databasePath)
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			scan, err := database.LatestScan()
+			Expect(err).NotTo(HaveOccurred())
+
+			var name, ip string
+			err = database.DB().QueryRow("SELECT name, ip FROM scan_failures WHERE scan_id = ?", scan.ID).Scan(&name, &ip)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("job/vm-id"))
+			Expect(ip).To(Equal("127.0.0.1"))
+		})
+	})
+})
+
+// fakeDirector answers the requests which bosh-scan makes for a deployment
+// with a single VM. Every task finishes straight away.
+func fakeDirector(deployment string, ip string) http.Handler {
+	vm, _ := json.Marshal(map[string]interface{}{
+		"job_name": "job",
+		"id":       "vm-id",
+		"index":    0,
+		"ips":      []string{ip},
+	})
+
+	ssh, _ := json.Marshal([]map[string]interface{}{
+		{"status": "success", "job": "job", "id": "vm-id", "ip": ip},
+	})
+
+	taskResults := map[string]string{
+		"/tasks/1/output": string(vm) + "\n",
+		"/tasks/2/output": string(ssh),
+		"/tasks/3/output": "",
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"fake","uuid":"fake-uuid","version":"1.0.0","user_authentication":{"type":"basic"}}`)
+	})
+
+	mux.HandleFunc("/deployments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"name":%q,"releases":[{"name":"release","version":"1"}],"stemcells":[{"name":"ubuntu-xenial","version":"1"}]}]`, deployment)
+	})
+
+	mux.HandleFunc("/deployments/"+deployment+"/vms", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/tasks/1", http.StatusFound)
+	})
+
+	mux.HandleFunc("/deployments/"+deployment+"/ssh", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Command string `json:"command"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if body.Command == "setup" {
+			http.Redirect(w, r, "/tasks/2", http.StatusFound)
+		} else {
+			http.Redirect(w, r, "/tasks/3", http.StatusFound)
+		}
+	})
+
+	mux.HandleFunc("/tasks/", func(w http.ResponseWriter, r *http.Request) {
+		result, isOutput := taskResults[r.URL.Path]
+		if !isOutput {
+			var id int
+			fmt.Sscanf(r.URL.Path, "/tasks/%d", &id)
+			fmt.Fprintf(w, `{"id":%d,"state":"done"}`, id)
+			return
+		}
+
+		if r.URL.Query().Get("type") == "result" {
+			fmt.Fprint(w, result)
+		}
+	})
+
+	return mux
+}
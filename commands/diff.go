@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/diff"
+)
+
+var DiffError = ExitStatusError{message: "security-relevant changes found", exitStatus: 3}
+
+type DiffCommand struct {
+	Database     string `long:"database" description:"path to report database with the newer scan" value-name:"PATH" default:"./database.db"`
+	ScanID       int    `long:"scan-id" description:"ID of the newer scan (defaults to the latest scan)" value-name:"ID"`
+	BaseDatabase string `long:"base-database" description:"path to report database with the older scan (defaults to --database)" value-name:"PATH"`
+	BaseScanID   int    `long:"base-scan-id" description:"ID of the older scan (defaults to the scan before --scan-id, or the latest scan in --base-database)" value-name:"ID"`
+	Format       string `long:"format" description:"output format" choice:"table" choice:"json" choice:"markdown" default:"table"`
+}
+
+func (command *DiffCommand) Execute(args []string) error {
+	database, err := db.OpenDatabase(command.Database)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	scan, err := findScan(database, command.ScanID)
+	if err != nil {
+		return err
+	}
+
+	baseDatabase := database
+	if command.BaseDatabase != "" {
+		baseDatabase, err = db.OpenDatabase(command.BaseDatabase)
+		if err != nil {
+			return err
+		}
+		defer baseDatabase.Close()
+	}
+
+	var baseScan db.Scan
+
+	switch {
+	case command.BaseScanID != 0:
+		baseScan, err = baseDatabase.Scan(command.BaseScanID)
+	case command.BaseDatabase != "":
+		baseScan, err = baseDatabase.LatestScan()
+	default:
+		baseScan, err = database.ScanBefore(scan.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := diff.Compare(baseDatabase.DB(), baseScan.ID, database.DB(), scan.ID)
+	if err != nil {
+		return err
+	}
+
+	switch command.Format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(result)
+		if err != nil {
+			return err
+		}
+	case "markdown":
+		result.Report().WriteMarkdown(os.Stdout)
+	default:
+		result.Report().WriteTo(os.Stdout)
+	}
+
+	if result.SecurityRelevant() {
+		return DiffError
+	}
+
+	return nil
+}
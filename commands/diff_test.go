@@ -0,0 +1,152 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/diff"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+var _ = Describe("Diff", func() {
+	var (
+		tmpdir, databasePath string
+		database             *db.Database
+		firstScan            db.Scan
+	)
+
+	saveScan := func(database *db.Database, jobs ...string) db.Scan {
+		scan, err := database.StartScan("scantron bosh-scan", "operator")
+		Expect(err).NotTo(HaveOccurred())
+
+		result := scanner.ScanResult{}
+		for _, job := range jobs {
+			result.JobResults = append(result.JobResults, scanner.JobResult{Job: job})
+		}
+
+		err = database.SaveReport(scan.ID, "cf1", result)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(database.FinishScan(scan.ID)).To(Succeed())
+
+		return scan
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "diff-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		databasePath = filepath.Join(tmpdir, "db.db")
+		database, err = db.CreateDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		firstScan = saveScan(database, "host1", "host2")
+	})
+
+	AfterEach(func() {
+		database.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	Context("when a host was added since the previous scan", func() {
+		BeforeEach(func() {
+			saveScan(database, "host1", "host2", "host3")
+		})
+
+		It("shows the change and exits with an error", func() {
+			session := runCommand("diff", "--database", databasePath)
+
+			Expect(session).To(Exit(3))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+IP\s+\|\s+CHANGE\s+\|\s+SUBJECT\s+\|\s+BEFORE\s+\|\s+AFTER\s+\|\s+SECURITY-RELEVANT\s+\|`))
+			Expect(session.Out).To(Say(`\|\s+host3\s+\|\s+\|\s+host added\s+\|\s+\|\s+\|\s+\|\s+yes\s+\|`))
+		})
+
+		It("can show the changes as json", func() {
+			session := runCommand("diff", "--database", databasePath, "--format", "json")
+			Expect(session).To(Exit(3))
+
+			var result diff.Result
+			err := json.Unmarshal(session.Out.Contents(), &result)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Changes).To(Equal([]diff.Change{
+				{Host: "host3", Kind: diff.HostAdded, Security: true},
+			}))
+		})
+
+		It("can show the changes as markdown", func() {
+			session := runCommand("diff", "--database", databasePath, "--format", "markdown")
+			Expect(session).To(Exit(3))
+
+			Expect(session.Out).To(Say(`### Changes between scans`))
+			Expect(session.Out).To(Say(`\| Identity \| IP \| Change \| Subject \| Before \| After \| Security-Relevant \|`))
+			Expect(session.Out).To(Say(`\| host3 \|  \| host added \|  \|  \|  \| yes \|`))
+		})
+	})
+
+	Context("when a host was removed since the previous scan", func() {
+		BeforeEach(func() {
+			saveScan(database, "host1")
+		})
+
+		It("shows the change but does not consider it security-relevant", func() {
+			session := runCommand("diff", "--database", databasePath)
+
+			Expect(session).To(Exit(0))
+			Expect(session.Out).To(Say(`\|\s+host2\s+\|\s+\|\s+host removed\s+\|`))
+		})
+	})
+
+	Context("when comparing with an older scan by id", func() {
+		BeforeEach(func() {
+			saveScan(database, "host1", "host2", "host3")
+			saveScan(database, "host1", "host2", "host3")
+		})
+
+		It("compares the given scans", func() {
+			session := runCommand("diff", "--database", databasePath, "--base-scan-id", strconv.Itoa(firstScan.ID))
+
+			Expect(session).To(Exit(3))
+			Expect(session.Out).To(Say(`host3`))
+		})
+	})
+
+	Context("when comparing scans in two databases", func() {
+		var basePath string
+
+		BeforeEach(func() {
+			basePath = filepath.Join(tmpdir, "base.db")
+			baseDatabase, err := db.CreateDatabase(basePath)
+			Expect(err).NotTo(HaveOccurred())
+			defer baseDatabase.Close()
+
+			saveScan(baseDatabase, "host1", "host2", "host4")
+		})
+
+		It("compares the latest scan in each database", func() {
+			session := runCommand("diff", "--database", databasePath, "--base-database", basePath)
+
+			Expect(session).To(Exit(0))
+			Expect(session.Out).To(Say(`\|\s+host4\s+\|\s+\|\s+host removed\s+\|`))
+		})
+	})
+
+	Context("when there is no previous scan", func() {
+		It("exits with an error", func() {
+			session := runCommand("diff", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+			Expect(session.Err).To(Say("no scan found before scan"))
+		})
+	})
+})
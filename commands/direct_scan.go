@@ -8,12 +8,13 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	"github.com/pivotal-cf/scantron"
-	"github.com/pivotal-cf/scantron/db"
 	"github.com/pivotal-cf/scantron/remotemachine"
 	"github.com/pivotal-cf/scantron/scanlog"
 	"github.com/pivotal-cf/scantron/scanner"
 )
 
+var DirectScanError = ExitStatusError{message: "machine could not be scanned", exitStatus: 3}
+
 type DirectScanCommand struct {
 	Address    string `long:"address" description:"Address of machine to scan" value-name:"ADDRESS" required:"true"`
 	Username   string `long:"username" description:"Username of machine to scan" value-name:"USERNAME" required:"true"`
@@ -21,8 +22,15 @@ type DirectScanCommand struct {
 	PrivateKey string `long:"private-key" description:"Private key of machine to scan" value-name:"PATH"`
 	Database   string `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
 	OSName     string `long:"os-name" description:"Name of stemcell OS of machine to scan" value-name:"STRING" required:"true"`
+	Append     bool   `long:"append" description:"Add the scan to the database if it already exists"`
+
+	HostKeys HostKeyVerification `group:"Host Key Verification"`
+	Gateway  GatewayOptions      `group:"SSH Gateway"`
+
+	AllowUnverifiedHostKeys bool `long:"allow-unverified-host-keys" description:"Scan without verifying host keys when no known_hosts file is given"`
 
-	FileRegexes scantron.FileMatch `group:"File Content Check"`
+	FileRegexes scantron.FileMatch      `group:"File Content Check"`
+	TLSScan     scantron.TLSScanOptions `group:"TLS Scan"`
 }
 
 func (command *DirectScanCommand) Execute(args []string) error {
@@ -46,35 +54,66 @@ func (command *DirectScanCommand) Execute(args []string) error {
 		}
 	}
 
+	hostKeyCallback, err := command.HostKeys.MachineCallback(command.AllowUnverifiedHostKeys, logger)
+	if err != nil {
+		log.Fatalf("unable to verify host keys: %s", err.Error())
+	}
+
+	gateways, err := command.Gateway.Gateways(privateKey, hostKeyCallback)
+	if err != nil {
+		log.Fatalf("unable to set up SSH gateways: %s", err.Error())
+	}
+
 	machine := scantron.Machine{
 		Address:  command.Address,
 		Username: command.Username,
 		Password: command.Password,
 		Key:      privateKey,
 		OSName:   command.OSName,
+
+		HostKeyCallback: hostKeyCallback,
+		Gateways:        gateways,
 	}
 
 	remoteMachine := remotemachine.NewRemoteMachine(machine)
 	defer remoteMachine.Close()
 
-	db, err := db.CreateDatabase(command.Database)
+	db, err := openScanDatabase(command.Database, command.Append)
 	if err != nil {
-		log.Fatalf("failed to create database: %s", err.Error())
+		log.Fatalf("failed to open database: %s", err.Error())
 	}
 
-	results, err := scanner.Direct(remoteMachine).Scan(&command.FileRegexes, logger)
+	scan, err := startScan(db)
 	if err != nil {
-		log.Fatalf("failed to scan: %s", err.Error())
+		log.Fatalf("failed to start scan: %s", err.Error())
 	}
 
-	err = db.SaveReport("direct-scan", results)
+	results, err := scanner.Direct(remoteMachine).Scan(&command.FileRegexes, &command.TLSScan, logger)
 	if err != nil {
-		log.Fatalf("failed to save to database: %s", err.Error())
+		results = scanner.ScanResult{
+			Failures: []scanner.FailureResult{
+				scanner.BuildFailureResult(err, remoteMachine.Host(), remoteMachine.Host()),
+			},
+		}
+	}
+
+	err = db.SaveReport(scan.ID, "direct-scan", results)
+	if err != nil {
+		abandonScan(db, scan.ID, "failed to save to database: %s", err.Error())
+	}
+
+	err = db.FinishScan(scan.ID)
+	if err != nil {
+		abandonScan(db, scan.ID, "failed to save to database: %s", err.Error())
 	}
 
 	db.Close()
 
-	fmt.Println("Report saved in SQLite3 database:", command.Database)
+	fmt.Printf("Report saved in SQLite3 database: %s (scan %d)\n", command.Database, scan.ID)
+
+	if len(results.Failures) > 0 {
+		return DirectScanError
+	}
 
 	return nil
 }
@@ -0,0 +1,74 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+var _ = Describe("DirectScan", func() {
+	var tmpdir, databasePath string
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "direct-scan-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		databasePath = filepath.Join(tmpdir, "db.db")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	Context("when the machine cannot be scanned", func() {
+		It("records the failure and exits with an error", func() {
+			session := runCommand("direct-scan",
+				"--database", databasePath,
+				"--address", "127.0.0.1",
+				"--username", "vcap",
+				"--password", "hunter2",
+				"--os-name", "ubuntu-xenial",
+				"--allow-unverified-host-keys",
+			)
+			Expect(session).To(Exit(3))
+			Expect(session.Err).To(Say("host keys will not be verified"))
+
+			database, err := db.OpenDatabase(databasePath)
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			scan, err := database.LatestScan()
+			Expect(err).NotTo(HaveOccurred())
+
+			var ip, stage string
+			err = database.DB().QueryRow("SELECT ip, stage FROM scan_failures WHERE scan_id = ?", scan.ID).Scan(&ip, &stage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip).To(Equal("127.0.0.1"))
+			Expect(stage).NotTo(BeEmpty())
+		})
+	})
+
+	Context("when no known_hosts file is given", func() {
+		It("refuses to scan without verifying host keys unless told to", func() {
+			session := runCommand("direct-scan",
+				"--database", databasePath,
+				"--address", "127.0.0.1",
+				"--username", "vcap",
+				"--password", "hunter2",
+				"--os-name", "ubuntu-xenial",
+			)
+			Expect(session).To(Exit(1))
+			Expect(session.Err).To(Say("--allow-unverified-host-keys"))
+
+			Expect(databasePath).NotTo(BeAnExistingFile())
+		})
+	})
+})
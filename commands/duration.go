@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a flag which, as well as everything time.ParseDuration accepts,
+// can be given in days ("30d") or weeks ("2w").
+type Duration time.Duration
+
+var durationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+func (d *Duration) UnmarshalFlag(value string) error {
+	for suffix, unit := range durationUnits {
+		if !strings.HasSuffix(value, suffix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid duration: %s", value)
+		}
+
+		*d = Duration(time.Duration(n) * unit)
+		return nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return fmt.Errorf("invalid duration: %s", value)
+	}
+
+	*d = Duration(duration)
+	return nil
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+type GatewayOptions struct {
+	Hosts      []string `long:"gateway-host" description:"SSH gateway to connect through ([USER@]HOST[:PORT]), repeat to hop through several gateways in order" value-name:"HOST"`
+	User       string   `long:"gateway-user" description:"Username for SSH gateways which do not specify one" value-name:"USERNAME"`
+	PrivateKey string   `long:"gateway-private-key" description:"Private key for SSH gateways" value-name:"PATH"`
+}
+
+// Gateways builds the gateway hops. The fallback key is used when no gateway
+// private key is given. Gateway host keys must be verified, so a host key
+// callback is required when there are gateways.
+func (o GatewayOptions) Gateways(fallbackKey ssh.Signer, hostKeyCallback ssh.HostKeyCallback) ([]scantron.Gateway, error) {
+	if len(o.Hosts) == 0 {
+		return nil, nil
+	}
+
+	if hostKeyCallback == nil {
+		return nil, errors.New("--known-hosts is required to verify the host keys of SSH gateways")
+	}
+
+	key, err := o.Key(fallbackKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gateways := []scantron.Gateway{}
+	for _, host := range o.Hosts {
+		username := o.User
+		address := host
+
+		if at := strings.LastIndex(host, "@"); at != -1 {
+			username = host[:at]
+			address = host[at+1:]
+		}
+
+		if username == "" {
+			return nil, errors.New("no username given for SSH gateway " + address)
+		}
+
+		gateways = append(gateways, scantron.Gateway{
+			Address:  address,
+			Username: username,
+			Key:      key,
+
+			HostKeyCallback: hostKeyCallback,
+		})
+	}
+
+	return gateways, nil
+}
+
+// Key reads the gateway private key, or returns the fallback key when none is
+// given.
+func (o GatewayOptions) Key(fallbackKey ssh.Signer) (ssh.Signer, error) {
+	if o.PrivateKey == "" {
+		return fallbackKey, nil
+	}
+
+	bs, err := ioutil.ReadFile(o.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.ParsePrivateKey(bs)
+}
@@ -9,6 +9,7 @@ import (
 
 type GenerateManifestCommand struct {
 	Database string `long:"database" description:"path to report database" value-name:"PATH" default:"./database.db"`
+	ScanID   int    `long:"scan-id" description:"ID of the scan to generate the manifest from (defaults to the latest scan)" value-name:"ID"`
 }
 
 func (command *GenerateManifestCommand) Execute(args []string) error {
@@ -17,5 +18,10 @@ func (command *GenerateManifestCommand) Execute(args []string) error {
 		return err
 	}
 
-	return audit.GenerateManifest(os.Stdout, db.DB())
+	scan, err := findScan(db, command.ScanID)
+	if err != nil {
+		return err
+	}
+
+	return audit.GenerateManifest(os.Stdout, db.DB(), scan.ID)
 }
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pivotal-cf/scantron/remotemachine"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+type HostKeyVerification struct {
+	KnownHosts     string `long:"known-hosts" description:"Verify host keys against this known_hosts file" value-name:"PATH"`
+	KnownHostsMode string `long:"known-hosts-mode" description:"Reject unknown hosts (strict) or add them to the known_hosts file (tofu)" choice:"strict" choice:"tofu" default:"strict"`
+}
+
+func (v HostKeyVerification) Callback() (ssh.HostKeyCallback, error) {
+	if v.KnownHosts == "" {
+		return nil, nil
+	}
+
+	return remotemachine.KnownHostsCallback(v.KnownHosts, v.KnownHostsMode == "tofu")
+}
+
+// MachineCallback is the callback for machines which have no other source of
+// host keys. Without a known_hosts file their host keys are only left
+// unchecked when allowUnverified is set.
+func (v HostKeyVerification) MachineCallback(allowUnverified bool, logger scanlog.Logger) (ssh.HostKeyCallback, error) {
+	if v.KnownHosts == "" {
+		if !allowUnverified {
+			return nil, errors.New("no known_hosts file was given, pass --allow-unverified-host-keys to scan without verifying host keys")
+		}
+
+		logger.Warnf("No known_hosts file was given; host keys will not be verified")
+	}
+
+	return v.Callback()
+}
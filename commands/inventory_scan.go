@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"golang.org/x/crypto/ssh"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/remotemachine"
+	"github.com/pivotal-cf/scantron/scanlog"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+var InventoryScanError = ExitStatusError{message: "machines could not be scanned", exitStatus: 3}
+
+type InventoryScanCommand struct {
+	Inventory  string `long:"inventory" description:"Path to inventory of machines to scan" value-name:"PATH" required:"true"`
+	Deployment string `long:"deployment" description:"Name to save the scanned machines under" value-name:"NAME" default:"inventory-scan"`
+	PrivateKey string `long:"private-key" description:"Private key of machines to scan" value-name:"PATH"`
+	Database   string `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
+	OSName     string `long:"os-name" description:"Name of stemcell OS of machines to scan" value-name:"STRING" required:"true"`
+	Append     bool   `long:"append" description:"Add the scan to the database if it already exists"`
+
+	HostKeys HostKeyVerification `group:"Host Key Verification"`
+	Gateway  GatewayOptions      `group:"SSH Gateway"`
+
+	AllowUnverifiedHostKeys bool `long:"allow-unverified-host-keys" description:"Scan without verifying host keys when no known_hosts file is given"`
+
+	FileRegexes scantron.FileMatch      `group:"File Content Check"`
+	TLSScan     scantron.TLSScanOptions `group:"TLS Scan"`
+}
+
+func (command *InventoryScanCommand) Execute(args []string) error {
+	scantron.SetDebug(Scantron.Debug)
+	logger, err := scanlog.NewLogger(Scantron.Debug)
+	if err != nil {
+		log.Fatalln("failed to set up logger:", err)
+	}
+
+	inventory, err := loadInventory(command.Inventory)
+	if err != nil {
+		log.Fatalf("failed to load inventory: %s", err.Error())
+	}
+
+	var privateKey ssh.Signer
+
+	if command.PrivateKey != "" {
+		key, err := ioutil.ReadFile(command.PrivateKey)
+		if err != nil {
+			log.Fatalf("unable to read private key: %s", err.Error())
+		}
+
+		privateKey, err = ssh.ParsePrivateKey(key)
+		if err != nil {
+			log.Fatalf("unable to parse private key: %s", err.Error())
+		}
+	}
+
+	hostKeyCallback, err := command.HostKeys.MachineCallback(command.AllowUnverifiedHostKeys, logger)
+	if err != nil {
+		log.Fatalf("unable to verify host keys: %s", err.Error())
+	}
+
+	gateways, err := command.Gateway.Gateways(privateKey, hostKeyCallback)
+	if err != nil {
+		log.Fatalf("unable to set up SSH gateways: %s", err.Error())
+	}
+
+	hosts := []scanner.InventoryHost{}
+	for _, host := range inventory.Hosts {
+		inventoryHost := scanner.InventoryHost{Name: host.Name}
+
+		for _, address := range host.Addresses {
+			inventoryHost.Machines = append(inventoryHost.Machines, remotemachine.NewRemoteMachine(scantron.Machine{
+				Address:  address,
+				Username: host.Username,
+				Password: host.Password,
+				Key:      privateKey,
+				OSName:   command.OSName,
+
+				HostKeyCallback: hostKeyCallback,
+				Gateways:        gateways,
+			}))
+		}
+
+		hosts = append(hosts, inventoryHost)
+	}
+
+	db, err := openScanDatabase(command.Database, command.Append)
+	if err != nil {
+		log.Fatalf("failed to open database: %s", err.Error())
+	}
+
+	scan, err := startScan(db)
+	if err != nil {
+		log.Fatalf("failed to start scan: %s", err.Error())
+	}
+
+	results, err := scanner.Inventory(hosts).Scan(&command.FileRegexes, &command.TLSScan, logger)
+	if err != nil {
+		abandonScan(db, scan.ID, "failed to scan: %s", err.Error())
+	}
+
+	err = db.SaveReport(scan.ID, command.Deployment, results)
+	if err != nil {
+		abandonScan(db, scan.ID, "failed to save to database: %s", err.Error())
+	}
+
+	err = db.FinishScan(scan.ID)
+	if err != nil {
+		abandonScan(db, scan.ID, "failed to save to database: %s", err.Error())
+	}
+
+	db.Close()
+
+	fmt.Printf("Report saved in SQLite3 database: %s (scan %d)\n", command.Database, scan.ID)
+
+	if len(results.Failures) > 0 {
+		return InventoryScanError
+	}
+
+	return nil
+}
+
+func loadInventory(path string) (scantron.Inventory, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return scantron.Inventory{}, err
+	}
+
+	var inventory scantron.Inventory
+
+	err = yaml.Unmarshal(bs, &inventory)
+	if err != nil {
+		return scantron.Inventory{}, errors.New("incorrect yaml format")
+	}
+
+	if len(inventory.Hosts) == 0 {
+		return scantron.Inventory{}, errors.New("inventory has no hosts")
+	}
+
+	return inventory, nil
+}
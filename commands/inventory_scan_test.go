@@ -0,0 +1,80 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+var _ = Describe("InventoryScan", func() {
+	var tmpdir, databasePath, inventoryPath string
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "inventory-scan-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		databasePath = filepath.Join(tmpdir, "db.db")
+		inventoryPath = filepath.Join(tmpdir, "hosts.yml")
+
+		err = ioutil.WriteFile(inventoryPath, []byte(`hosts:
+- name: host
+  username: vcap
+  password: hunter2
+  addresses:
+  - 127.0.0.1
+`), 0600)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	Context("when a machine cannot be scanned", func() {
+		It("records the failure and exits with an error", func() {
+			session := runCommand("inventory-scan",
+				"--database", databasePath,
+				"--inventory", inventoryPath,
+				"--os-name", "ubuntu-xenial",
+				"--allow-unverified-host-keys",
+			)
+			Expect(session).To(Exit(3))
+			Expect(session.Err).To(Say("host keys will not be verified"))
+
+			database, err := db.OpenDatabase(databasePath)
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			scan, err := database.LatestScan()
+			Expect(err).NotTo(HaveOccurred())
+
+			var name, ip string
+			err = database.DB().QueryRow("SELECT name, ip FROM scan_failures WHERE scan_id = ?", scan.ID).Scan(&name, &ip)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("host"))
+			Expect(ip).To(Equal("127.0.0.1"))
+		})
+	})
+
+	Context("when no known_hosts file is given", func() {
+		It("refuses to scan without verifying host keys unless told to", func() {
+			session := runCommand("inventory-scan",
+				"--database", databasePath,
+				"--inventory", inventoryPath,
+				"--os-name", "ubuntu-xenial",
+			)
+			Expect(session).To(Exit(1))
+			Expect(session.Err).To(Say("--allow-unverified-host-keys"))
+
+			Expect(databasePath).NotTo(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+type MigrateCommand struct {
+	Database string `long:"database" description:"path to report database" value-name:"PATH" default:"./database.db"`
+}
+
+func (command *MigrateCommand) Execute(args []string) error {
+	from, err := db.MigrateDatabase(command.Database)
+	if err != nil {
+		return err
+	}
+
+	if from == db.SchemaVersion {
+		fmt.Printf("Database is already at the latest version (%d)\n", db.SchemaVersion)
+		return nil
+	}
+
+	fmt.Printf("Migrated database from version %d to %d\n", from, db.SchemaVersion)
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+var _ = Describe("Migrate", func() {
+	var databasePath, tmpdir string
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "migrate-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("leaves a database at the latest version alone", func() {
+		database, err := db.CreateDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+		database.Close()
+
+		session := runCommand("migrate", "--database", databasePath)
+
+		Expect(session).To(Exit(0))
+		Expect(session.Out).To(Say(`Database is already at the latest version`))
+	})
+
+	It("exits with an error when the database does not exist", func() {
+		session := runCommand("migrate", "--database", databasePath)
+
+		Expect(session).To(Exit(1))
+	})
+})
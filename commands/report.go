@@ -4,16 +4,22 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"time"
 
 	"encoding/csv"
 
 	"github.com/pivotal-cf/scantron/db"
 	"github.com/pivotal-cf/scantron/report"
+	"github.com/pivotal-cf/scantron/tlspolicy"
 )
 
 type ReportCommand struct {
-	Database      string `long:"database" description:"path to report database" required:"true" value-name:"DB PATH"`
-	CsvExportPath string `long:"csv" description:"path to csv output" value-name:"CSV PATH"`
+	Database      string   `long:"database" description:"path to report database" required:"true" value-name:"DB PATH"`
+	CsvExportPath string   `long:"csv" description:"path to csv output" value-name:"CSV PATH"`
+	ScanID        int      `long:"scan-id" description:"ID of the scan to report on (defaults to the latest scan)" value-name:"ID"`
+	TLSPolicyPath string   `long:"tls-policy" description:"path to a policy of approved TLS settings (defaults to TLS 1.2 and TLS 1.3 with IANA recommended ciphers)" value-name:"POLICY PATH"`
+	CertExpiry    Duration `long:"cert-expiry-warning" description:"report certificates which expire within this long (e.g. 30d, 2w, 12h)" value-name:"DURATION" default:"30d"`
+	Format        string   `long:"format" description:"output format" choice:"table" choice:"json" choice:"sarif" choice:"junit" choice:"markdown" default:"table"`
 }
 
 func (command *ReportCommand) Execute(args []string) error {
@@ -22,22 +28,77 @@ func (command *ReportCommand) Execute(args []string) error {
 		return err
 	}
 
-	rootReport, err := report.BuildRootProcessesReport(database)
+	scan, err := findScan(database, command.ScanID)
 	if err != nil {
 		return err
 	}
 
-	tlsReport, err := report.BuildTLSViolationsReport(database)
+	failuresReport, err := report.BuildScanFailuresReport(database, scan.ID)
 	if err != nil {
 		return err
 	}
 
-	filesReport, err := report.BuildWorldReadableFilesReport(database)
+	rootReport, err := report.BuildRootProcessesReport(database, scan.ID)
 	if err != nil {
 		return err
 	}
 
-	sshKeysReport, err := report.BuildInsecureSshKeyReport(database)
+	tlsPolicy, err := loadTLSPolicy(command.TLSPolicyPath)
+	if err != nil {
+		return err
+	}
+
+	privilegedReport, err := report.BuildPrivilegedProcessesReport(database, scan.ID)
+	if err != nil {
+		return err
+	}
+
+	deletedExecutablesReport, err := report.BuildDeletedExecutablesReport(database, scan.ID)
+	if err != nil {
+		return err
+	}
+
+	tlsReport, err := report.BuildTLSViolationsReport(database, scan.ID, tlsPolicy)
+	if err != nil {
+		return err
+	}
+
+	incompleteTLSReport, err := report.BuildIncompleteTLSScanReport(database, scan.ID)
+	if err != nil {
+		return err
+	}
+
+	expiryReport, err := report.BuildCertificateExpiryReport(database, scan.ID, time.Duration(command.CertExpiry))
+	if err != nil {
+		return err
+	}
+
+	weakKeyReport, err := report.BuildWeakKeyReport(database, scan.ID)
+	if err != nil {
+		return err
+	}
+
+	httpReport, err := report.BuildHTTPHeadersReport(database, scan.ID)
+	if err != nil {
+		return err
+	}
+
+	filesReport, err := report.BuildWorldReadableFilesReport(database, scan.ID)
+	if err != nil {
+		return err
+	}
+
+	socketsReport, err := report.BuildWritableSocketsReport(database, scan.ID)
+	if err != nil {
+		return err
+	}
+
+	sshKeysReport, err := report.BuildInsecureSshKeyReport(database, scan.ID)
+	if err != nil {
+		return err
+	}
+
+	weakSSHReport, err := report.BuildWeakSSHReport(database, scan.ID)
 	if err != nil {
 		return err
 	}
@@ -52,42 +113,125 @@ func (command *ReportCommand) Execute(args []string) error {
 			}
 		}
 
+		err = exportCsv(command.CsvExportPath, failuresReport, "scan_failures_report.csv")
+		if err != nil {
+			return err
+		}
+
 		err = exportCsv(command.CsvExportPath, rootReport, "root_process_report.csv")
 		if err != nil {
 			return err
 		}
 
+		err = exportCsv(command.CsvExportPath, privilegedReport, "privileged_process_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, deletedExecutablesReport, "deleted_executables_report.csv")
+		if err != nil {
+			return err
+		}
+
 		err = exportCsv(command.CsvExportPath, tlsReport, "tls_violation_report.csv")
 		if err != nil {
 			return err
 		}
 
+		err = exportCsv(command.CsvExportPath, incompleteTLSReport, "incomplete_tls_scan_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, expiryReport, "cert_expiry_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, weakKeyReport, "weak_key_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, httpReport, "http_headers_report.csv")
+		if err != nil {
+			return err
+		}
+
 		err = exportCsv(command.CsvExportPath, filesReport, "world_readable_files_report.csv")
 		if err != nil {
 			return err
 		}
 
+		err = exportCsv(command.CsvExportPath, socketsReport, "writable_sockets_report.csv")
+		if err != nil {
+			return err
+		}
+
 		err = exportCsv(command.CsvExportPath, sshKeysReport, "insecure_sshkey_report.csv")
 		if err != nil {
 			return err
 		}
+
+		err = exportCsv(command.CsvExportPath, weakSSHReport, "weak_ssh_report.csv")
+		if err != nil {
+			return err
+		}
 	}
 
-	rootReport.WriteTo(os.Stdout)
-	tlsReport.WriteTo(os.Stdout)
-	filesReport.WriteTo(os.Stdout)
-	sshKeysReport.WriteTo(os.Stdout)
+	reports := []report.Report{
+		failuresReport,
+		rootReport,
+		privilegedReport,
+		deletedExecutablesReport,
+		tlsReport,
+		incompleteTLSReport,
+		expiryReport,
+		weakKeyReport,
+		httpReport,
+		filesReport,
+		socketsReport,
+		sshKeysReport,
+		weakSSHReport,
+	}
 
-	if !rootReport.IsEmpty() ||
-		!tlsReport.IsEmpty() ||
-		!filesReport.IsEmpty() ||
-		!sshKeysReport.IsEmpty() {
-		return errors.New("Violations were found!")
+	switch command.Format {
+	case "json":
+		err = report.WriteJSON(os.Stdout, reports)
+	case "sarif":
+		err = report.WriteSARIF(os.Stdout, reports)
+	case "junit":
+		err = report.WriteJUnit(os.Stdout, reports)
+	case "markdown":
+		for _, r := range reports {
+			r.WriteMarkdown(os.Stdout)
+		}
+	default:
+		for _, r := range reports {
+			r.WriteTo(os.Stdout)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		if !r.IsEmpty() {
+			return errors.New("Violations were found!")
+		}
 	}
 
 	return nil
 }
 
+func loadTLSPolicy(path string) (tlspolicy.Policy, error) {
+	if path == "" {
+		return tlspolicy.Default()
+	}
+
+	return tlspolicy.Parse(path)
+}
+
 func exportCsv(absDir string, report report.Report, reportFileName string) error {
 	f, err := os.Create(filepath.Join(absDir, reportFileName))
 	if err != nil {
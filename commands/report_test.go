@@ -1,18 +1,23 @@
 package commands_test
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gbytes"
 	. "github.com/onsi/gomega/gexec"
+	. "github.com/onsi/gomega/gstruct"
 
 	"path/filepath"
 
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
 	"github.com/pivotal-cf/scantron/scanner"
 )
 
@@ -20,6 +25,7 @@ var _ = Describe("Report", func() {
 	var (
 		databasePath, tmpdir string
 		database             *db.Database
+		scan                 db.Scan
 	)
 
 	BeforeEach(func() {
@@ -30,6 +36,9 @@ var _ = Describe("Report", func() {
 
 		database, err = db.CreateDatabase(databasePath)
 		Expect(err).NotTo(HaveOccurred())
+
+		scan, err = database.StartScan("scantron bosh-scan", "operator")
+		Expect(err).NotTo(HaveOccurred())
 	})
 
 	AfterEach(func() {
@@ -60,18 +69,42 @@ var _ = Describe("Report", func() {
 						},
 						Services: []scantron.Process{
 							{
-								CommandName: "command1",
-								User:        "root",
+								CommandName:       "command1",
+								PID:               4321,
+								User:              "root",
+								Executable:        "/var/vcap/packages/command1/bin/command1",
+								ExecutableDeleted: true,
+								UnixSockets: []scantron.UnixSocket{
+									{
+										Path:        "/var/vcap/sys/run/command1.sock",
+										Type:        "stream",
+										Permissions: 0666,
+										User:        "root",
+										Group:       "root",
+									},
+								},
 								Ports: []scantron.Port{
 									{
 										State:   "LISTEN",
 										Address: "10.0.5.21",
 										Number:  7890,
 										TLSInformation: &scantron.TLSInformation{
-											Certificate: &scantron.Certificate{},
+											Certificate: &scantron.Certificate{
+												Expiration: time.Now().AddDate(0, 0, -3).Add(time.Hour),
+												Bits:       1024,
+												KeyType:    "RSA",
+												Subject:    scantron.CertificateSubject{CommonName: "host1.example.com"},
+											},
 											CipherInformation: scantron.CipherInformation{
 												"VersionSSL30": []string{"bad cipher"},
 											},
+											ProbeErrors: []scantron.ProbeError{
+												{Protocol: "VersionTLS12", Error: "i/o timeout"},
+											},
+										},
+										HTTPInformation: &scantron.HTTPInformation{
+											TLS:        true,
+											StatusCode: 200,
 										},
 									},
 								},
@@ -86,12 +119,38 @@ var _ = Describe("Report", func() {
 								Key:  "key-1",
 							},
 						},
+						Services: []scantron.Process{
+							{
+								CommandName:           "monitor",
+								PID:                   3100,
+								User:                  "vcap",
+								EffectiveCapabilities: []string{"CAP_NET_RAW"},
+								PermittedCapabilities: []string{"CAP_NET_RAW"},
+							},
+						},
+						SSHConfiguration: &scantron.SSHConfiguration{
+							Banner:       "SSH-2.0-OpenSSH_5.3",
+							KeyExchanges: []string{"diffie-hellman-group1-sha1"},
+							HostKeys:     []string{"ssh-ed25519"},
+							Ciphers:      []string{"aes256-ctr"},
+							MACs:         []string{"hmac-md5"},
+						},
+					},
+				},
+				Failures: []scanner.FailureResult{
+					{
+						Job:   "host3",
+						IP:    "10.0.5.23",
+						Stage: scanner.StageConnect,
+						Error: "connection refused",
 					},
 				},
 			}
 
-			err := database.SaveReport("cf1", hosts)
+			err := database.SaveReport(scan.ID, "cf1", hosts)
 			Expect(err).NotTo(HaveOccurred())
+
+			Expect(database.FinishScan(scan.ID)).To(Succeed())
 		})
 
 		It("shows externally-accessible processes running as root", func() {
@@ -116,6 +175,46 @@ var _ = Describe("Report", func() {
 			Expect(session.Out).To(Say("If this is not an internal endpoint then please check with your PM and the security team before applying this change. This change is not backwards compatible."))
 		})
 
+		It("shows ports with incomplete TLS scans", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say("Ports with incomplete TLS scans:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+PORT\s+\|\s+PROCESS NAME\s+\|\s+ERROR\(S\)\s+\|`))
+			Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+7890\s+\|\s+command1\s+\|\s+VersionTLS12: i/o timeout\s+\|`))
+		})
+
+		It("shows certificates which have expired", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say("Certificates expiring soon or expired:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+PORT\s+\|\s+PROCESS NAME\s+\|\s+COMMON NAME\s+\|\s+EXPIRATION\s+\|\s+DAYS REMAINING\s+\|\s+STATUS\s+\|`))
+			Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+7890\s+\|\s+command1\s+\|\s+host1.example.com\s+\|\s+\S+\s+\|\s+-3\s+\|\s+expired\s+\|`))
+		})
+
+		It("shows certificates with weak keys", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say("Certificates with weak keys:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+PORT\s+\|\s+PROCESS NAME\s+\|\s+KEY TYPE\s+\|\s+KEY BITS\s+\|`))
+			Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+7890\s+\|\s+command1\s+\|\s+RSA\s+\|\s+1024\s+\|`))
+		})
+
+		It("shows HTTP endpoints missing security headers", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say("HTTP endpoints missing security headers:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+PORT\s+\|\s+PROCESS NAME\s+\|\s+SCHEME\s+\|\s+PROBLEM\(S\)\s+\|`))
+			Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+7890\s+\|\s+command1\s+\|\s+https\s+\|\s+no Strict-Transport-Security`))
+		})
+
 		It("shows world-readable files", func() {
 			session := runCommand("report", "--database", databasePath)
 
@@ -127,6 +226,39 @@ var _ = Describe("Report", func() {
 			Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+/var/vcap/data/jobs/my.cnf\s+\|`))
 		})
 
+		It("shows privileged non-root processes", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say("Privileged non-root processes:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+PROCESS NAME\s+\|\s+PID\s+\|\s+USER\s+\|\s+CAPABILITIES\s+\|`))
+
+			Expect(session.Out).To(Say(`\|\s+host2\s+\|\s+monitor\s+\|\s+3100\s+\|\s+vcap\s+\|\s+CAP_NET_RAW\s+\|`))
+		})
+
+		It("shows processes running deleted executables", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say("Processes running deleted executables:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+PROCESS NAME\s+\|\s+PID\s+\|\s+EXECUTABLE\s+\|`))
+
+			Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+command1\s+\|\s+4321\s+\|\s+/var/vcap/packages/command1/bin/command1\s+\|`))
+		})
+
+		It("shows sockets writable by non-root users", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say("Sockets writable by non-root users:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+PATH\s+\|\s+PERMISSIONS\s+\|\s+OWNER\s+\|\s+PROCESS NAME\s+\|`))
+
+			Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+/var/vcap/sys/run/command1.sock\s+\|\s+0666\s+\|\s+root:root\s+\|\s+command1\s+\|`))
+		})
+
 		It("shows hosts with duplicate ssh keys", func() {
 			session := runCommand("report", "--database", databasePath)
 
@@ -138,6 +270,90 @@ var _ = Describe("Report", func() {
 			Expect(session.Out).To(Say(`\|\s+host2\s+\|`))
 		})
 
+		It("shows hosts with a weak ssh configuration", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+			Expect(session.Out).To(Say("Weak SSH configuration:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+ALGORITHM TYPE\s+\|\s+WEAK ALGORITHM\(S\)\s+\|`))
+
+			Expect(session.Out).To(Say(`\|\s+host2\s+\|\s+key exchange\s+\|\s+diffie-hellman-group1-sha1\s+\|`))
+			Expect(session.Out).To(Say(`\|\s+host2\s+\|\s+MAC\s+\|\s+hmac-md5\s+\|`))
+		})
+
+		It("shows hosts which could not be scanned", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(1))
+			Expect(session.Out).To(Say("Hosts which could not be scanned:"))
+			Expect(session.Out).To(Say(`\|\s+IDENTITY\s+\|\s+IP\s+\|\s+STAGE\s+\|\s+ERROR\s+\|`))
+
+			Expect(session.Out).To(Say(`\|\s+host3\s+\|\s+10.0.5.23\s+\|\s+connect\s+\|\s+connection refused\s+\|`))
+		})
+
+		It("can show the findings as json", func() {
+			session := runCommand("report", "--database", databasePath, "--format", "json")
+			Expect(session).To(Exit(1))
+
+			var output struct {
+				Findings []report.Finding `json:"findings"`
+			}
+
+			err := json.Unmarshal(session.Out.Contents(), &output)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(output.Findings).To(ContainElement(report.Finding{
+				ID:       "host1:7890",
+				RuleID:   "root-process",
+				Rule:     "Externally-accessible processes running as root",
+				Severity: report.SeverityHigh,
+				Host:     "host1",
+				Location: "7890",
+				Message:  "Externally-accessible processes running as root on host1:7890 (Process Name: command1)",
+				Details:  map[string]string{"Process Name": "command1"},
+			}))
+			Expect(output.Findings).To(ContainElement(MatchFields(IgnoreExtras, Fields{
+				"RuleID":   Equal("certificate-expiry"),
+				"Host":     Equal("host1"),
+				"Location": Equal("7890"),
+			})))
+			Expect(output.Findings).To(ContainElement(report.Finding{
+				ID:       "host1:/var/vcap/data/jobs/my.cnf",
+				RuleID:   "world-readable-file",
+				Rule:     "World-readable files",
+				Severity: report.SeverityMedium,
+				Host:     "host1",
+				Location: "/var/vcap/data/jobs/my.cnf",
+				Message:  "World-readable files on host1:/var/vcap/data/jobs/my.cnf",
+			}))
+		})
+
+		It("can show the findings as sarif", func() {
+			session := runCommand("report", "--database", databasePath, "--format", "sarif")
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say(`"version": "2.1.0"`))
+			Expect(session.Out).To(Say(`"id": "scan-failure"`))
+			Expect(session.Out).To(Say(`"ruleId": "scan-failure"`))
+			Expect(session.Out).To(Say(`"name": "host3"`))
+		})
+
+		It("can show the findings as junit xml", func() {
+			session := runCommand("report", "--database", databasePath, "--format", "junit")
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say(`<testsuite name="Hosts which could not be scanned" tests="1" failures="1">`))
+			Expect(session.Out).To(Say(`<testcase classname="scan-failure" name="host3:10.0.5.23">`))
+		})
+
+		It("can show the findings as markdown", func() {
+			session := runCommand("report", "--database", databasePath, "--format", "markdown")
+			Expect(session).To(Exit(1))
+
+			Expect(session.Out).To(Say(`### Hosts which could not be scanned`))
+			Expect(session.Out).To(Say(`\| host3 \| 10.0.5.23 \| connect \| connection refused \|`))
+		})
+
 		Context("and the csv flag is provided", func() {
 			var (
 				path string
@@ -175,17 +391,65 @@ var _ = Describe("Report", func() {
 				Expect(string(result)).To(ContainSubstring("Identity,Port,Process Name,Non-approved Protocol(s),Non-approved Cipher(s)"))
 				Expect(string(result)).To(ContainSubstring("host1,7890,command1,VersionSSL30,bad cipher"))
 
+				result, err = ioutil.ReadFile(filepath.Join(path, "incomplete_tls_scan_report.csv"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(result)).To(ContainSubstring("Identity,Port,Process Name,Error(s)"))
+				Expect(string(result)).To(ContainSubstring("host1,7890,command1,VersionTLS12: i/o timeout"))
+
+				result, err = ioutil.ReadFile(filepath.Join(path, "cert_expiry_report.csv"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(result)).To(ContainSubstring("Identity,Port,Process Name,Common Name,Expiration,Days Remaining,Status"))
+				Expect(string(result)).To(MatchRegexp(`host1,7890,command1,host1.example.com,[0-9-]+,-3,expired`))
+
+				result, err = ioutil.ReadFile(filepath.Join(path, "http_headers_report.csv"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(result)).To(ContainSubstring("Identity,Port,Process Name,Scheme,Problem(s)"))
+				Expect(string(result)).To(ContainSubstring("host1,7890,command1,https,no Strict-Transport-Security header"))
+
 				result, err = ioutil.ReadFile(filepath.Join(path, "world_readable_files_report.csv"))
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(string(result)).To(ContainSubstring("Identity,Path"))
 				Expect(string(result)).To(ContainSubstring("host1,/var/vcap/data/jobs/my.cnf"))
 
+				result, err = ioutil.ReadFile(filepath.Join(path, "privileged_process_report.csv"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(result)).To(ContainSubstring("Identity,Process Name,PID,User,Capabilities"))
+				Expect(string(result)).To(ContainSubstring("host2,monitor,3100,vcap,CAP_NET_RAW"))
+
+				result, err = ioutil.ReadFile(filepath.Join(path, "deleted_executables_report.csv"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(result)).To(ContainSubstring("Identity,Process Name,PID,Executable"))
+				Expect(string(result)).To(ContainSubstring("host1,command1,4321,/var/vcap/packages/command1/bin/command1"))
+
+				result, err = ioutil.ReadFile(filepath.Join(path, "writable_sockets_report.csv"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(result)).To(ContainSubstring("Identity,Path,Permissions,Owner,Process Name"))
+				Expect(string(result)).To(ContainSubstring("host1,/var/vcap/sys/run/command1.sock,0666,root:root,command1"))
+
 				result, err = ioutil.ReadFile(filepath.Join(path, "insecure_sshkey_report.csv"))
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(string(result)).To(ContainSubstring("Identity"))
 				Expect(string(result)).To(ContainSubstring("host1"))
+
+				result, err = ioutil.ReadFile(filepath.Join(path, "weak_ssh_report.csv"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(result)).To(ContainSubstring("Identity,Algorithm Type,Weak Algorithm(s)"))
+				Expect(string(result)).To(ContainSubstring("host2,key exchange,diffie-hellman-group1-sha1"))
+
+				result, err = ioutil.ReadFile(filepath.Join(path, "scan_failures_report.csv"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(result)).To(ContainSubstring("Identity,IP,Stage,Error"))
+				Expect(string(result)).To(ContainSubstring("host3,10.0.5.23,connect,connection refused"))
 			})
 		})
 	})
@@ -206,7 +470,11 @@ var _ = Describe("Report", func() {
 										Address: "10.0.5.21",
 										Number:  7890,
 										TLSInformation: &scantron.TLSInformation{
-											Certificate: &scantron.Certificate{},
+											Certificate: &scantron.Certificate{
+												Expiration: time.Now().AddDate(1, 0, 0),
+												Bits:       2048,
+												KeyType:    "RSA",
+											},
 											CipherInformation: scantron.CipherInformation{
 												"VersionTLS12": []string{"TLS_DHE_RSA_WITH_AES_128_GCM_SHA256"},
 											},
@@ -219,8 +487,10 @@ var _ = Describe("Report", func() {
 				},
 			}
 
-			err := database.SaveReport("cf1", hosts)
+			err := database.SaveReport(scan.ID, "cf1", hosts)
 			Expect(err).NotTo(HaveOccurred())
+
+			Expect(database.FinishScan(scan.ID)).To(Succeed())
 		})
 
 		It("exits without error", func() {
@@ -231,5 +501,142 @@ var _ = Describe("Report", func() {
 			Expect(session.Out).To(Say("Externally-accessible processes running as root:"))
 			Expect(session.Out).To(Say("Processes using non-approved SSL/TLS settings:"))
 		})
+
+		Context("but a certificate expires within the warning window", func() {
+			It("shows the certificate", func() {
+				session := runCommand("report", "--database", databasePath, "--cert-expiry-warning", "400d")
+
+				Expect(session).To(Exit(1))
+				Expect(session.Out).To(Say("Certificates expiring soon or expired:"))
+				Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+7890\s+\|\s+command1\s+\|.*\|\s+expiring\s+\|`))
+			})
+		})
+
+		Context("but the certificate expiry warning is invalid", func() {
+			It("exits with an error", func() {
+				session := runCommand("report", "--database", databasePath, "--cert-expiry-warning", "soon")
+
+				Expect(session).To(Exit(1))
+				Expect(session.Err).To(Say("invalid duration: soon"))
+			})
+		})
+
+		Context("but the tls policy is stricter", func() {
+			var policyPath string
+
+			BeforeEach(func() {
+				policyPath = filepath.Join(tmpdir, "policy.yml")
+
+				err := ioutil.WriteFile(policyPath, []byte("require_mutual_tls: true\n"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("shows the processes which do not meet the policy", func() {
+				session := runCommand("report", "--database", databasePath, "--tls-policy", policyPath)
+
+				Expect(session).To(Exit(1))
+				Expect(session.Out).To(Say("Processes using non-approved SSL/TLS settings:"))
+				Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+7890\s+\|\s+command1\s+\|\s+\|\s+\|\s+mutual TLS is not required\s+\|`))
+			})
+		})
+
+		Context("but the tls policy cannot be read", func() {
+			It("exits with an error", func() {
+				session := runCommand("report", "--database", databasePath, "--tls-policy", filepath.Join(tmpdir, "missing.yml"))
+
+				Expect(session).To(Exit(1))
+				Expect(session.Err).To(Say("missing.yml"))
+			})
+		})
+
+		Context("but a host could not be scanned", func() {
+			BeforeEach(func() {
+				hosts := scanner.ScanResult{
+					Failures: []scanner.FailureResult{
+						{
+							Job:   "host2",
+							IP:    "10.0.5.22",
+							Stage: scanner.StageRun,
+							Error: "disaster",
+						},
+					},
+				}
+
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(database.FinishScan(scan.ID)).To(Succeed())
+			})
+
+			It("exits with an error", func() {
+				session := runCommand("report", "--database", databasePath)
+
+				Expect(session).To(Exit(1))
+			})
+		})
+	})
+
+	Context("when there are several scans", func() {
+		var latestScan db.Scan
+
+		BeforeEach(func() {
+			err := database.SaveReport(scan.ID, "cf1", scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						Job: "host1",
+						Services: []scantron.Process{
+							{
+								CommandName: "command1",
+								User:        "root",
+								Ports: []scantron.Port{
+									{
+										State:   "LISTEN",
+										Address: "10.0.5.21",
+										Number:  7890,
+									},
+								},
+							},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(database.FinishScan(scan.ID)).To(Succeed())
+
+			latestScan, err = database.StartScan("scantron bosh-scan", "operator")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = database.SaveReport(latestScan.ID, "cf1", scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						Job: "host1",
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(database.FinishScan(latestScan.ID)).To(Succeed())
+		})
+
+		It("reports on the latest scan by default", func() {
+			session := runCommand("report", "--database", databasePath)
+
+			Expect(session).To(Exit(0))
+		})
+
+		It("reports on the scan given by --scan-id", func() {
+			session := runCommand("report", "--database", databasePath, "--scan-id", strconv.Itoa(scan.ID))
+
+			Expect(session).To(Exit(1))
+			Expect(session.Out).To(Say(`\|\s+host1\s+\|\s+7890\s+\|\s+command1\s+\|`))
+		})
+
+		It("errors when the scan does not exist", func() {
+			session := runCommand("report", "--database", databasePath, "--scan-id", "42")
+
+			Expect(session).To(Exit(1))
+			Expect(session.Err).To(Say("scan 42 does not exist"))
+		})
 	})
 })
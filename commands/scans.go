@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"log"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+var secretFlags = []string{"--password", "--client-secret"}
+
+func openScanDatabase(path string, appendToExisting bool) (*db.Database, error) {
+	if appendToExisting {
+		if _, err := os.Stat(path); err == nil {
+			return db.OpenDatabase(path)
+		}
+	}
+
+	return db.CreateDatabase(path)
+}
+
+func startScan(database *db.Database) (db.Scan, error) {
+	return database.StartScan(commandLine(os.Args), operator())
+}
+
+// abandonScan removes a scan which could not be completed, so that its partial
+// results are not mistaken for a whole scan, and exits.
+func abandonScan(database *db.Database, scanID int, format string, args ...interface{}) {
+	err := database.DeleteScan(scanID)
+	if err != nil {
+		log.Printf("failed to remove incomplete scan %d: %s", scanID, err)
+	}
+
+	log.Fatalf(format, args...)
+}
+
+func findScan(database *db.Database, scanID int) (db.Scan, error) {
+	if scanID == 0 {
+		return database.LatestScan()
+	}
+
+	return database.Scan(scanID)
+}
+
+// commandLine redacts secrets so that they are not stored with the scan.
+func commandLine(args []string) string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+
+	for i, arg := range redacted {
+		for _, flag := range secretFlags {
+			if arg == flag && i+1 < len(redacted) {
+				redacted[i+1] = "REDACTED"
+			} else if strings.HasPrefix(arg, flag+"=") {
+				redacted[i] = flag + "=REDACTED"
+			}
+		}
+	}
+
+	return strings.Join(redacted, " ")
+}
+
+func operator() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
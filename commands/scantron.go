@@ -5,9 +5,12 @@ type ScantronCommand struct {
 
 	BoshScan         BoshScanCommand         `command:"bosh-scan" description:"Scan all of the machines in a BOSH deployment"`
 	DirectScan       DirectScanCommand       `command:"direct-scan" description:"Scan a single machine"`
+	InventoryScan    InventoryScanCommand    `command:"inventory-scan" description:"Scan all of the machines in an inventory file"`
 	Audit            AuditCommand            `command:"audit" description:"Audit a scan report for unexpected hosts, processes, and ports"`
 	GenerateManifest GenerateManifestCommand `command:"generate-manifest" description:"Generate a audit manifest from the last report"`
 	Report           ReportCommand           `command:"report" description:"Generate a human readable report from the given database"`
+	Diff             DiffCommand             `command:"diff" description:"Show what changed between two scans"`
+	Migrate          MigrateCommand          `command:"migrate" description:"Upgrade a database to the latest schema version"`
 }
 
 var Scantron ScantronCommand
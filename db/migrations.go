@@ -0,0 +1,295 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// The oldest schema version which can be migrated. Databases from before then
+// have to be recreated.
+const oldestMigratableVersion = 8
+
+type migration struct {
+	version int
+	ddl     string
+}
+
+// Add a migration here whenever the SchemaVersion is updated. Each migration
+// upgrades a database from the previous version.
+var migrations = []migration{
+	{
+		version: 9,
+		ddl: `
+CREATE TABLE scan_failures (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  deployment_id integer,
+  name text,
+  ip text,
+  stage text,
+  error text,
+  FOREIGN KEY(deployment_id) REFERENCES deployments(id)
+);
+`,
+	},
+	{
+		version: 10,
+		ddl: `
+CREATE TABLE scans (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  start_time datetime,
+  end_time datetime,
+  scantron_version text,
+  command text,
+  operator text
+);
+
+-- Everything which was already in the database is put into a single scan.
+INSERT INTO scans(scantron_version, command, operator)
+  SELECT '', 'unknown (migrated from schema version 9)', ''
+  WHERE EXISTS (SELECT 1 FROM hosts)
+    OR EXISTS (SELECT 1 FROM scan_failures)
+    OR EXISTS (SELECT 1 FROM releases);
+
+CREATE TABLE hosts_migrated (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  scan_id integer,
+  deployment_id integer,
+  name text,
+  ip text,
+  UNIQUE(scan_id, ip, name),
+  FOREIGN KEY(scan_id) REFERENCES scans(id),
+  FOREIGN KEY(deployment_id) REFERENCES deployments(id)
+);
+
+INSERT INTO hosts_migrated(id, scan_id, deployment_id, name, ip)
+  SELECT id, (SELECT MAX(id) FROM scans), deployment_id, name, ip FROM hosts;
+
+DROP TABLE hosts;
+ALTER TABLE hosts_migrated RENAME TO hosts;
+
+ALTER TABLE scan_failures ADD COLUMN scan_id integer REFERENCES scans(id);
+UPDATE scan_failures SET scan_id = (SELECT MAX(id) FROM scans);
+
+ALTER TABLE releases ADD COLUMN scan_id integer REFERENCES scans(id);
+UPDATE releases SET scan_id = (SELECT MAX(id) FROM scans);
+`,
+	},
+	{
+		version: 11,
+		ddl: `
+CREATE TABLE tls_certificate_chain (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  certificate_id integer NOT NULL,
+  position integer,
+  subject text,
+  issuer text,
+  serial_number text,
+  not_before datetime,
+  not_after datetime,
+  sans text,
+  signature_algorithm text,
+  key_type text,
+  key_bits integer,
+  sha256_fingerprint text,
+  self_signed bool,
+  FOREIGN KEY(certificate_id) REFERENCES tls_certificates(id)
+);
+`,
+	},
+	{
+		version: 12,
+		ddl: `
+ALTER TABLE tls_certificates ADD COLUMN key_type text;
+`,
+	},
+	{
+		version: 13,
+		ddl: `
+ALTER TABLE tls_certificates ADD COLUMN server_cipher_preference bool;
+ALTER TABLE tls_certificates ADD COLUMN dh_bits integer;
+ALTER TABLE certificate_to_ciphersuite ADD COLUMN position integer;
+
+CREATE TABLE tls_key_exchange_groups (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  certificate_id integer NOT NULL,
+  group_name text,
+  FOREIGN KEY(certificate_id) REFERENCES tls_certificates(id)
+);
+`,
+	},
+	{
+		version: 14,
+		ddl: `
+CREATE TABLE tls_alpn_protocols (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  certificate_id integer NOT NULL,
+  protocol text,
+  FOREIGN KEY(certificate_id) REFERENCES tls_certificates(id)
+);
+
+CREATE TABLE http_endpoints (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  port_id integer NOT NULL,
+  tls bool,
+  status_code integer,
+  hsts text,
+  server text,
+  powered_by text,
+  redirects_to_https bool,
+  FOREIGN KEY(port_id) REFERENCES ports(id)
+);
+`,
+	},
+	{
+		version: 15,
+		ddl: `
+ALTER TABLE tls_scan_errors ADD COLUMN protocol text;
+`,
+	},
+	{
+		version: 16,
+		ddl: `
+ALTER TABLE ports ADD COLUMN probed_address text;
+`,
+	},
+	{
+		version: 17,
+		ddl: `
+CREATE TABLE ssh_servers (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer NOT NULL,
+  banner text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE ssh_algorithms (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  ssh_server_id integer NOT NULL,
+  kind text,
+  name text,
+  position integer,
+  FOREIGN KEY(ssh_server_id) REFERENCES ssh_servers(id)
+);
+`,
+	},
+	{
+		version: 18,
+		ddl: `
+CREATE TABLE sockets (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  process_id integer NOT NULL,
+  family text,
+  type text,
+  path text,
+  protocol integer,
+  permissions integer,
+  user text,
+  socket_group text,
+  FOREIGN KEY(process_id) REFERENCES processes(id)
+);
+`,
+	},
+	{
+		version: 19,
+		ddl: `
+ALTER TABLE processes ADD COLUMN ppid integer;
+ALTER TABLE processes ADD COLUMN start_time datetime;
+ALTER TABLE processes ADD COLUMN executable text;
+ALTER TABLE processes ADD COLUMN executable_sha256 text;
+ALTER TABLE processes ADD COLUMN executable_deleted bool;
+`,
+	},
+	{
+		version: 20,
+		ddl: `
+ALTER TABLE processes ADD COLUMN no_new_privs bool;
+ALTER TABLE processes ADD COLUMN seccomp text;
+ALTER TABLE processes ADD COLUMN net_ns integer;
+ALTER TABLE processes ADD COLUMN pid_ns integer;
+ALTER TABLE processes ADD COLUMN mnt_ns integer;
+
+CREATE TABLE process_capabilities (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  process_id integer NOT NULL,
+  kind text,
+  name text,
+  FOREIGN KEY(process_id) REFERENCES processes(id)
+);
+`,
+	},
+	{
+		// Certificates scanned before version 12 have no key type. Scantron
+		// only read RSA and ECDSA keys then, and ECDSA keys are the size of
+		// their curve.
+		version: 21,
+		ddl: `
+UPDATE tls_certificates
+  SET key_type = CASE WHEN cert_bits IN (224, 256, 384, 521) THEN 'ECDSA' ELSE 'RSA' END
+  WHERE key_type IS NULL
+    AND cert_bits IS NOT NULL;
+`,
+	},
+}
+
+// MigrateDatabase upgrades the database at path to the latest schema version
+// in place. It returns the version the database was at before.
+func MigrateDatabase(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return -1, err
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return -1, err
+	}
+
+	database := &Database{db: db}
+	defer database.Close()
+
+	version, err := database.Version()
+	if err != nil {
+		return -1, err
+	}
+
+	if version > SchemaVersion {
+		return version, fmt.Errorf("The database version (%d) is newer than the latest version (%d).", version, SchemaVersion)
+	}
+
+	if version < oldestMigratableVersion {
+		return version, fmt.Errorf("The database version (%d) is too old to migrate. Please create a new database.", version)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		err := database.migrate(m)
+		if err != nil {
+			return version, fmt.Errorf("failed to migrate database to version %d: %s", m.version, err)
+		}
+	}
+
+	return version, nil
+}
+
+func (db *Database) migrate(m migration) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(m.ddl)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE version SET version = ?", m.version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
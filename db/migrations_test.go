@@ -0,0 +1,365 @@
+package db_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+var _ = Describe("Migrations", func() {
+	var (
+		tmpdir string
+		dbPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "scantron_db")
+		Expect(err).NotTo(HaveOccurred())
+
+		dbPath = filepath.Join(tmpdir, "database.db")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	Context("with a database from schema version 8", func() {
+		BeforeEach(func() {
+			sqliteDB, err := sql.Open("sqlite3", dbPath)
+			Expect(err).NotTo(HaveOccurred())
+			defer sqliteDB.Close()
+
+			_, err = sqliteDB.Exec(schemaVersion8DDL, 8)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = sqliteDB.Exec(`
+				INSERT INTO deployments(id, name) VALUES (1, 'cf1');
+				INSERT INTO hosts(id, deployment_id, name, ip) VALUES (1, 1, 'router/0', '10.0.0.1');
+				INSERT INTO processes(host_id, name, pid, cmdline, user) VALUES (1, 'gorouter', 42, 'gorouter', 'vcap');
+				INSERT INTO releases(deployment_id, name, version) VALUES (1, 'routing', '1.0.0');
+				INSERT INTO tls_certificates(id, cert_bits) VALUES (1, 1024);
+				INSERT INTO tls_certificates(id, cert_bits) VALUES (2, 256);
+			`)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("cannot be opened until it is migrated", func() {
+			_, err := db.OpenDatabase(dbPath)
+			Expect(err).To(MatchError(ContainSubstring("scantron migrate")))
+		})
+
+		It("migrates the database to the latest version", func() {
+			from, err := db.MigrateDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(from).To(Equal(8))
+
+			database, err := db.OpenDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			Expect(database.Version()).To(Equal(db.SchemaVersion))
+		})
+
+		It("migrates to the same schema as a new database", func() {
+			_, err := db.MigrateDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			newPath := filepath.Join(tmpdir, "new.db")
+			database, err := db.CreateDatabase(newPath)
+			Expect(err).NotTo(HaveOccurred())
+			database.Close()
+
+			Expect(schemaOf(dbPath)).To(Equal(schemaOf(newPath)))
+		})
+
+		It("puts the existing data into a scan", func() {
+			_, err := db.MigrateDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			database, err := db.OpenDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			scan, err := database.LatestScan()
+			Expect(err).NotTo(HaveOccurred())
+
+			var hostScanID, releaseScanID, processes int
+			err = database.DB().QueryRow(`SELECT scan_id FROM hosts WHERE name = 'router/0'`).Scan(&hostScanID)
+			Expect(err).NotTo(HaveOccurred())
+			err = database.DB().QueryRow(`SELECT scan_id FROM releases WHERE name = 'routing'`).Scan(&releaseScanID)
+			Expect(err).NotTo(HaveOccurred())
+			err = database.DB().QueryRow(`SELECT COUNT(*) FROM processes WHERE host_id = 1`).Scan(&processes)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(scan.StartTime.IsZero()).To(BeTrue())
+			Expect(scan.Command).To(ContainSubstring("migrated"))
+			Expect(hostScanID).To(Equal(scan.ID))
+			Expect(releaseScanID).To(Equal(scan.ID))
+			Expect(processes).To(Equal(1))
+		})
+
+		It("fills in the key type of existing certificates", func() {
+			_, err := db.MigrateDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			database, err := db.OpenDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			keyTypes := map[int]string{}
+			rows, err := database.DB().Query(`SELECT id, key_type FROM tls_certificates`)
+			Expect(err).NotTo(HaveOccurred())
+			defer rows.Close()
+
+			for rows.Next() {
+				var (
+					id      int
+					keyType string
+				)
+				Expect(rows.Scan(&id, &keyType)).To(Succeed())
+				keyTypes[id] = keyType
+			}
+			Expect(rows.Err()).NotTo(HaveOccurred())
+
+			Expect(keyTypes).To(Equal(map[int]string{1: "RSA", 2: "ECDSA"}))
+		})
+
+		It("can have new scans appended once it is migrated", func() {
+			_, err := db.MigrateDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			database, err := db.OpenDatabase(dbPath)
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			scan, err := database.StartScan("scantron bosh-scan", "operator")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = database.SaveReport(scan.ID, "cf1", scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						IP:  "10.0.0.1",
+						Job: "router/0",
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var count int
+			err = database.DB().QueryRow(`SELECT COUNT(*) FROM hosts WHERE name = 'router/0'`).Scan(&count)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal(2))
+		})
+	})
+
+	It("does nothing to a database which is already at the latest version", func() {
+		database, err := db.CreateDatabase(dbPath)
+		Expect(err).NotTo(HaveOccurred())
+		database.Close()
+
+		from, err := db.MigrateDatabase(dbPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(from).To(Equal(db.SchemaVersion))
+	})
+
+	It("returns an error when the database is too old to migrate", func() {
+		database, err := db.CreateDatabase(dbPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = database.DB().Exec("UPDATE version SET version = 7")
+		Expect(err).NotTo(HaveOccurred())
+		database.Close()
+
+		_, err = db.MigrateDatabase(dbPath)
+		Expect(err).To(MatchError(ContainSubstring("too old to migrate")))
+	})
+
+	It("returns an error when the database is newer than this version of scantron", func() {
+		database, err := db.CreateDatabase(dbPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = database.DB().Exec("UPDATE version SET version = 1000")
+		Expect(err).NotTo(HaveOccurred())
+		database.Close()
+
+		_, err = db.MigrateDatabase(dbPath)
+		Expect(err).To(MatchError(ContainSubstring("is newer than the latest version")))
+	})
+})
+
+// schemaOf returns the columns of each table in the database. Columns added by
+// a migration come after the existing ones so their order is ignored.
+func schemaOf(path string) map[string][]string {
+	sqliteDB, err := sql.Open("sqlite3", path)
+	Expect(err).NotTo(HaveOccurred())
+	defer sqliteDB.Close()
+
+	rows, err := sqliteDB.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	Expect(err).NotTo(HaveOccurred())
+
+	tables := []string{}
+	for rows.Next() {
+		var table string
+		Expect(rows.Scan(&table)).To(Succeed())
+		tables = append(tables, table)
+	}
+	rows.Close()
+
+	schema := map[string][]string{}
+	for _, table := range tables {
+		columns, err := sqliteDB.Query(`SELECT name, type FROM pragma_table_info(?) ORDER BY name`, table)
+		Expect(err).NotTo(HaveOccurred())
+
+		for columns.Next() {
+			var name, columnType string
+			Expect(columns.Scan(&name, &columnType)).To(Succeed())
+			schema[table] = append(schema[table], name+" "+columnType)
+		}
+		columns.Close()
+	}
+
+	return schema
+}
+
+// The schema before migrations were introduced.
+const schemaVersion8DDL = `
+CREATE TABLE deployments (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name text
+);
+
+CREATE TABLE hosts (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  deployment_id integer,
+  name text,
+  ip text,
+  UNIQUE(ip, name),
+  FOREIGN KEY(deployment_id) REFERENCES deployments(id)
+);
+
+CREATE TABLE processes (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  name text,
+  pid integer,
+  cmdline text,
+  user text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE ports (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  process_id integer,
+  protocol string,
+  address string,
+  number integer,
+  foreignAddress string,
+  foreignNumber integer,
+  state string,
+  FOREIGN KEY(process_id) REFERENCES processes(id)
+);
+
+CREATE TABLE tls_certificates (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  port_id integer,
+  cert_expiration datetime,
+  cert_bits integer,
+  cert_country string,
+  cert_province string,
+  cert_locality string,
+  cert_organization string,
+  cert_common_name string,
+  mutual bool,
+  FOREIGN KEY(port_id) REFERENCES ports(id)
+);
+
+CREATE TABLE tls_scan_errors (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  port_id integer,
+  cert_scan_error string,
+  FOREIGN KEY(port_id) REFERENCES ports(id)
+);
+
+CREATE TABLE tls_suites (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  suite string NOT NULL
+);
+
+CREATE TABLE tls_ciphers (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cipher string NOT NULL
+);
+
+CREATE TABLE certificate_to_ciphersuite (
+  certificate_id integer NOT NULL,
+  suite_id integer NOT NULL,
+  cipher_id integer NOT NULL,
+  FOREIGN KEY(certificate_id) REFERENCES tls_certificates(id),
+  FOREIGN KEY(suite_id) REFERENCES tls_suites(id),
+  FOREIGN KEY(cipher_id) REFERENCES tls_ciphers(id)
+);
+
+CREATE TABLE env_vars (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  process_id integer,
+  var text,
+  FOREIGN KEY(process_id) REFERENCES processes(id)
+);
+
+CREATE TABLE files (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  path text,
+  permissions integer,
+  user text,
+  file_group text,
+  size integer,
+  modified datetime,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE ssh_keys (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  type string,
+  key string,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE version (
+  version integer
+);
+
+CREATE TABLE releases (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  deployment_id integer,
+  name string,
+  version string,
+  FOREIGN KEY(deployment_id) REFERENCES deployments(id)
+);
+
+CREATE TABLE regexes (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  regex string NOT NULL
+);
+
+CREATE TABLE file_to_regex (
+  file_id integer NOT NULL,
+  path_regex_id integer,
+  content_regex_id integer NOT NULL,
+  FOREIGN KEY(file_id) REFERENCES files(id),
+  FOREIGN KEY(path_regex_id) REFERENCES regexes(id),
+  FOREIGN KEY(content_regex_id) REFERENCES regexes(id)
+);
+
+INSERT INTO version(version) VALUES(?);
+`
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+type Scan struct {
+	ID        int
+	StartTime time.Time
+	EndTime   *time.Time
+	Version   string
+	Command   string
+	Operator  string
+}
+
+func (db *Database) StartScan(command, operator string) (Scan, error) {
+	scan := Scan{
+		StartTime: time.Now().UTC(),
+		Version:   scantron.Version,
+		Command:   command,
+		Operator:  operator,
+	}
+
+	res, err := db.db.Exec(
+		"INSERT INTO scans(start_time, scantron_version, command, operator) VALUES (?, ?, ?, ?)",
+		scan.StartTime, scan.Version, scan.Command, scan.Operator,
+	)
+	if err != nil {
+		return Scan{}, err
+	}
+
+	scanID, err := res.LastInsertId()
+	if err != nil {
+		return Scan{}, err
+	}
+
+	scan.ID = int(scanID)
+
+	return scan, nil
+}
+
+func (db *Database) FinishScan(scanID int) error {
+	_, err := db.db.Exec("UPDATE scans SET end_time = ? WHERE id = ?", time.Now().UTC(), scanID)
+	return err
+}
+
+func (db *Database) Scan(scanID int) (Scan, error) {
+	return db.scan(db.db.QueryRow(`
+		SELECT id, start_time, end_time, scantron_version, command, operator
+		FROM scans
+		WHERE id = ?`, scanID), fmt.Sprintf("scan %d does not exist", scanID))
+}
+
+// finishedScan matches the scans which ran to completion. Scans migrated from
+// before scans were recorded have neither a start nor an end time.
+const finishedScan = "(end_time IS NOT NULL OR start_time IS NULL)"
+
+func (db *Database) LatestScan() (Scan, error) {
+	return db.scan(db.db.QueryRow(`
+		SELECT id, start_time, end_time, scantron_version, command, operator
+		FROM scans
+		WHERE `+finishedScan+`
+		ORDER BY id DESC
+		LIMIT 1`), "no scans found in database")
+}
+
+func (db *Database) ScanBefore(scanID int) (Scan, error) {
+	return db.scan(db.db.QueryRow(`
+		SELECT id, start_time, end_time, scantron_version, command, operator
+		FROM scans
+		WHERE id < ? AND `+finishedScan+`
+		ORDER BY id DESC
+		LIMIT 1`, scanID), fmt.Sprintf("no scan found before scan %d", scanID))
+}
+
+// DeleteScan removes a scan and everything which was saved in it.
+func (db *Database) DeleteScan(scanID int) error {
+	hosts := "SELECT id FROM hosts WHERE scan_id = ?"
+	processes := "SELECT id FROM processes WHERE host_id IN (" + hosts + ")"
+	ports := "SELECT id FROM ports WHERE process_id IN (" + processes + ")"
+	certificates := "SELECT id FROM tls_certificates WHERE port_id IN (" + ports + ")"
+	files := "SELECT id FROM files WHERE host_id IN (" + hosts + ")"
+	sshServers := "SELECT id FROM ssh_servers WHERE host_id IN (" + hosts + ")"
+
+	// Children are deleted before their parents since they are found through
+	// them.
+	statements := []string{
+		"DELETE FROM tls_key_exchange_groups WHERE certificate_id IN (" + certificates + ")",
+		"DELETE FROM tls_alpn_protocols WHERE certificate_id IN (" + certificates + ")",
+		"DELETE FROM tls_certificate_chain WHERE certificate_id IN (" + certificates + ")",
+		"DELETE FROM certificate_to_ciphersuite WHERE certificate_id IN (" + certificates + ")",
+		"DELETE FROM tls_certificates WHERE id IN (" + certificates + ")",
+		"DELETE FROM tls_scan_errors WHERE port_id IN (" + ports + ")",
+		"DELETE FROM http_endpoints WHERE port_id IN (" + ports + ")",
+		"DELETE FROM ports WHERE id IN (" + ports + ")",
+		"DELETE FROM process_capabilities WHERE process_id IN (" + processes + ")",
+		"DELETE FROM env_vars WHERE process_id IN (" + processes + ")",
+		"DELETE FROM sockets WHERE process_id IN (" + processes + ")",
+		"DELETE FROM processes WHERE id IN (" + processes + ")",
+		"DELETE FROM file_to_regex WHERE file_id IN (" + files + ")",
+		"DELETE FROM files WHERE id IN (" + files + ")",
+		"DELETE FROM ssh_algorithms WHERE ssh_server_id IN (" + sshServers + ")",
+		"DELETE FROM ssh_servers WHERE id IN (" + sshServers + ")",
+		"DELETE FROM ssh_keys WHERE host_id IN (" + hosts + ")",
+		"DELETE FROM hosts WHERE id IN (" + hosts + ")",
+		"DELETE FROM scan_failures WHERE scan_id = ?",
+		"DELETE FROM releases WHERE scan_id = ?",
+		"DELETE FROM scans WHERE id = ?",
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		_, err = tx.Exec(statement, scanID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (db *Database) scan(row *sql.Row, notFound string) (Scan, error) {
+	var (
+		scan      Scan
+		startTime *time.Time
+	)
+
+	err := row.Scan(&scan.ID, &startTime, &scan.EndTime, &scan.Version, &scan.Command, &scan.Operator)
+	if err == sql.ErrNoRows {
+		return Scan{}, errors.New(notFound)
+	}
+	if err != nil {
+		return Scan{}, err
+	}
+
+	// Scans migrated from before scans were recorded have no start time.
+	if startTime != nil {
+		scan.StartTime = *startTime
+	}
+
+	return scan, nil
+}
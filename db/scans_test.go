@@ -0,0 +1,179 @@
+package db_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+var _ = Describe("Scans", func() {
+	var (
+		tmpdir   string
+		database *db.Database
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "scantron_db")
+		Expect(err).NotTo(HaveOccurred())
+
+		database, err = db.CreateDatabase(filepath.Join(tmpdir, "database.db"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(database.Close()).To(Succeed())
+		os.RemoveAll(tmpdir)
+	})
+
+	Describe("StartScan", func() {
+		It("records when the scan started and who started it", func() {
+			scan, err := database.StartScan("scantron bosh-scan", "operator")
+			Expect(err).NotTo(HaveOccurred())
+
+			saved, err := database.Scan(scan.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(saved.StartTime).To(BeTemporally("~", time.Now(), time.Minute))
+			Expect(saved.EndTime).To(BeNil())
+			Expect(saved.Version).To(Equal(scantron.Version))
+			Expect(saved.Command).To(Equal("scantron bosh-scan"))
+			Expect(saved.Operator).To(Equal("operator"))
+		})
+	})
+
+	Describe("FinishScan", func() {
+		It("records when the scan finished", func() {
+			scan, err := database.StartScan("scantron bosh-scan", "operator")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = database.FinishScan(scan.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			saved, err := database.Scan(scan.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(saved.EndTime).NotTo(BeNil())
+			Expect(*saved.EndTime).To(BeTemporally(">=", saved.StartTime))
+		})
+	})
+
+	Describe("Scan", func() {
+		It("returns an error when the scan does not exist", func() {
+			_, err := database.Scan(42)
+			Expect(err).To(MatchError("scan 42 does not exist"))
+		})
+	})
+
+	finishedScan := func(command string) db.Scan {
+		scan, err := database.StartScan(command, "operator")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(database.FinishScan(scan.ID)).To(Succeed())
+
+		return scan
+	}
+
+	Describe("ScanBefore", func() {
+		It("returns the scan before the given one", func() {
+			first := finishedScan("scantron bosh-scan")
+			second := finishedScan("scantron bosh-scan")
+
+			before, err := database.ScanBefore(second.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(before.ID).To(Equal(first.ID))
+		})
+
+		It("skips scans which did not finish", func() {
+			first := finishedScan("scantron bosh-scan")
+
+			_, err := database.StartScan("scantron bosh-scan", "operator")
+			Expect(err).NotTo(HaveOccurred())
+
+			third := finishedScan("scantron bosh-scan")
+
+			before, err := database.ScanBefore(third.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(before.ID).To(Equal(first.ID))
+		})
+
+		It("returns an error when there is no earlier scan", func() {
+			first := finishedScan("scantron bosh-scan")
+
+			_, err := database.ScanBefore(first.ID)
+			Expect(err).To(MatchError(fmt.Sprintf("no scan found before scan %d", first.ID)))
+		})
+	})
+
+	Describe("LatestScan", func() {
+		It("returns the most recent scan", func() {
+			finishedScan("scantron bosh-scan")
+			second := finishedScan("scantron direct-scan")
+
+			latest, err := database.LatestScan()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latest.ID).To(Equal(second.ID))
+			Expect(latest.Command).To(Equal("scantron direct-scan"))
+		})
+
+		It("skips scans which did not finish", func() {
+			first := finishedScan("scantron bosh-scan")
+
+			_, err := database.StartScan("scantron direct-scan", "operator")
+			Expect(err).NotTo(HaveOccurred())
+
+			latest, err := database.LatestScan()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latest.ID).To(Equal(first.ID))
+		})
+
+		It("returns an error when there are no scans", func() {
+			_, err := database.LatestScan()
+			Expect(err).To(MatchError("no scans found in database"))
+		})
+	})
+
+	Describe("DeleteScan", func() {
+		It("removes the scan and its results", func() {
+			kept := finishedScan("scantron bosh-scan")
+			Expect(database.SaveReport(kept.ID, "cf", scanner.ScanResult{
+				JobResults: []scanner.JobResult{{IP: "10.0.0.1", Job: "kept/0"}},
+			})).To(Succeed())
+
+			deleted, err := database.StartScan("scantron bosh-scan", "operator")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(database.SaveReport(deleted.ID, "cf", scanner.ScanResult{
+				JobResults: []scanner.JobResult{{
+					IP:  "10.0.0.2",
+					Job: "deleted/0",
+					Services: []scantron.Process{{
+						CommandName: "server",
+						Ports:       []scantron.Port{{Protocol: "TCP", Number: 443}},
+					}},
+				}},
+			})).To(Succeed())
+
+			Expect(database.DeleteScan(deleted.ID)).To(Succeed())
+
+			_, err = database.Scan(deleted.ID)
+			Expect(err).To(HaveOccurred())
+
+			var hosts, processes, ports int
+			err = database.DB().QueryRow("SELECT (SELECT count(1) FROM hosts), (SELECT count(1) FROM processes), (SELECT count(1) FROM ports)").Scan(&hosts, &processes, &ports)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hosts).To(Equal(1))
+			Expect(processes).To(BeZero())
+			Expect(ports).To(BeZero())
+		})
+	})
+})
@@ -1,9 +1,18 @@
 package db
 
-// Update the schema version when the DDL changes
-const SchemaVersion = 8
+// Update the schema version and add a migration when the DDL changes
+const SchemaVersion = 21
 
 const createDDL = `
+CREATE TABLE scans (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  start_time datetime,
+  end_time datetime,
+  scantron_version text,
+  command text,
+  operator text
+);
+
 CREATE TABLE deployments (
   id integer PRIMARY KEY AUTOINCREMENT,
   name text
@@ -11,10 +20,12 @@ CREATE TABLE deployments (
 
 CREATE TABLE hosts (
   id integer PRIMARY KEY AUTOINCREMENT,
+  scan_id integer,
   deployment_id integer,
   name text,
   ip text,
-  UNIQUE(ip, name),
+  UNIQUE(scan_id, ip, name),
+  FOREIGN KEY(scan_id) REFERENCES scans(id),
   FOREIGN KEY(deployment_id) REFERENCES deployments(id)
 );
 
@@ -25,9 +36,27 @@ CREATE TABLE processes (
   pid integer,
   cmdline text,
   user text,
+  ppid integer,
+  start_time datetime,
+  executable text,
+  executable_sha256 text,
+  executable_deleted bool,
+  no_new_privs bool,
+  seccomp text,
+  net_ns integer,
+  pid_ns integer,
+  mnt_ns integer,
   FOREIGN KEY(host_id) REFERENCES hosts(id)
 );
 
+CREATE TABLE process_capabilities (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  process_id integer NOT NULL,
+  kind text,
+  name text,
+  FOREIGN KEY(process_id) REFERENCES processes(id)
+);
+
 CREATE TABLE ports (
   id integer PRIMARY KEY AUTOINCREMENT,
   process_id integer,
@@ -37,6 +66,7 @@ CREATE TABLE ports (
   foreignAddress string,
   foreignNumber integer,
   state string,
+  probed_address text,
   FOREIGN KEY(process_id) REFERENCES processes(id)
 );
 
@@ -51,13 +81,61 @@ CREATE TABLE tls_certificates (
   cert_organization string,
   cert_common_name string,
   mutual bool,
+  key_type text,
+  server_cipher_preference bool,
+  dh_bits integer,
   FOREIGN KEY(port_id) REFERENCES ports(id)
 );
 
+CREATE TABLE tls_key_exchange_groups (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  certificate_id integer NOT NULL,
+  group_name text,
+  FOREIGN KEY(certificate_id) REFERENCES tls_certificates(id)
+);
+
+CREATE TABLE tls_alpn_protocols (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  certificate_id integer NOT NULL,
+  protocol text,
+  FOREIGN KEY(certificate_id) REFERENCES tls_certificates(id)
+);
+
+CREATE TABLE http_endpoints (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  port_id integer NOT NULL,
+  tls bool,
+  status_code integer,
+  hsts text,
+  server text,
+  powered_by text,
+  redirects_to_https bool,
+  FOREIGN KEY(port_id) REFERENCES ports(id)
+);
+
+CREATE TABLE tls_certificate_chain (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  certificate_id integer NOT NULL,
+  position integer,
+  subject text,
+  issuer text,
+  serial_number text,
+  not_before datetime,
+  not_after datetime,
+  sans text,
+  signature_algorithm text,
+  key_type text,
+  key_bits integer,
+  sha256_fingerprint text,
+  self_signed bool,
+  FOREIGN KEY(certificate_id) REFERENCES tls_certificates(id)
+);
+
 CREATE TABLE tls_scan_errors (
   id integer PRIMARY KEY AUTOINCREMENT,
   port_id integer,
   cert_scan_error string,
+  protocol text,
   FOREIGN KEY(port_id) REFERENCES ports(id)
 );
 
@@ -75,6 +153,7 @@ CREATE TABLE certificate_to_ciphersuite (
   certificate_id integer NOT NULL,
   suite_id integer NOT NULL,
   cipher_id integer NOT NULL,
+  position integer,
   FOREIGN KEY(certificate_id) REFERENCES tls_certificates(id),
   FOREIGN KEY(suite_id) REFERENCES tls_suites(id),
   FOREIGN KEY(cipher_id) REFERENCES tls_ciphers(id)
@@ -87,6 +166,19 @@ CREATE TABLE env_vars (
   FOREIGN KEY(process_id) REFERENCES processes(id)
 );
 
+CREATE TABLE sockets (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  process_id integer NOT NULL,
+  family text,
+  type text,
+  path text,
+  protocol integer,
+  permissions integer,
+  user text,
+  socket_group text,
+  FOREIGN KEY(process_id) REFERENCES processes(id)
+);
+
 CREATE TABLE files (
   id integer PRIMARY KEY AUTOINCREMENT,
   host_id integer,
@@ -107,15 +199,45 @@ CREATE TABLE ssh_keys (
   FOREIGN KEY(host_id) REFERENCES hosts(id)
 );
 
+CREATE TABLE ssh_servers (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer NOT NULL,
+  banner text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE ssh_algorithms (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  ssh_server_id integer NOT NULL,
+  kind text,
+  name text,
+  position integer,
+  FOREIGN KEY(ssh_server_id) REFERENCES ssh_servers(id)
+);
+
+CREATE TABLE scan_failures (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  scan_id integer,
+  deployment_id integer,
+  name text,
+  ip text,
+  stage text,
+  error text,
+  FOREIGN KEY(scan_id) REFERENCES scans(id),
+  FOREIGN KEY(deployment_id) REFERENCES deployments(id)
+);
+
 CREATE TABLE version (
   version integer
 );
 
 CREATE TABLE releases (
   id integer PRIMARY KEY AUTOINCREMENT,
+  scan_id integer,
   deployment_id integer,
   name string,
   version string,
+  FOREIGN KEY(scan_id) REFERENCES scans(id),
   FOREIGN KEY(deployment_id) REFERENCES deployments(id)
 );
 
@@ -10,6 +10,7 @@ import (
 	// Include SQLite3 for database.
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/scanner"
 )
 
@@ -61,7 +62,8 @@ func OpenDatabase(path string) (*Database, error) {
 	}
 
 	if version != SchemaVersion {
-		return nil, fmt.Errorf("The database version (%d) does not match latest version (%d). Please create a new database.", version, SchemaVersion)
+		database.Close()
+		return nil, fmt.Errorf("The database version (%d) does not match latest version (%d). Please run `scantron migrate` to upgrade it.", version, SchemaVersion)
 	}
 
 	return database, nil
@@ -116,7 +118,7 @@ func getIndexOrInsert(qf queryFunc, insF insertFunc) (int, error) {
 	return rowId, nil
 }
 
-func (db *Database) SaveReport(deployment string, report scanner.ScanResult) error {
+func (db *Database) SaveReport(scanID int, deployment string, report scanner.ScanResult) error {
 	tx, err := db.db.Begin()
 	if err != nil {
 		return err
@@ -134,10 +136,10 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 
 		hostID, err := getIndexOrInsert(
 			func() *sql.Row {
-				return tx.QueryRow("SELECT id FROM hosts WHERE name = ? AND ip = ?", scan.Job, scan.IP)
+				return tx.QueryRow("SELECT id FROM hosts WHERE scan_id = ? AND name = ? AND ip = ?", scanID, scan.Job, scan.IP)
 			},
 			func() (sql.Result, error) {
-				return tx.Exec("INSERT INTO hosts(name, ip, deployment_id) VALUES (?, ?, ?)", scan.Job, scan.IP, depID)
+				return tx.Exec("INSERT INTO hosts(scan_id, name, ip, deployment_id) VALUES (?, ?, ?, ?)", scanID, scan.Job, scan.IP, depID)
 			})
 		if err != nil {
 			return err
@@ -145,9 +147,22 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 
 		for _, service := range scan.Services {
 			cmdline := strings.Join(service.Cmdline, " ")
+
+			// The start time is unknown on Windows.
+			var startTime interface{}
+			if !service.StartTime.IsZero() {
+				startTime = service.StartTime
+			}
+
 			res, err := tx.Exec(
-				"INSERT INTO processes(host_id, name, pid, cmdline, user) VALUES (?, ?, ?, ?, ?)",
-				hostID, service.CommandName, service.PID, cmdline, service.User,
+				`INSERT INTO processes(
+				   host_id, name, pid, cmdline, user, ppid, start_time,
+				   executable, executable_sha256, executable_deleted,
+				   no_new_privs, seccomp, net_ns, pid_ns, mnt_ns
+				 ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				hostID, service.CommandName, service.PID, cmdline, service.User, service.PPID, startTime,
+				service.Executable, service.ExecutableSHA256, service.ExecutableDeleted,
+				service.NoNewPrivs, service.Seccomp, service.Namespaces.Net, service.Namespaces.PID, service.Namespaces.Mount,
 			)
 			if err != nil {
 				return err
@@ -160,8 +175,8 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 
 			for _, port := range service.Ports {
 				res, err = tx.Exec(
-					"INSERT INTO ports(process_id, protocol, address, number, foreignAddress, foreignNumber, state) VALUES (?, ?, ?, ?, ?, ?, ?)",
-					processID, port.Protocol, port.Address, port.Number, port.ForeignAddress, port.ForeignNumber, port.State,
+					"INSERT INTO ports(process_id, protocol, address, number, foreignAddress, foreignNumber, state, probed_address) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+					processID, port.Protocol, port.Address, port.Number, port.ForeignAddress, port.ForeignNumber, port.State, port.ProbedAddress,
 				)
 				if err != nil {
 					return err
@@ -172,23 +187,58 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 					return err
 				}
 
-				if port.TLSInformation != nil && port.TLSInformation.ScanError != nil {
+				if port.TLSInformation != nil && port.TLSInformation.ScanError != "" {
 					_, err = tx.Exec(`
             INSERT INTO tls_scan_errors (
                port_id,
                cert_scan_error
             ) VALUES (?, ?)`,
 						portID,
-						port.TLSInformation.ScanError.Error(),
+						port.TLSInformation.ScanError,
 					)
 					if err != nil {
 						return err
 					}
 				}
 
-				if port.TLSInformation != nil && port.TLSInformation.Certificate != nil {
+				if port.TLSInformation != nil {
+					for _, probeError := range port.TLSInformation.ProbeErrors {
+						_, err = tx.Exec(`
+            INSERT INTO tls_scan_errors (
+               port_id,
+               cert_scan_error,
+               protocol
+            ) VALUES (?, ?, ?)`,
+							portID,
+							probeError.Error,
+							probeError.Protocol,
+						)
+						if err != nil {
+							return err
+						}
+					}
+				}
+
+				if port.TLSInformation != nil && (port.TLSInformation.Certificate != nil || port.TLSInformation.CipherInformation.HasTLS()) {
 					cert := port.TLSInformation.Certificate
 
+					// When the certificate could not be fetched the cipher suites
+					// are still saved and the certificate columns are left NULL.
+					var expiration, bits, country, province, locality, organization, commonName, mutual, keyType interface{}
+					var chain []scantron.ChainCertificate
+					if cert != nil {
+						expiration = cert.Expiration
+						bits = cert.Bits
+						country = cert.Subject.Country
+						province = cert.Subject.Province
+						locality = cert.Subject.Locality
+						organization = cert.Subject.Organization
+						commonName = cert.Subject.CommonName
+						mutual = port.TLSInformation.Mutual
+						keyType = cert.KeyType
+						chain = cert.Chain
+					}
+
 					res, err = tx.Exec(`
             INSERT INTO tls_certificates (
                port_id,
@@ -199,17 +249,23 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
                cert_locality,
                cert_organization,
                cert_common_name,
-               mutual
-             ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+               mutual,
+               key_type,
+               server_cipher_preference,
+               dh_bits
+             ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 						portID,
-						cert.Expiration,
-						cert.Bits,
-						cert.Subject.Country,
-						cert.Subject.Province,
-						cert.Subject.Locality,
-						cert.Subject.Organization,
-						cert.Subject.CommonName,
-						port.TLSInformation.Mutual,
+						expiration,
+						bits,
+						country,
+						province,
+						locality,
+						organization,
+						commonName,
+						mutual,
+						keyType,
+						port.TLSInformation.ServerCipherPreference,
+						port.TLSInformation.KeyExchange.DHBits,
 					)
 					if err != nil {
 						return err
@@ -220,6 +276,42 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 						return err
 					}
 
+					for position, chainCert := range chain {
+						_, err = tx.Exec(`
+            INSERT INTO tls_certificate_chain (
+               certificate_id,
+               position,
+               subject,
+               issuer,
+               serial_number,
+               not_before,
+               not_after,
+               sans,
+               signature_algorithm,
+               key_type,
+               key_bits,
+               sha256_fingerprint,
+               self_signed
+             ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+							certID,
+							position,
+							chainCert.Subject,
+							chainCert.Issuer,
+							chainCert.SerialNumber,
+							chainCert.NotBefore,
+							chainCert.NotAfter,
+							strings.Join(chainCert.SANs, " "),
+							chainCert.SignatureAlgorithm,
+							chainCert.KeyType,
+							chainCert.KeyBits,
+							chainCert.SHA256Fingerprint,
+							chainCert.SelfSigned,
+						)
+						if err != nil {
+							return err
+						}
+					}
+
 					for suite, ciphers := range port.TLSInformation.CipherInformation {
 						if len(ciphers) > 0 {
 							suiteID, err := getIndexOrInsert(
@@ -229,7 +321,7 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 								return err
 							}
 
-							for _, cipher := range ciphers {
+							for position, cipher := range ciphers {
 								cipherID, err := getIndexOrInsert(
 									func() *sql.Row { return tx.QueryRow("SELECT id FROM tls_ciphers WHERE cipher = ?", cipher) },
 									func() (sql.Result, error) { return tx.Exec("INSERT INTO tls_ciphers(cipher) VALUES (?)", cipher) })
@@ -237,13 +329,93 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 									return err
 								}
 
-								_, err = tx.Exec("INSERT INTO certificate_to_ciphersuite(certificate_id, suite_id, cipher_id) VALUES (?, ?, ?)", certID, suiteID, cipherID)
+								_, err = tx.Exec("INSERT INTO certificate_to_ciphersuite(certificate_id, suite_id, cipher_id, position) VALUES (?, ?, ?, ?)", certID, suiteID, cipherID, position)
 								if err != nil {
 									return err
 								}
 							}
 						}
 					}
+
+					for _, group := range port.TLSInformation.KeyExchange.Groups {
+						_, err = tx.Exec("INSERT INTO tls_key_exchange_groups(certificate_id, group_name) VALUES (?, ?)", certID, group)
+						if err != nil {
+							return err
+						}
+					}
+
+					for _, protocol := range port.TLSInformation.ALPNProtocols {
+						_, err = tx.Exec("INSERT INTO tls_alpn_protocols(certificate_id, protocol) VALUES (?, ?)", certID, protocol)
+						if err != nil {
+							return err
+						}
+					}
+				}
+
+				if port.HTTPInformation != nil {
+					httpInfo := port.HTTPInformation
+
+					_, err = tx.Exec(`
+            INSERT INTO http_endpoints (
+               port_id,
+               tls,
+               status_code,
+               hsts,
+               server,
+               powered_by,
+               redirects_to_https
+             ) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+						portID,
+						httpInfo.TLS,
+						httpInfo.StatusCode,
+						httpInfo.HSTS,
+						httpInfo.Server,
+						httpInfo.PoweredBy,
+						httpInfo.RedirectsToHTTPS,
+					)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			capabilitySets := []struct {
+				kind  string
+				names []string
+			}{
+				{"effective", service.EffectiveCapabilities},
+				{"permitted", service.PermittedCapabilities},
+			}
+
+			for _, set := range capabilitySets {
+				for _, name := range set.names {
+					_, err = tx.Exec(
+						"INSERT INTO process_capabilities(process_id, kind, name) VALUES (?, ?, ?)",
+						processID, set.kind, name,
+					)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			for _, socket := range service.UnixSockets {
+				_, err = tx.Exec(
+					"INSERT INTO sockets(process_id, family, type, path, permissions, user, socket_group) VALUES (?, ?, ?, ?, ?, ?, ?)",
+					processID, "unix", socket.Type, socket.Path, socket.Permissions, socket.User, socket.Group,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, socket := range service.RawSockets {
+				_, err = tx.Exec(
+					"INSERT INTO sockets(process_id, family, protocol) VALUES (?, ?, ?)",
+					processID, socket.Family, socket.Protocol,
+				)
+				if err != nil {
+					return err
 				}
 			}
 
@@ -311,10 +483,54 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 				return err
 			}
 		}
+
+		if config := scan.SSHConfiguration; config != nil {
+			res, err := tx.Exec("INSERT INTO ssh_servers(host_id, banner) VALUES (?, ?)", hostID, config.Banner)
+			if err != nil {
+				return err
+			}
+
+			serverID, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+
+			algorithms := []struct {
+				kind  string
+				names []string
+			}{
+				{"kex", config.KeyExchanges},
+				{"host_key", config.HostKeys},
+				{"cipher", config.Ciphers},
+				{"mac", config.MACs},
+			}
+
+			for _, algorithm := range algorithms {
+				for position, name := range algorithm.names {
+					_, err = tx.Exec(
+						"INSERT INTO ssh_algorithms(ssh_server_id, kind, name, position) VALUES (?, ?, ?, ?)",
+						serverID, algorithm.kind, name, position,
+					)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	for _, failure := range report.Failures {
+		_, err := tx.Exec(
+			"INSERT INTO scan_failures(scan_id, deployment_id, name, ip, stage, error) VALUES (?, ?, ?, ?, ?, ?)",
+			scanID, depID, failure.Job, failure.IP, failure.Stage, failure.Error,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
 	for _, releaseReport := range report.ReleaseResults {
-		_, err := tx.Exec("INSERT INTO releases(name, version, deployment_id) VALUES (?, ?, ?)", releaseReport.Name, releaseReport.Version, depID)
+		_, err := tx.Exec("INSERT INTO releases(scan_id, name, version, deployment_id) VALUES (?, ?, ?, ?)", scanID, releaseReport.Name, releaseReport.Version, depID)
 		if err != nil {
 			return err
 		}
@@ -2,7 +2,7 @@ package db_test
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -81,7 +81,15 @@ var _ = Describe("Sqlite", func() {
 				"processes",
 				"releases",
 				"ssh_keys",
+				"ssh_servers",
+				"ssh_algorithms",
+				"sockets",
+				"process_capabilities",
 				"tls_certificates",
+				"tls_certificate_chain",
+				"tls_key_exchange_groups",
+				"tls_alpn_protocols",
+				"http_endpoints",
 				"tls_suites",
 				"tls_ciphers",
 				"certificate_to_ciphersuite",
@@ -89,6 +97,8 @@ var _ = Describe("Sqlite", func() {
 				"version",
 				"regexes",
 				"file_to_regex",
+				"scan_failures",
+				"scans",
 			))
 		})
 
@@ -171,10 +181,12 @@ var _ = Describe("Sqlite", func() {
 	Describe("SaveReport", func() {
 		var (
 			database       *db.Database
+			scan           db.Scan
 			hosts          scanner.ScanResult
 			host           scanner.JobResult
 			sqliteDB       *sql.DB
 			certExpiration time.Time
+			certNotBefore  time.Time
 		)
 
 		BeforeEach(func() {
@@ -182,6 +194,9 @@ var _ = Describe("Sqlite", func() {
 			database, err = db.CreateDatabase(dbPath)
 			Expect(err).NotTo(HaveOccurred())
 
+			scan, err = database.StartScan("scantron bosh-scan", "operator")
+			Expect(err).NotTo(HaveOccurred())
+
 			sqliteDB, err = sql.Open("sqlite3", dbPath)
 			Expect(err).NotTo(HaveOccurred())
 		})
@@ -197,23 +212,51 @@ var _ = Describe("Sqlite", func() {
 				certExpiration, err = time.Parse(time.RFC3339, "2012-11-01T22:08:41+00:00")
 				Expect(err).NotTo(HaveOccurred())
 
+				serverCipherPreference := true
+
+				certNotBefore, err = time.Parse(time.RFC3339, "2010-11-01T22:08:41+00:00")
+				Expect(err).NotTo(HaveOccurred())
+
 				host = scanner.JobResult{
 					IP:  "10.0.0.1",
 					Job: "custom_name/0",
 					Services: []scantron.Process{{
-						CommandName: "server-name",
-						PID:         213,
-						User:        "root",
-						Cmdline:     []string{"this", "is", "a", "cmd"},
-						Env:         []string{"PATH=this", "OTHER=that"},
+						CommandName:           "server-name",
+						PID:                   213,
+						PPID:                  1,
+						User:                  "root",
+						Cmdline:               []string{"this", "is", "a", "cmd"},
+						Env:                   []string{"PATH=this", "OTHER=that"},
+						StartTime:             time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+						Executable:            "/var/vcap/packages/server/bin/server",
+						ExecutableSHA256:      "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+						ExecutableDeleted:     true,
+						EffectiveCapabilities: []string{"CAP_NET_BIND_SERVICE"},
+						PermittedCapabilities: []string{"CAP_NET_BIND_SERVICE", "CAP_SYS_ADMIN"},
+						NoNewPrivs:            true,
+						Seccomp:               "filter",
+						Namespaces:            scantron.Namespaces{Net: 4026531992, PID: 4026531836, Mount: 4026531840},
+						UnixSockets: []scantron.UnixSocket{{
+							Path:        "/var/run/docker.sock",
+							Type:        "stream",
+							Permissions: 0660,
+							User:        "root",
+							Group:       "docker",
+						}},
+						RawSockets: []scantron.RawSocket{{
+							Family:   "raw",
+							Protocol: 1,
+						}},
 						Ports: []scantron.Port{
 							{
-								Protocol: "TCP",
-								Address:  "123.0.0.1",
-								Number:   123,
+								Protocol:      "TCP",
+								Address:       "123.0.0.1",
+								Number:        123,
+								ProbedAddress: "123.0.0.1",
 								TLSInformation: &scantron.TLSInformation{
-									ScanError: errors.New("this was a terrible error"),
-									Mutual:    true,
+									ScanError:              "this was a terrible error",
+									Mutual:                 true,
+									ServerCipherPreference: &serverCipherPreference,
 									CipherInformation: scantron.CipherInformation{
 										"tls1.0": []string{
 											"ECDHE-NOT-REALLY-SECURE",
@@ -221,10 +264,23 @@ var _ = Describe("Sqlite", func() {
 										"tls1.1": []string{
 											"ECDHE-REALLY-SECURE",
 										},
+										"tls1.2": []string{
+											"ECDHE-PREFERRED",
+											"DHE-FALLBACK",
+										},
+									},
+									KeyExchange: scantron.KeyExchange{
+										Groups: []string{"x25519", "secp256r1"},
+										DHBits: 1024,
+									},
+									ALPNProtocols: []string{"h2", "http/1.1"},
+									ProbeErrors: []scantron.ProbeError{
+										{Protocol: "tls1.2", Error: "read: connection reset by peer"},
 									},
 									Certificate: &scantron.Certificate{
 										Expiration: certExpiration,
 										Bits:       234,
+										KeyType:    "RSA",
 										Subject: scantron.CertificateSubject{
 											Country:      "some-country",
 											Province:     "some-province",
@@ -232,8 +288,42 @@ var _ = Describe("Sqlite", func() {
 											Organization: "some-organization",
 											CommonName:   "some-common-name",
 										},
+										Chain: []scantron.ChainCertificate{
+											{
+												Subject:            "CN=some-common-name",
+												Issuer:             "CN=some-intermediate",
+												SerialNumber:       "1a",
+												NotBefore:          certNotBefore,
+												NotAfter:           certExpiration,
+												SANs:               []string{"some-host.example.com", "10.0.0.1"},
+												SignatureAlgorithm: "SHA256-RSA",
+												KeyType:            "RSA",
+												KeyBits:            234,
+												SHA256Fingerprint:  "abcdef",
+											},
+											{
+												Subject:            "CN=some-intermediate",
+												Issuer:             "CN=some-intermediate",
+												SerialNumber:       "1",
+												NotBefore:          certNotBefore,
+												NotAfter:           certExpiration,
+												SANs:               []string{},
+												SignatureAlgorithm: "SHA1-RSA",
+												KeyType:            "ECDSA",
+												KeyBits:            384,
+												SHA256Fingerprint:  "123456",
+												SelfSigned:         true,
+											},
+										},
 									},
 								},
+								HTTPInformation: &scantron.HTTPInformation{
+									TLS:        true,
+									StatusCode: 200,
+									HSTS:       "max-age=31536000",
+									Server:     "nginx/1.18.0",
+									PoweredBy:  "Express",
+								},
 							},
 						},
 					}},
@@ -253,13 +343,20 @@ var _ = Describe("Sqlite", func() {
 							Key:  "My Special DSA Key",
 						},
 					},
+					SSHConfiguration: &scantron.SSHConfiguration{
+						Banner:       "SSH-2.0-OpenSSH_7.4",
+						KeyExchanges: []string{"curve25519-sha256", "diffie-hellman-group1-sha1"},
+						HostKeys:     []string{"ssh-ed25519"},
+						Ciphers:      []string{"aes256-ctr", "aes128-cbc"},
+						MACs:         []string{"hmac-sha2-256"},
+					},
 				}
 
 				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
 			})
 
 			It("records host information", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(` SELECT name, ip FROM	hosts `)
@@ -281,7 +378,7 @@ var _ = Describe("Sqlite", func() {
 			})
 
 			It("records process information", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(` SELECT pid, user, cmdline FROM processes `)
@@ -302,31 +399,101 @@ var _ = Describe("Sqlite", func() {
 				Expect(cmdline).To(Equal("this is a cmd"))
 			})
 
+			It("records the parent, start time and executable of processes", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					ppid                         int
+					startTime                    time.Time
+					executable, executableSHA256 string
+					executableDeleted            bool
+				)
+				err = sqliteDB.QueryRow(`
+				SELECT ppid, start_time, executable, executable_sha256, executable_deleted
+				FROM processes
+				WHERE pid = 213`).Scan(&ppid, &startTime, &executable, &executableSHA256, &executableDeleted)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(ppid).To(Equal(1))
+				Expect(startTime.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))).To(BeTrue())
+				Expect(executable).To(Equal("/var/vcap/packages/server/bin/server"))
+				Expect(executableSHA256).To(Equal("5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"))
+				Expect(executableDeleted).To(BeTrue())
+			})
+
+			It("records the capabilities, sandboxing and namespaces of processes", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					noNewPrivs          bool
+					seccomp             string
+					netNS, pidNS, mntNS uint64
+				)
+				err = sqliteDB.QueryRow(`
+				SELECT no_new_privs, seccomp, net_ns, pid_ns, mnt_ns
+				FROM processes
+				WHERE pid = 213`).Scan(&noNewPrivs, &seccomp, &netNS, &pidNS, &mntNS)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(noNewPrivs).To(BeTrue())
+				Expect(seccomp).To(Equal("filter"))
+				Expect(netNS).To(BeEquivalentTo(4026531992))
+				Expect(pidNS).To(BeEquivalentTo(4026531836))
+				Expect(mntNS).To(BeEquivalentTo(4026531840))
+
+				rows, err := sqliteDB.Query(`
+				SELECT c.kind, c.name
+				FROM process_capabilities c
+				  JOIN processes p
+				    ON c.process_id = p.id
+				WHERE p.pid = 213
+				ORDER BY c.id`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				capabilities := []string{}
+				for rows.Next() {
+					var kind, name string
+					Expect(rows.Scan(&kind, &name)).To(Succeed())
+					capabilities = append(capabilities, kind+" "+name)
+				}
+				Expect(rows.Err()).NotTo(HaveOccurred())
+
+				Expect(capabilities).To(Equal([]string{
+					"effective CAP_NET_BIND_SERVICE",
+					"permitted CAP_NET_BIND_SERVICE",
+					"permitted CAP_SYS_ADMIN",
+				}))
+			})
+
 			It("records port information", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
-				rows, err := sqliteDB.Query(`SELECT protocol, address, number FROM ports`)
+				rows, err := sqliteDB.Query(`SELECT protocol, address, number, probed_address FROM ports`)
 				Expect(err).NotTo(HaveOccurred())
 				defer rows.Close()
 				hasRows := rows.Next()
 				Expect(hasRows).To(BeTrue())
 
 				var (
-					protocol, address string
-					number            int
+					protocol, address, probedAddress string
+					number                           int
 				)
 
-				err = rows.Scan(&protocol, &address, &number)
+				err = rows.Scan(&protocol, &address, &number, &probedAddress)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(protocol).To(Equal("TCP"))
 				Expect(address).To(Equal("123.0.0.1"))
 				Expect(number).To(Equal(123))
+				Expect(probedAddress).To(Equal("123.0.0.1"))
 			})
 
 			It("records tls informations", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(`
@@ -394,11 +561,183 @@ var _ = Describe("Sqlite", func() {
 				Expect(mutual).To(BeTrue())
 			})
 
+			It("records the certificate key type", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var keyType string
+				err = sqliteDB.QueryRow(`SELECT key_type FROM tls_certificates`).Scan(&keyType)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(keyType).To(Equal("RSA"))
+			})
+
+			It("records when the server cipher order could not be checked", func() {
+				host.Services[0].Ports[0].TLSInformation.ServerCipherPreference = nil
+
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var serverCipherPreference sql.NullBool
+				err = sqliteDB.QueryRow(`SELECT server_cipher_preference FROM tls_certificates`).Scan(&serverCipherPreference)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(serverCipherPreference.Valid).To(BeFalse())
+			})
+
+			It("records the server cipher order and key exchange", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					serverCipherPreference bool
+					dhBits                 int
+				)
+				err = sqliteDB.QueryRow(`SELECT server_cipher_preference, dh_bits FROM tls_certificates`).Scan(&serverCipherPreference, &dhBits)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(serverCipherPreference).To(BeTrue())
+				Expect(dhBits).To(Equal(1024))
+
+				rows, err := sqliteDB.Query(`
+				SELECT c.cipher
+				FROM certificate_to_ciphersuite ctc
+				JOIN tls_suites s
+				  ON ctc.suite_id = s.id
+				JOIN tls_ciphers c
+				  ON ctc.cipher_id = c.id
+				WHERE s.suite = 'tls1.2'
+				ORDER BY ctc.position`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				ciphers := []string{}
+				for rows.Next() {
+					var cipher string
+					Expect(rows.Scan(&cipher)).To(Succeed())
+					ciphers = append(ciphers, cipher)
+				}
+				Expect(ciphers).To(Equal([]string{"ECDHE-PREFERRED", "DHE-FALLBACK"}))
+
+				groupRows, err := sqliteDB.Query(`SELECT group_name FROM tls_key_exchange_groups ORDER BY id`)
+				Expect(err).NotTo(HaveOccurred())
+				defer groupRows.Close()
+
+				groups := []string{}
+				for groupRows.Next() {
+					var group string
+					Expect(groupRows.Scan(&group)).To(Succeed())
+					groups = append(groups, group)
+				}
+				Expect(groups).To(Equal([]string{"x25519", "secp256r1"}))
+			})
+
+			It("records the ALPN protocols", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				rows, err := sqliteDB.Query(`SELECT protocol FROM tls_alpn_protocols ORDER BY id`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				protocols := []string{}
+				for rows.Next() {
+					var protocol string
+					Expect(rows.Scan(&protocol)).To(Succeed())
+					protocols = append(protocols, protocol)
+				}
+				Expect(protocols).To(Equal([]string{"h2", "http/1.1"}))
+			})
+
+			It("records the HTTP headers", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					number                  int
+					tls, redirectsToHTTPS   bool
+					statusCode              int
+					hsts, server, poweredBy string
+				)
+				err = sqliteDB.QueryRow(`
+				SELECT po.number, e.tls, e.status_code, e.hsts, e.server, e.powered_by, e.redirects_to_https
+				FROM http_endpoints e
+				JOIN ports po
+				  ON e.port_id = po.id`).Scan(&number, &tls, &statusCode, &hsts, &server, &poweredBy, &redirectsToHTTPS)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(number).To(Equal(123))
+				Expect(tls).To(BeTrue())
+				Expect(statusCode).To(Equal(200))
+				Expect(hsts).To(Equal("max-age=31536000"))
+				Expect(server).To(Equal("nginx/1.18.0"))
+				Expect(poweredBy).To(Equal("Express"))
+				Expect(redirectsToHTTPS).To(BeFalse())
+			})
+
+			It("records the certificate chain", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				rows, err := sqliteDB.Query(`
+				SELECT
+					position,
+					subject,
+					issuer,
+					serial_number,
+					not_before,
+					not_after,
+					sans,
+					signature_algorithm,
+					key_type,
+					key_bits,
+					sha256_fingerprint,
+					self_signed
+				FROM tls_certificate_chain
+				ORDER BY position`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				type chainRow struct {
+					position                                                                int
+					subject, issuer, serial, sans, signatureAlgorithm, keyType, fingerprint string
+					notBefore, notAfter                                                     time.Time
+					keyBits                                                                 int
+					selfSigned                                                              bool
+				}
+
+				chain := []chainRow{}
+				for rows.Next() {
+					var r chainRow
+					err := rows.Scan(&r.position, &r.subject, &r.issuer, &r.serial, &r.notBefore, &r.notAfter, &r.sans, &r.signatureAlgorithm, &r.keyType, &r.keyBits, &r.fingerprint, &r.selfSigned)
+					Expect(err).NotTo(HaveOccurred())
+					chain = append(chain, r)
+				}
+
+				Expect(chain).To(HaveLen(2))
+
+				Expect(chain[0].position).To(Equal(0))
+				Expect(chain[0].subject).To(Equal("CN=some-common-name"))
+				Expect(chain[0].issuer).To(Equal("CN=some-intermediate"))
+				Expect(chain[0].serial).To(Equal("1a"))
+				Expect(chain[0].notBefore.Equal(certNotBefore)).To(BeTrue())
+				Expect(chain[0].notAfter.Equal(certExpiration)).To(BeTrue())
+				Expect(chain[0].sans).To(Equal("some-host.example.com 10.0.0.1"))
+				Expect(chain[0].signatureAlgorithm).To(Equal("SHA256-RSA"))
+				Expect(chain[0].keyType).To(Equal("RSA"))
+				Expect(chain[0].keyBits).To(Equal(234))
+				Expect(chain[0].fingerprint).To(Equal("abcdef"))
+				Expect(chain[0].selfSigned).To(BeFalse())
+
+				Expect(chain[1].position).To(Equal(1))
+				Expect(chain[1].signatureAlgorithm).To(Equal("SHA1-RSA"))
+				Expect(chain[1].keyType).To(Equal("ECDSA"))
+				Expect(chain[1].keyBits).To(Equal(384))
+				Expect(chain[1].selfSigned).To(BeTrue())
+			})
+
 			It("records tls errors", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
-				rows, err := sqliteDB.Query(`SELECT cert_scan_error FROM tls_scan_errors`)
+				rows, err := sqliteDB.Query(`SELECT cert_scan_error, protocol FROM tls_scan_errors ORDER BY id`)
 				Expect(err).NotTo(HaveOccurred())
 				defer rows.Close()
 				hasRows := rows.Next()
@@ -406,15 +745,25 @@ var _ = Describe("Sqlite", func() {
 
 				var (
 					cert_scan_error string
+					protocol        sql.NullString
 				)
 
-				err = rows.Scan(&cert_scan_error)
+				err = rows.Scan(&cert_scan_error, &protocol)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(cert_scan_error).To(Equal("this was a terrible error"))
+				Expect(protocol.Valid).To(BeFalse())
+
+				hasRows = rows.Next()
+				Expect(hasRows).To(BeTrue())
+
+				err = rows.Scan(&cert_scan_error, &protocol)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cert_scan_error).To(Equal("read: connection reset by peer"))
+				Expect(protocol.String).To(Equal("tls1.2"))
 			})
 
 			It("records env_vars info", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(`SELECT env_vars.var FROM env_vars`)
@@ -430,8 +779,51 @@ var _ = Describe("Sqlite", func() {
 				Expect(env_vars).To(Equal("PATH=this OTHER=that"))
 			})
 
+			It("records the process's sockets", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				rows, err := sqliteDB.Query(`
+				SELECT s.family, s.type, s.path, s.protocol, s.permissions, s.user, s.socket_group
+				FROM sockets s
+				  JOIN processes p
+				    ON s.process_id = p.id
+				WHERE p.pid = 213
+				ORDER BY s.id`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				type socketRow struct {
+					family, socketType, path, user, group sql.NullString
+					protocol, permissions                 sql.NullInt64
+				}
+
+				sockets := []socketRow{}
+				for rows.Next() {
+					var row socketRow
+					err = rows.Scan(&row.family, &row.socketType, &row.path, &row.protocol, &row.permissions, &row.user, &row.group)
+					Expect(err).NotTo(HaveOccurred())
+					sockets = append(sockets, row)
+				}
+				Expect(rows.Err()).NotTo(HaveOccurred())
+
+				Expect(sockets).To(HaveLen(2))
+
+				Expect(sockets[0].family.String).To(Equal("unix"))
+				Expect(sockets[0].socketType.String).To(Equal("stream"))
+				Expect(sockets[0].path.String).To(Equal("/var/run/docker.sock"))
+				Expect(sockets[0].protocol.Valid).To(BeFalse())
+				Expect(sockets[0].permissions.Int64).To(BeEquivalentTo(0660))
+				Expect(sockets[0].user.String).To(Equal("root"))
+				Expect(sockets[0].group.String).To(Equal("docker"))
+
+				Expect(sockets[1].family.String).To(Equal("raw"))
+				Expect(sockets[1].path.Valid).To(BeFalse())
+				Expect(sockets[1].protocol.Int64).To(BeEquivalentTo(1))
+			})
+
 			It("records file information", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(`SELECT path, permissions FROM files`)
@@ -452,7 +844,7 @@ var _ = Describe("Sqlite", func() {
 			})
 
 			It("records sshkey information", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(`SELECT ssh_keys.type, ssh_keys.key FROM ssh_keys`)
@@ -478,6 +870,47 @@ var _ = Describe("Sqlite", func() {
 				Expect(sshKey).To(Equal("My Special DSA Key"))
 			})
 
+			It("records the ssh server's banner and algorithms", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var banner string
+				err = sqliteDB.QueryRow(`SELECT banner FROM ssh_servers`).Scan(&banner)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(banner).To(Equal("SSH-2.0-OpenSSH_7.4"))
+
+				rows, err := sqliteDB.Query(`
+				SELECT a.kind, a.name, a.position
+				FROM ssh_algorithms a
+				  JOIN ssh_servers s
+				    ON a.ssh_server_id = s.id
+				ORDER BY a.id`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				algorithms := []string{}
+				for rows.Next() {
+					var (
+						kind, name string
+						position   int
+					)
+
+					err = rows.Scan(&kind, &name, &position)
+					Expect(err).NotTo(HaveOccurred())
+
+					algorithms = append(algorithms, fmt.Sprintf("%s %d %s", kind, position, name))
+				}
+
+				Expect(algorithms).To(Equal([]string{
+					"kex 0 curve25519-sha256",
+					"kex 1 diffie-hellman-group1-sha1",
+					"host_key 0 ssh-ed25519",
+					"cipher 0 aes256-ctr",
+					"cipher 1 aes128-cbc",
+					"mac 0 hmac-sha2-256",
+				}))
+			})
+
 			Context("when the service does not have a certificate", func() {
 				BeforeEach(func() {
 					service := host.Services[0]
@@ -487,7 +920,7 @@ var _ = Describe("Sqlite", func() {
 				})
 
 				It("records a process", func() {
-					err := database.SaveReport("cf1", hosts)
+					err := database.SaveReport(scan.ID, "cf1", hosts)
 					Expect(err).NotTo(HaveOccurred())
 
 					rows, err := sqliteDB.Query(`
@@ -527,22 +960,38 @@ var _ = Describe("Sqlite", func() {
 					Expect(portNumber).To(Equal(123))
 				})
 
-				It("does not store any tls information", func() {
-					err := database.SaveReport("cf1", hosts)
+				It("stores the cipher suites without certificate details", func() {
+					err := database.SaveReport(scan.ID, "cf1", hosts)
 					Expect(err).NotTo(HaveOccurred())
 
-					rows, err := sqliteDB.Query(`SELECT count(1) FROM tls_certificates`)
+					var (
+						expiration sql.NullString
+						bits       sql.NullInt64
+						mutual     sql.NullBool
+						keyType    sql.NullString
+						preference bool
+					)
+
+					err = sqliteDB.QueryRow(`
+						SELECT cert_expiration, cert_bits, mutual, key_type, server_cipher_preference
+						FROM tls_certificates`).Scan(&expiration, &bits, &mutual, &keyType, &preference)
 					Expect(err).NotTo(HaveOccurred())
-					defer rows.Close()
 
-					hasRows := rows.Next()
-					Expect(hasRows).To(BeTrue())
+					Expect(expiration.Valid).To(BeFalse())
+					Expect(bits.Valid).To(BeFalse())
+					Expect(mutual.Valid).To(BeFalse())
+					Expect(keyType.Valid).To(BeFalse())
+					Expect(preference).To(BeTrue())
 
-					var count int
-					err = rows.Scan(&count)
+					var ciphers int
+					err = sqliteDB.QueryRow(`SELECT count(1) FROM certificate_to_ciphersuite`).Scan(&ciphers)
 					Expect(err).NotTo(HaveOccurred())
+					Expect(ciphers).NotTo(BeZero())
 
-					Expect(count).To(BeZero())
+					var chain int
+					err = sqliteDB.QueryRow(`SELECT count(1) FROM tls_certificate_chain`).Scan(&chain)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(chain).To(BeZero())
 				})
 			})
 		})
@@ -564,7 +1013,7 @@ var _ = Describe("Sqlite", func() {
 			})
 
 			It("records only a single host", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(`SELECT COUNT(*) FROM hosts`)
@@ -582,6 +1031,45 @@ var _ = Describe("Sqlite", func() {
 			})
 		})
 
+		Context("when the same host is scanned more than once", func() {
+			BeforeEach(func() {
+				hosts = scanner.ScanResult{
+					JobResults: []scanner.JobResult{
+						{
+							IP:  "10.0.0.1",
+							Job: "custom_name/0",
+						},
+					},
+				}
+			})
+
+			It("records a host for each scan", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				secondScan, err := database.StartScan("scantron bosh-scan", "operator")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = database.SaveReport(secondScan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				rows, err := sqliteDB.Query(`SELECT scan_id FROM hosts ORDER BY scan_id`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				scanIDs := []int{}
+				for rows.Next() {
+					var scanID int
+					err = rows.Scan(&scanID)
+					Expect(err).NotTo(HaveOccurred())
+
+					scanIDs = append(scanIDs, scanID)
+				}
+
+				Expect(scanIDs).To(Equal([]int{scan.ID, secondScan.ID}))
+			})
+		})
+
 		Context("with a multiple services on different hosts", func() {
 			BeforeEach(func() {
 				hosts = scanner.ScanResult{
@@ -599,7 +1087,7 @@ var _ = Describe("Sqlite", func() {
 			})
 
 			It("records both hosts", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(`SELECT COUNT(*) FROM hosts`)
@@ -632,7 +1120,7 @@ var _ = Describe("Sqlite", func() {
 			})
 
 			It("records the release version and name", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				rows, err := sqliteDB.Query(` SELECT name, version FROM	releases `)
@@ -660,7 +1148,48 @@ var _ = Describe("Sqlite", func() {
 				_, err := sqliteDB.Exec(`DROP TABLE releases`)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = database.SaveReport("cf1", hosts)
+				err = database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("with scan failures", func() {
+			BeforeEach(func() {
+				hosts.Failures = []scanner.FailureResult{
+					{
+						Job:   "router/abc",
+						IP:    "10.0.0.7",
+						Stage: scanner.StageConnect,
+						Error: "connection refused",
+					},
+				}
+			})
+
+			It("records the host, stage, and error of the failure", func() {
+				err := database.SaveReport(scan.ID, "cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var deployment, name, ip, stage, scanError string
+				err = sqliteDB.QueryRow(`
+					SELECT deployments.name, scan_failures.name, scan_failures.ip, scan_failures.stage, scan_failures.error
+					FROM scan_failures
+						JOIN deployments
+							ON scan_failures.deployment_id = deployments.id`,
+				).Scan(&deployment, &name, &ip, &stage, &scanError)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(deployment).To(Equal("cf1"))
+				Expect(name).To(Equal("router/abc"))
+				Expect(ip).To(Equal("10.0.0.7"))
+				Expect(stage).To(Equal("connect"))
+				Expect(scanError).To(Equal("connection refused"))
+			})
+
+			It("returns an error when inserting fails", func() {
+				_, err := sqliteDB.Exec(`DROP TABLE scan_failures`)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -690,7 +1219,7 @@ var _ = Describe("Sqlite", func() {
 			})
 
 			It("records the regexes the file matched", func() {
-				err := database.SaveReport("cf1", hosts)
+				err := database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
 				regexes, err := sqliteDB.Query(` SELECT pr.regex as path_regex, cr.regex as content_regex, files.path from files 
@@ -716,7 +1245,7 @@ var _ = Describe("Sqlite", func() {
 				_, err = sqliteDB.Exec(`DROP TABLE regexes`)
 				Expect(err).NotTo(HaveOccurred())
 
-				err = database.SaveReport("cf1", hosts)
+				err = database.SaveReport(scan.ID, "cf1", hosts)
 				Expect(err).To(HaveOccurred())
 			})
 		})
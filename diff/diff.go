@@ -0,0 +1,271 @@
+package diff
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pivotal-cf/scantron/report"
+)
+
+const (
+	HostAdded            = "host added"
+	HostRemoved          = "host removed"
+	PortOpened           = "port opened"
+	PortClosed           = "port closed"
+	ProcessUserChanged   = "process user changed"
+	TLSProtocolsAdded    = "tls protocols added"
+	TLSProtocolsRemoved  = "tls protocols removed"
+	TLSCiphersAdded      = "tls ciphers added"
+	TLSCiphersRemoved    = "tls ciphers removed"
+	CertificateChanged   = "certificate expiry changed"
+	WorldReadableFileNew = "world-readable file added"
+	SSHKeyRotated        = "ssh key rotated"
+)
+
+// Changes which widen what is exposed, or which may indicate that a machine
+// has been tampered with, are security-relevant.
+var securityRelevant = map[string]bool{
+	HostAdded:            true,
+	PortOpened:           true,
+	ProcessUserChanged:   true,
+	TLSProtocolsAdded:    true,
+	TLSCiphersAdded:      true,
+	WorldReadableFileNew: true,
+	SSHKeyRotated:        true,
+}
+
+type Change struct {
+	Host     string `json:"host"`
+	IP       string `json:"ip,omitempty"`
+	Kind     string `json:"kind"`
+	Subject  string `json:"subject,omitempty"`
+	Before   string `json:"before,omitempty"`
+	After    string `json:"after,omitempty"`
+	Security bool   `json:"security_relevant"`
+}
+
+type Result struct {
+	Changes []Change `json:"changes"`
+}
+
+func (r Result) SecurityRelevant() bool {
+	for _, change := range r.Changes {
+		if change.Security {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Compare finds what changed between the "from" scan and the "to" scan. The
+// scans may be in different databases.
+func Compare(fromDB *sql.DB, fromScanID int, toDB *sql.DB, toScanID int) (Result, error) {
+	from, err := loadSnapshot(fromDB, fromScanID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	to, err := loadSnapshot(toDB, toScanID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	changes := []Change{}
+	add := func(change Change) {
+		change.Security = securityRelevant[change.Kind]
+		changes = append(changes, change)
+	}
+
+	for _, host := range sortedKeys(to) {
+		if _, ok := from[host]; !ok {
+			add(Change{Host: host.name, IP: host.ip, Kind: HostAdded})
+		}
+	}
+
+	for _, host := range sortedKeys(from) {
+		if _, ok := to[host]; !ok {
+			add(Change{Host: host.name, IP: host.ip, Kind: HostRemoved})
+			continue
+		}
+
+		host := host
+		compareHost(from[host], to[host], func(change Change) {
+			change.Host = host.name
+			change.IP = host.ip
+			add(change)
+		})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Host != changes[j].Host {
+			return changes[i].Host < changes[j].Host
+		}
+
+		return changes[i].IP < changes[j].IP
+	})
+
+	return Result{Changes: changes}, nil
+}
+
+// compareHost finds what changed on a host. The changes are passed to add
+// without the host, which the caller fills in.
+func compareHost(from, to *hostSnapshot, add func(Change)) {
+	for _, key := range sortedPortKeys(to.ports) {
+		if _, ok := from.ports[key]; !ok {
+			add(Change{Kind: PortOpened, Subject: portSubject(key, to.ports[key])})
+		}
+	}
+
+	for _, key := range sortedPortKeys(from.ports) {
+		fromPort := from.ports[key]
+
+		toPort, ok := to.ports[key]
+		if !ok {
+			add(Change{Kind: PortClosed, Subject: portSubject(key, fromPort)})
+			continue
+		}
+
+		subject := portSubject(key, toPort)
+
+		if added := toPort.protocols.minus(fromPort.protocols); len(added) > 0 {
+			add(Change{Kind: TLSProtocolsAdded, Subject: subject, After: strings.Join(added, ", ")})
+		}
+
+		if removed := fromPort.protocols.minus(toPort.protocols); len(removed) > 0 {
+			add(Change{Kind: TLSProtocolsRemoved, Subject: subject, Before: strings.Join(removed, ", ")})
+		}
+
+		if added := toPort.ciphers.minus(fromPort.ciphers); len(added) > 0 {
+			add(Change{Kind: TLSCiphersAdded, Subject: subject, After: strings.Join(added, ", ")})
+		}
+
+		if removed := fromPort.ciphers.minus(toPort.ciphers); len(removed) > 0 {
+			add(Change{Kind: TLSCiphersRemoved, Subject: subject, Before: strings.Join(removed, ", ")})
+		}
+
+		if !sameExpiration(fromPort.expiration, toPort.expiration) {
+			add(Change{
+				Kind:    CertificateChanged,
+				Subject: subject,
+				Before:  formatExpiration(fromPort.expiration),
+				After:   formatExpiration(toPort.expiration),
+			})
+		}
+	}
+
+	for _, process := range sortedSetKeys(from.processUsers) {
+		toUsers, ok := to.processUsers[process]
+		if !ok || from.processUsers[process].equal(toUsers) {
+			continue
+		}
+
+		add(Change{
+			Kind:    ProcessUserChanged,
+			Subject: process,
+			Before:  from.processUsers[process].String(),
+			After:   toUsers.String(),
+		})
+	}
+
+	for _, path := range to.worldReadable.minus(from.worldReadable) {
+		add(Change{Kind: WorldReadableFileNew, Subject: path})
+	}
+
+	for _, keyType := range sortedSetKeys(from.sshKeys) {
+		toKeys, ok := to.sshKeys[keyType]
+		if !ok || from.sshKeys[keyType].equal(toKeys) {
+			continue
+		}
+
+		add(Change{Kind: SSHKeyRotated, Subject: keyType})
+	}
+}
+
+func portSubject(key string, port portSnapshot) string {
+	return key + " (" + port.process + ")"
+}
+
+func sameExpiration(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+func formatExpiration(expiration *time.Time) string {
+	if expiration == nil {
+		return "none"
+	}
+
+	return expiration.UTC().Format("2006-01-02")
+}
+
+func sortedKeys(snap snapshot) []hostKey {
+	keys := []hostKey{}
+	for key := range snap {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+
+		return keys[i].ip < keys[j].ip
+	})
+
+	return keys
+}
+
+func sortedPortKeys(ports map[string]portSnapshot) []string {
+	keys := []string{}
+	for key := range ports {
+		keys = append(keys, key)
+	}
+
+	return sorted(keys)
+}
+
+func sortedSetKeys(sets map[string]stringSet) []string {
+	keys := []string{}
+	for key := range sets {
+		keys = append(keys, key)
+	}
+
+	return sorted(keys)
+}
+
+func sorted(values []string) []string {
+	sort.Strings(values)
+	return values
+}
+
+func (r Result) Report() report.Report {
+	rpt := report.Report{
+		Title:  "Changes between scans:",
+		Header: []string{"Identity", "IP", "Change", "Subject", "Before", "After", "Security-Relevant"},
+	}
+
+	for _, change := range r.Changes {
+		security := ""
+		if change.Security {
+			security = "yes"
+		}
+
+		rpt.Rows = append(rpt.Rows, []string{
+			change.Host,
+			change.IP,
+			change.Kind,
+			change.Subject,
+			change.Before,
+			change.After,
+			security,
+		})
+	}
+
+	return rpt
+}
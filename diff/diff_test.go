@@ -0,0 +1,330 @@
+package diff_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/diff"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+var _ = Describe("Compare", func() {
+	var (
+		database *db.Database
+		tmpdir   string
+
+		before, after scanner.ScanResult
+
+		result diff.Result
+	)
+
+	process := func(name, user string, ports ...scantron.Port) scantron.Process {
+		return scantron.Process{
+			CommandName: name,
+			User:        user,
+			Ports:       ports,
+		}
+	}
+
+	listening := func(number int) scantron.Port {
+		return scantron.Port{
+			Protocol: "tcp",
+			Address:  "0.0.0.0",
+			Number:   number,
+			State:    "LISTEN",
+		}
+	}
+
+	withTLS := func(port scantron.Port, expiration time.Time, ciphers scantron.CipherInformation) scantron.Port {
+		port.TLSInformation = &scantron.TLSInformation{
+			Certificate:       &scantron.Certificate{Expiration: expiration},
+			CipherInformation: ciphers,
+		}
+
+		return port
+	}
+
+	expiration := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "diff")
+		Expect(err).NotTo(HaveOccurred())
+
+		database, err = db.CreateDatabase(filepath.Join(tmpdir, "database.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		before = scanner.ScanResult{
+			JobResults: []scanner.JobResult{
+				{
+					Job: "router/0",
+					Services: []scantron.Process{
+						process("gorouter", "vcap",
+							listening(80),
+							withTLS(listening(443), expiration, scantron.CipherInformation{
+								"VersionTLS12": {"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+							}),
+						),
+					},
+					Files: []scantron.File{
+						{Path: "/var/vcap/jobs/gorouter/config.yml", Permissions: 0640},
+					},
+					SSHKeys: []scantron.SSHKey{
+						{Type: "ssh-rsa", Key: "key-1"},
+					},
+				},
+			},
+		}
+
+		after = before
+	})
+
+	JustBeforeEach(func() {
+		beforeScan, err := database.StartScan("scantron bosh-scan", "operator")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = database.SaveReport(beforeScan.ID, "cf", before)
+		Expect(err).NotTo(HaveOccurred())
+
+		afterScan, err := database.StartScan("scantron bosh-scan", "operator")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = database.SaveReport(afterScan.ID, "cf", after)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err = diff.Compare(database.DB(), beforeScan.ID, database.DB(), afterScan.ID)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		database.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	Context("when nothing changed", func() {
+		It("has no changes", func() {
+			Expect(result.Changes).To(BeEmpty())
+			Expect(result.SecurityRelevant()).To(BeFalse())
+		})
+	})
+
+	Context("when hosts were added and removed", func() {
+		BeforeEach(func() {
+			after = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{Job: "router/1"},
+				},
+			}
+		})
+
+		It("shows the added and removed hosts", func() {
+			Expect(result.Changes).To(Equal([]diff.Change{
+				{Host: "router/0", Kind: diff.HostRemoved},
+				{Host: "router/1", Kind: diff.HostAdded, Security: true},
+			}))
+			Expect(result.SecurityRelevant()).To(BeTrue())
+		})
+	})
+
+	Context("when listening ports changed", func() {
+		BeforeEach(func() {
+			after = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						Job: "router/0",
+						Services: []scantron.Process{
+							process("gorouter", "vcap",
+								withTLS(listening(443), expiration, scantron.CipherInformation{
+									"VersionTLS12": {"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+								}),
+								listening(8080),
+							),
+						},
+						Files:   before.JobResults[0].Files,
+						SSHKeys: before.JobResults[0].SSHKeys,
+					},
+				},
+			}
+		})
+
+		It("shows the new and vanished ports", func() {
+			Expect(result.Changes).To(ConsistOf(
+				diff.Change{Host: "router/0", Kind: diff.PortOpened, Subject: "tcp 0.0.0.0:8080 (gorouter)", Security: true},
+				diff.Change{Host: "router/0", Kind: diff.PortClosed, Subject: "tcp 0.0.0.0:80 (gorouter)"},
+			))
+		})
+	})
+
+	Context("when a process runs as a different user", func() {
+		BeforeEach(func() {
+			after = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						Job: "router/0",
+						Services: []scantron.Process{
+							process("gorouter", "root",
+								listening(80),
+								withTLS(listening(443), expiration, scantron.CipherInformation{
+									"VersionTLS12": {"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+								}),
+							),
+						},
+						Files:   before.JobResults[0].Files,
+						SSHKeys: before.JobResults[0].SSHKeys,
+					},
+				},
+			}
+		})
+
+		It("shows the change of user", func() {
+			Expect(result.Changes).To(Equal([]diff.Change{
+				{Host: "router/0", Kind: diff.ProcessUserChanged, Subject: "gorouter", Before: "vcap", After: "root", Security: true},
+			}))
+		})
+	})
+
+	Context("when the TLS configuration of a port changed", func() {
+		BeforeEach(func() {
+			after = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						Job: "router/0",
+						Services: []scantron.Process{
+							process("gorouter", "vcap",
+								listening(80),
+								withTLS(listening(443), expiration.AddDate(1, 0, 0), scantron.CipherInformation{
+									"VersionTLS11": {"TLS_RSA_WITH_RC4_128_SHA"},
+								}),
+							),
+						},
+						Files:   before.JobResults[0].Files,
+						SSHKeys: before.JobResults[0].SSHKeys,
+					},
+				},
+			}
+		})
+
+		It("shows the protocol, cipher, and certificate changes", func() {
+			subject := "tcp 0.0.0.0:443 (gorouter)"
+
+			Expect(result.Changes).To(Equal([]diff.Change{
+				{Host: "router/0", Kind: diff.TLSProtocolsAdded, Subject: subject, After: "VersionTLS11", Security: true},
+				{Host: "router/0", Kind: diff.TLSProtocolsRemoved, Subject: subject, Before: "VersionTLS12"},
+				{Host: "router/0", Kind: diff.TLSCiphersAdded, Subject: subject, After: "VersionTLS11 TLS_RSA_WITH_RC4_128_SHA", Security: true},
+				{Host: "router/0", Kind: diff.TLSCiphersRemoved, Subject: subject, Before: "VersionTLS12 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+				{Host: "router/0", Kind: diff.CertificateChanged, Subject: subject, Before: "2030-01-01", After: "2031-01-01"},
+			}))
+		})
+	})
+
+	Context("when a cipher which was offered for another protocol is offered for an older one", func() {
+		BeforeEach(func() {
+			after = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						Job: "router/0",
+						Services: []scantron.Process{
+							process("gorouter", "vcap",
+								listening(80),
+								withTLS(listening(443), expiration, scantron.CipherInformation{
+									"VersionTLS12": {"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+									"VersionTLS10": {"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+								}),
+							),
+						},
+						Files:   before.JobResults[0].Files,
+						SSHKeys: before.JobResults[0].SSHKeys,
+					},
+				},
+			}
+		})
+
+		It("shows the cipher as added for that protocol", func() {
+			subject := "tcp 0.0.0.0:443 (gorouter)"
+
+			Expect(result.Changes).To(Equal([]diff.Change{
+				{Host: "router/0", Kind: diff.TLSProtocolsAdded, Subject: subject, After: "VersionTLS10", Security: true},
+				{Host: "router/0", Kind: diff.TLSCiphersAdded, Subject: subject, After: "VersionTLS10 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", Security: true},
+			}))
+		})
+	})
+
+	Context("when hosts share a name", func() {
+		BeforeEach(func() {
+			before = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{Job: "web", IP: "10.0.0.1", Services: []scantron.Process{process("nginx", "vcap", listening(80))}},
+					{Job: "web", IP: "10.0.0.2", Services: []scantron.Process{process("nginx", "vcap", listening(8080))}},
+				},
+			}
+
+			after = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{Job: "web", IP: "10.0.0.1", Services: []scantron.Process{process("nginx", "vcap", listening(80), listening(8080))}},
+					{Job: "web", IP: "10.0.0.3", Services: []scantron.Process{process("nginx", "vcap", listening(8080))}},
+				},
+			}
+		})
+
+		It("tells them apart by their IP", func() {
+			Expect(result.Changes).To(Equal([]diff.Change{
+				{Host: "web", IP: "10.0.0.1", Kind: diff.PortOpened, Subject: "tcp 0.0.0.0:8080 (nginx)", Security: true},
+				{Host: "web", IP: "10.0.0.2", Kind: diff.HostRemoved},
+				{Host: "web", IP: "10.0.0.3", Kind: diff.HostAdded, Security: true},
+			}))
+		})
+	})
+
+	Context("when files became world-readable and ssh keys were rotated", func() {
+		BeforeEach(func() {
+			after = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						Job:      "router/0",
+						Services: before.JobResults[0].Services,
+						Files: []scantron.File{
+							{Path: "/var/vcap/jobs/gorouter/config.yml", Permissions: 0644},
+						},
+						SSHKeys: []scantron.SSHKey{
+							{Type: "ssh-rsa", Key: "key-2"},
+						},
+					},
+				},
+			}
+		})
+
+		It("shows the new world-readable files and rotated keys", func() {
+			Expect(result.Changes).To(Equal([]diff.Change{
+				{Host: "router/0", Kind: diff.WorldReadableFileNew, Subject: "/var/vcap/jobs/gorouter/config.yml", Security: true},
+				{Host: "router/0", Kind: diff.SSHKeyRotated, Subject: "ssh-rsa", Security: true},
+			}))
+		})
+	})
+
+	Describe("Report", func() {
+		BeforeEach(func() {
+			after = scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{Job: "router/1"},
+				},
+			}
+		})
+
+		It("has a row for each change", func() {
+			r := result.Report()
+
+			Expect(r.Header).To(Equal([]string{"Identity", "IP", "Change", "Subject", "Before", "After", "Security-Relevant"}))
+			Expect(r.Rows).To(Equal([][]string{
+				{"router/0", "", "host removed", "", "", "", ""},
+				{"router/1", "", "host added", "", "", "", "yes"},
+			}))
+		})
+	})
+})
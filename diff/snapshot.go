@@ -0,0 +1,361 @@
+package diff
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type snapshot map[hostKey]*hostSnapshot
+
+// hostKey identifies a host by both name and IP as inventory scans give every
+// address in a group the same name.
+type hostKey struct {
+	name string
+	ip   string
+}
+
+type hostSnapshot struct {
+	ports         map[string]portSnapshot
+	processUsers  map[string]stringSet
+	worldReadable stringSet
+	sshKeys       map[string]stringSet
+}
+
+type portSnapshot struct {
+	process   string
+	protocols stringSet
+	// ciphers holds each cipher together with the protocol it was offered
+	// for, see cipherKey.
+	ciphers    stringSet
+	expiration *time.Time
+}
+
+type stringSet map[string]struct{}
+
+func (s stringSet) add(value string) {
+	s[value] = struct{}{}
+}
+
+func (s stringSet) contains(value string) bool {
+	_, ok := s[value]
+	return ok
+}
+
+// minus returns the sorted values which are in s but not in other.
+func (s stringSet) minus(other stringSet) []string {
+	values := []string{}
+	for value := range s {
+		if !other.contains(value) {
+			values = append(values, value)
+		}
+	}
+
+	return sorted(values)
+}
+
+func (s stringSet) equal(other stringSet) bool {
+	return len(s.minus(other)) == 0 && len(other.minus(s)) == 0
+}
+
+func (s stringSet) String() string {
+	return strings.Join(s.minus(nil), ", ")
+}
+
+func portKey(protocol, address string, number int) string {
+	return fmt.Sprintf("%s %s:%d", protocol, address, number)
+}
+
+func cipherKey(suite, cipher string) string {
+	return fmt.Sprintf("%s %s", suite, cipher)
+}
+
+func loadSnapshot(db *sql.DB, scanID int) (snapshot, error) {
+	snap := snapshot{}
+
+	loaders := []func(*sql.DB, int, snapshot) error{
+		loadHosts,
+		loadPorts,
+		loadCiphers,
+		loadCertificates,
+		loadProcessUsers,
+		loadWorldReadableFiles,
+		loadSSHKeys,
+	}
+
+	for _, load := range loaders {
+		err := load(db, scanID, snap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return snap, nil
+}
+
+func loadHosts(db *sql.DB, scanID int, snap snapshot) error {
+	rows, err := db.Query(`SELECT hosts.name, hosts.ip FROM hosts WHERE hosts.scan_id = ?`, scanID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var host hostKey
+
+		err := rows.Scan(&host.name, &host.ip)
+		if err != nil {
+			return err
+		}
+
+		snap[host] = &hostSnapshot{
+			ports:         map[string]portSnapshot{},
+			processUsers:  map[string]stringSet{},
+			worldReadable: stringSet{},
+			sshKeys:       map[string]stringSet{},
+		}
+	}
+
+	return rows.Err()
+}
+
+func loadPorts(db *sql.DB, scanID int, snap snapshot) error {
+	rows, err := db.Query(`
+		SELECT DISTINCT h.name, h.ip, po.protocol, po.address, po.number, pr.name
+		FROM hosts h
+			JOIN processes pr
+				ON h.id = pr.host_id
+			JOIN ports po
+				ON po.process_id = pr.id
+		WHERE h.scan_id = ?
+			AND (upper(po.state) = "LISTEN" OR po.state = "Bound")
+	`, scanID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			host                       hostKey
+			protocol, address, process string
+			number                     int
+		)
+
+		err := rows.Scan(&host.name, &host.ip, &protocol, &address, &number, &process)
+		if err != nil {
+			return err
+		}
+
+		snap[host].ports[portKey(protocol, address, number)] = portSnapshot{
+			process:   process,
+			protocols: stringSet{},
+			ciphers:   stringSet{},
+		}
+	}
+
+	return rows.Err()
+}
+
+func loadCiphers(db *sql.DB, scanID int, snap snapshot) error {
+	rows, err := db.Query(`
+		SELECT DISTINCT h.name, h.ip, po.protocol, po.address, po.number, s.suite, c.cipher
+		FROM hosts h
+			JOIN processes pr
+				ON h.id = pr.host_id
+			JOIN ports po
+				ON po.process_id = pr.id
+			JOIN tls_certificates t
+				ON t.port_id = po.id
+			JOIN certificate_to_ciphersuite ctc
+				ON t.id = ctc.certificate_id
+			JOIN tls_suites s
+				ON ctc.suite_id = s.id
+			JOIN tls_ciphers c
+				ON ctc.cipher_id = c.id
+		WHERE h.scan_id = ?
+	`, scanID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			host                             hostKey
+			protocol, address, suite, cipher string
+			number                           int
+		)
+
+		err := rows.Scan(&host.name, &host.ip, &protocol, &address, &number, &suite, &cipher)
+		if err != nil {
+			return err
+		}
+
+		port, ok := snap[host].ports[portKey(protocol, address, number)]
+		if !ok {
+			continue
+		}
+
+		port.protocols.add(suite)
+		port.ciphers.add(cipherKey(suite, cipher))
+	}
+
+	return rows.Err()
+}
+
+func loadCertificates(db *sql.DB, scanID int, snap snapshot) error {
+	rows, err := db.Query(`
+		SELECT h.name, h.ip, po.protocol, po.address, po.number, t.cert_expiration
+		FROM hosts h
+			JOIN processes pr
+				ON h.id = pr.host_id
+			JOIN ports po
+				ON po.process_id = pr.id
+			JOIN tls_certificates t
+				ON t.port_id = po.id
+		WHERE h.scan_id = ?
+		AND t.cert_expiration IS NOT NULL
+	`, scanID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			host              hostKey
+			protocol, address string
+			number            int
+			expiration        time.Time
+		)
+
+		err := rows.Scan(&host.name, &host.ip, &protocol, &address, &number, &expiration)
+		if err != nil {
+			return err
+		}
+
+		key := portKey(protocol, address, number)
+
+		port, ok := snap[host].ports[key]
+		if !ok {
+			continue
+		}
+
+		port.expiration = &expiration
+		snap[host].ports[key] = port
+	}
+
+	return rows.Err()
+}
+
+func loadProcessUsers(db *sql.DB, scanID int, snap snapshot) error {
+	rows, err := db.Query(`
+		SELECT DISTINCT h.name, h.ip, pr.name, pr.user
+		FROM hosts h
+			JOIN processes pr
+				ON h.id = pr.host_id
+		WHERE h.scan_id = ?
+	`, scanID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			host          hostKey
+			process, user string
+		)
+
+		err := rows.Scan(&host.name, &host.ip, &process, &user)
+		if err != nil {
+			return err
+		}
+
+		users, ok := snap[host].processUsers[process]
+		if !ok {
+			users = stringSet{}
+			snap[host].processUsers[process] = users
+		}
+
+		users.add(user)
+	}
+
+	return rows.Err()
+}
+
+func loadWorldReadableFiles(db *sql.DB, scanID int, snap snapshot) error {
+	rows, err := db.Query(`
+		SELECT DISTINCT h.name, h.ip, f.path
+		FROM hosts h
+			JOIN files f
+				ON h.id = f.host_id
+		WHERE h.scan_id = ?
+			AND f.permissions & 04 != 0
+	`, scanID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			host hostKey
+			path string
+		)
+
+		err := rows.Scan(&host.name, &host.ip, &path)
+		if err != nil {
+			return err
+		}
+
+		snap[host].worldReadable.add(path)
+	}
+
+	return rows.Err()
+}
+
+func loadSSHKeys(db *sql.DB, scanID int, snap snapshot) error {
+	rows, err := db.Query(`
+		SELECT DISTINCT h.name, h.ip, s.type, s.key
+		FROM hosts h
+			JOIN ssh_keys s
+				ON h.id = s.host_id
+		WHERE h.scan_id = ?
+	`, scanID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			host         hostKey
+			keyType, key string
+		)
+
+		err := rows.Scan(&host.name, &host.ip, &keyType, &key)
+		if err != nil {
+			return err
+		}
+
+		keys, ok := snap[host].sshKeys[keyType]
+		if !ok {
+			keys = stringSet{}
+			snap[host].sshKeys[keyType] = keys
+		}
+
+		keys.add(key)
+	}
+
+	return rows.Err()
+}
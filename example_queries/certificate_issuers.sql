@@ -0,0 +1,9 @@
+SELECT ch.issuer, h.name AS host, pr.name AS process, po.number AS port
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+  JOIN ports po ON po.process_id = pr.id
+  JOIN tls_certificates t ON t.port_id = po.id
+  JOIN tls_certificate_chain ch ON ch.certificate_id = t.id
+WHERE ch.position = 0 -- the server's own certificate
+  -- AND ch.issuer != "CN=my-expected-ca" -- uncomment to only show certificates signed by another CA
+ORDER BY ch.issuer, h.name, po.number
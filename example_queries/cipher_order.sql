@@ -0,0 +1,9 @@
+SELECT h.name AS host, pr.name AS process, po.number AS port, t.server_cipher_preference, s.suite, ctc.position, c.cipher
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+  JOIN ports po ON po.process_id = pr.id
+  JOIN tls_certificates t ON t.port_id = po.id
+  JOIN certificate_to_ciphersuite ctc ON ctc.certificate_id = t.id
+  JOIN tls_suites s ON s.id = ctc.suite_id
+  JOIN tls_ciphers c ON c.id = ctc.cipher_id
+ORDER BY h.name, po.number, s.suite, ctc.position
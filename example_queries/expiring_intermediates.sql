@@ -0,0 +1,10 @@
+SELECT h.name AS host, pr.name AS process, po.number AS port, ch.subject, ch.not_after
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+  JOIN ports po ON po.process_id = pr.id
+  JOIN tls_certificates t ON t.port_id = po.id
+  JOIN tls_certificate_chain ch ON ch.certificate_id = t.id
+WHERE ch.position > 0 -- skip the server's own certificate
+  AND NOT ch.self_signed -- skip root certificates
+  AND ch.not_after < datetime('now', '+30 days') -- expiring within the next 30 days
+ORDER BY ch.not_after, h.name, po.number
@@ -0,0 +1,7 @@
+SELECT h.name AS host, pr.name AS process, po.number AS port, e.status_code, e.server
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+  JOIN ports po ON po.process_id = pr.id
+  JOIN http_endpoints e ON e.port_id = po.id
+WHERE e.tls AND e.hsts = ''
+ORDER BY h.name, po.number
@@ -0,0 +1,6 @@
+SELECT h.name AS host, p.name AS process, p.user, s.family, s.protocol
+FROM hosts h
+  JOIN processes p ON p.host_id = h.id
+  JOIN sockets s ON s.process_id = p.id
+WHERE s.family IN ('raw', 'raw6', 'packet')
+ORDER BY h.name, p.name
@@ -0,0 +1,6 @@
+SELECT h.name AS host, s.banner, a.name AS cipher
+FROM hosts h
+  JOIN ssh_servers s ON s.host_id = h.id
+  JOIN ssh_algorithms a ON a.ssh_server_id = s.id
+WHERE a.kind = 'cipher'
+ORDER BY h.name, a.position
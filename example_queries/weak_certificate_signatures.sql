@@ -0,0 +1,9 @@
+SELECT h.name AS host, pr.name AS process, po.number AS port, ch.position, ch.subject, ch.signature_algorithm
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+  JOIN ports po ON po.process_id = pr.id
+  JOIN tls_certificates t ON t.port_id = po.id
+  JOIN tls_certificate_chain ch ON ch.certificate_id = t.id
+WHERE (ch.signature_algorithm LIKE "SHA1-%" OR ch.signature_algorithm LIKE "MD%") -- SHA-1 and MD5 signatures can be forged
+  AND NOT ch.self_signed -- the signature on a root certificate is never checked
+ORDER BY h.name, po.number, ch.position
@@ -0,0 +1,7 @@
+SELECT h.name AS host, pr.name AS process, po.number AS port, t.dh_bits
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+  JOIN ports po ON po.process_id = pr.id
+  JOIN tls_certificates t ON t.port_id = po.id
+WHERE t.dh_bits > 0 AND t.dh_bits < 2048 -- 0 means no DHE cipher suites
+ORDER BY h.name, po.number
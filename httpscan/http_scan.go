@@ -0,0 +1,79 @@
+package httpscan
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+// Ports which do not speak HTTP often wait for the client to say something
+// else, so the request should not wait long for them.
+const requestTimeout = 3 * time.Second
+
+type HttpScannerImpl struct{}
+
+// Scan sends a HEAD request for / to the port. It returns an error if the
+// port does not speak HTTP.
+func (s *HttpScannerImpl) Scan(logger scanlog.Logger, host, port string, useTLS bool) (*scantron.HTTPInformation, error) {
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+
+	target := url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, port),
+		Path:   "/",
+	}
+
+	client := &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			// We never send secret information over this connection. We're
+			// just probing it.
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	logger.Debugf("Sending HEAD request to %s", target.String())
+
+	response, err := client.Head(target.String())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	information := &scantron.HTTPInformation{
+		TLS:        useTLS,
+		StatusCode: response.StatusCode,
+		HSTS:       response.Header.Get("Strict-Transport-Security"),
+		Server:     response.Header.Get("Server"),
+		PoweredBy:  response.Header.Get("X-Powered-By"),
+	}
+
+	if !useTLS && isRedirect(response.StatusCode) {
+		location, err := response.Location()
+		information.RedirectsToHTTPS = err == nil && location.Scheme == "https"
+	}
+
+	return information, nil
+}
+
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,155 @@
+package httpscan_test
+
+import (
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/httpscan"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+var _ = Describe("HTTP Scan", func() {
+	var (
+		logger  scanlog.Logger
+		subject *httpscan.HttpScannerImpl
+	)
+
+	BeforeEach(func() {
+		// net/http uses the stdlib log package
+		log.SetOutput(GinkgoWriter)
+
+		logger = scanlog.NewNopLogger()
+		subject = &httpscan.HttpScannerImpl{}
+	})
+
+	Context("scanning an HTTPS server", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("HEAD"))
+
+				w.Header().Set("Strict-Transport-Security", "max-age=31536000")
+				w.Header().Set("Server", "nginx/1.18.0")
+				w.Header().Set("X-Powered-By", "Express")
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("records the security headers", func() {
+			host, port := hostport(server.URL)
+
+			information, err := subject.Scan(logger, host, port, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(information).To(Equal(&scantron.HTTPInformation{
+				TLS:        true,
+				StatusCode: http.StatusOK,
+				HSTS:       "max-age=31536000",
+				Server:     "nginx/1.18.0",
+				PoweredBy:  "Express",
+			}))
+		})
+	})
+
+	Context("scanning a plain HTTP server", func() {
+		var (
+			server   *httptest.Server
+			location string
+		)
+
+		BeforeEach(func() {
+			location = "https://example.com/"
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, location, http.StatusMovedPermanently)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("records that it redirects to HTTPS", func() {
+			host, port := hostport(server.URL)
+
+			information, err := subject.Scan(logger, host, port, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(information.TLS).To(BeFalse())
+			Expect(information.StatusCode).To(Equal(http.StatusMovedPermanently))
+			Expect(information.RedirectsToHTTPS).To(BeTrue())
+		})
+
+		Context("when the redirect is to another plain HTTP page", func() {
+			BeforeEach(func() {
+				location = "/login"
+			})
+
+			It("does not count it", func() {
+				host, port := hostport(server.URL)
+
+				information, err := subject.Scan(logger, host, port, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(information.RedirectsToHTTPS).To(BeFalse())
+			})
+		})
+	})
+
+	Context("scanning a port which does not speak HTTP", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+
+					io.WriteString(conn, "SSH-2.0-OpenSSH_8.9\r\n")
+					conn.Close()
+				}
+			}()
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("returns an error", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			information, err := subject.Scan(logger, host, port, false)
+			Expect(err).To(HaveOccurred())
+			Expect(information).To(BeNil())
+		})
+	})
+})
+
+func hostport(uri string) (string, string) {
+	pu, err := url.Parse(uri)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	host, port, err := net.SplitHostPort(pu.Host)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	return host, port
+}
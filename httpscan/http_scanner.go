@@ -0,0 +1,10 @@
+package httpscan
+
+import (
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+type HttpScanner interface {
+	Scan(logger scanlog.Logger, host, port string, useTLS bool) (*scantron.HTTPInformation, error)
+}
@@ -0,0 +1,13 @@
+package httpscan_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestHttpscan(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HTTP Scan Suite")
+}
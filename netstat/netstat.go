@@ -5,7 +5,6 @@ package netstat
 import (
 	"bufio"
 	"log"
-	"net"
 	"strconv"
 	"strings"
 
@@ -91,15 +90,16 @@ func parseNetstatLine(line string) (NetstatInfo, bool) {
 }
 
 func splitAddress(infoAddress string) (string, int) {
-	// XXX(cb): In the netstat output IPv6 addresses are shown as :::22 whereas
-	// Go parsing requires [::]:22. We try and fix this up here but this is
-	// undoubtedly imperfect.
-	conformAddr := strings.Replace(infoAddress, "::", "[::]", 1)
-	address, port, err := net.SplitHostPort(conformAddr)
-	if err != nil {
-		log.Printf("failed to split address %q: %s", conformAddr, err)
+	// In the netstat output IPv6 addresses are not bracketed (:::22 or
+	// ::1:8443) so the port is everything after the last colon.
+	i := strings.LastIndex(infoAddress, ":")
+	if i < 0 {
+		log.Printf("failed to split address %q: missing port", infoAddress)
+		return "", -1
 	}
-	number, err := strconv.Atoi(port)
+
+	address := strings.Trim(infoAddress[:i], "[]")
+	number, err := strconv.Atoi(infoAddress[i+1:])
 	if err != nil {
 		number = -1
 	}
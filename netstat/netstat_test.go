@@ -90,6 +90,24 @@ udp        0      0 127.0.0.2:8080          127.0.0.3:8443          ESTABLISHED
 		}))
 	})
 
+	It("parses IPv6 addresses which are not wildcards", func() {
+		input := "tcp6       0      0 ::1:8443                fe80::1:52044           ESTABLISHED 5149/rolodexd"
+
+		Expect(netstat.ParseNetstatOutputForPort(input)).To(Equal([]netstat.NetstatPort{
+			{
+				PID: 5149,
+				Port: scantron.Port{
+					Protocol:       "tcp6",
+					Address:        "::1",
+					Number:         8443,
+					ForeignAddress: "fe80::1",
+					ForeignNumber:  52044,
+					State:          "ESTABLISHED",
+				},
+			},
+		}))
+	})
+
 	Context("when the socket state is missing because it is a raw socket", func() {
 		It("still parses that", func() {
 			input := "udp        0      0 127.0.0.1:53            0.0.0.0:*                           4113/consul"
@@ -57,3 +57,27 @@ func (m *MockSystemResources) GetPorts() ProcessPorts {
 func (mr *MockSystemResourcesMockRecorder) GetPorts() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPorts", reflect.TypeOf((*MockSystemResources)(nil).GetPorts))
 }
+
+// GetUnixSockets mocks base method
+func (m *MockSystemResources) GetUnixSockets() ProcessUnixSockets {
+	ret := m.ctrl.Call(m, "GetUnixSockets")
+	ret0, _ := ret[0].(ProcessUnixSockets)
+	return ret0
+}
+
+// GetUnixSockets indicates an expected call of GetUnixSockets
+func (mr *MockSystemResourcesMockRecorder) GetUnixSockets() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnixSockets", reflect.TypeOf((*MockSystemResources)(nil).GetUnixSockets))
+}
+
+// GetRawSockets mocks base method
+func (m *MockSystemResources) GetRawSockets() ProcessRawSockets {
+	ret := m.ctrl.Call(m, "GetRawSockets")
+	ret0, _ := ret[0].(ProcessRawSockets)
+	return ret0
+}
+
+// GetRawSockets indicates an expected call of GetRawSockets
+func (mr *MockSystemResourcesMockRecorder) GetRawSockets() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRawSockets", reflect.TypeOf((*MockSystemResources)(nil).GetRawSockets))
+}
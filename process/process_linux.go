@@ -9,9 +9,12 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/netstat"
+	"github.com/pivotal-cf/scantron/procfs"
+	"github.com/pivotal-cf/scantron/procnet"
 )
 
 type SystemResourceImpl struct {
@@ -23,25 +26,77 @@ func (s *SystemResourceImpl) GetProcesses() ([]scantron.Process, error) {
 	if err != nil {
 		return nil, err
 	}
+	bootTime, err := procfs.BootTime("/proc")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error getting boot time:", err)
+	}
+
 	processes := []scantron.Process{}
 	for _, rawProcess := range rawProcesses {
 		pid := rawProcess.Pid()
 		process := scantron.Process{
 			CommandName: rawProcess.Executable(),
 			PID:         pid,
+			PPID:        rawProcess.PPid(),
 			User:        getUser(pid),
 			Cmdline:     getCmdline(pid),
 			Env:         getEnv(pid),
 		}
+
+		if !bootTime.IsZero() {
+			process.StartTime = getStartTime(pid, bootTime)
+		}
+
+		executable, err := procfs.ReadExecutable("/proc", pid)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Fprintln(os.Stderr, "error reading executable:", err)
+		}
+		process.Executable = executable.Path
+		process.ExecutableSHA256 = executable.SHA256
+		process.ExecutableDeleted = executable.Deleted
+
+		status, err := procfs.ReadStatus("/proc", pid)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Fprintln(os.Stderr, "error reading status:", err)
+		}
+		process.EffectiveCapabilities = status.EffectiveCapabilities
+		process.PermittedCapabilities = status.PermittedCapabilities
+		process.NoNewPrivs = status.NoNewPrivs
+		process.Seccomp = status.Seccomp
+
+		namespaces, err := procfs.ReadNamespaces("/proc", pid)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Fprintln(os.Stderr, "error reading namespaces:", err)
+		}
+		process.Namespaces = namespaces
+
 		processes = append(processes, process)
 	}
 
 	return processes, nil
 }
 
+// GetPorts reads the sockets from /proc/net. netstat is only used if that
+// fails, since it is missing from many images.
 func (s *SystemResourceImpl) GetPorts() ProcessPorts {
+	procNetPorts, err := procnet.ReadPorts("/proc")
+	if err == nil {
+		processPorts := []ProcessPort{}
+		for _, pp := range procNetPorts {
+			processPorts = append(processPorts, ProcessPort{
+				PID:  pp.PID,
+				Port: pp.Port,
+			})
+		}
+
+		return processPorts
+	}
+
+	fmt.Fprintln(os.Stderr, "error reading /proc/net, falling back to netstat:", err)
+
 	bs, err := exec.Command("netstat", "-at", "-4", "-6", "--numeric-ports", "-u", "-p").Output()
 	if err != nil {
+		fmt.Fprintln(os.Stderr, "error running netstat:", err)
 		return nil
 	}
 
@@ -57,6 +112,42 @@ func (s *SystemResourceImpl) GetPorts() ProcessPorts {
 	return processPorts
 }
 
+func (s *SystemResourceImpl) GetUnixSockets() ProcessUnixSockets {
+	procNetSockets, err := procnet.ReadUnixSockets("/proc")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading /proc/net/unix:", err)
+		return nil
+	}
+
+	sockets := ProcessUnixSockets{}
+	for _, ps := range procNetSockets {
+		sockets = append(sockets, ProcessUnixSocket{
+			PID:    ps.PID,
+			Socket: ps.Socket,
+		})
+	}
+
+	return sockets
+}
+
+func (s *SystemResourceImpl) GetRawSockets() ProcessRawSockets {
+	procNetSockets, err := procnet.ReadRawSockets("/proc")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading raw sockets from /proc/net:", err)
+		return nil
+	}
+
+	sockets := ProcessRawSockets{}
+	for _, ps := range procNetSockets {
+		sockets = append(sockets, ProcessRawSocket{
+			PID:    ps.PID,
+			Socket: ps.Socket,
+		})
+	}
+
+	return sockets
+}
+
 func getUser(pid int) string {
 	bs, err := exec.Command("ps", "-e", "-o", "uname:20=", "-f", strconv.Itoa(pid)).CombinedOutput()
 	if err != nil {
@@ -67,6 +158,16 @@ func getUser(pid int) string {
 	return strings.TrimSpace(string(bs))
 }
 
+func getStartTime(pid int, bootTime time.Time) time.Time {
+	startTime, err := procfs.StartTime("/proc", pid, bootTime)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error getting start time:", err)
+		return time.Time{}
+	}
+
+	return startTime
+}
+
 func getCmdline(pid int) []string {
 	cmdline, err := readFile(fmt.Sprintf("/proc/%d/cmdline", pid))
 	if err != nil {
@@ -2,10 +2,13 @@ package process
 
 import (
 	"io/ioutil"
+	"net"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/httpscan"
 	"github.com/pivotal-cf/scantron/scanlog"
 	"github.com/pivotal-cf/scantron/tlsscan"
 )
@@ -17,9 +20,28 @@ type ProcessPort struct {
 
 type ProcessPorts []ProcessPort
 
+type ProcessUnixSocket struct {
+	PID    int
+	Socket scantron.UnixSocket
+}
+
+type ProcessUnixSockets []ProcessUnixSocket
+
+type ProcessRawSocket struct {
+	PID    int
+	Socket scantron.RawSocket
+}
+
+type ProcessRawSockets []ProcessRawSocket
+
 type ProcessScanner struct {
-	SysRes  SystemResources
-	TlsScan tlsscan.TlsScanner
+	SysRes   SystemResources
+	TlsScan  tlsscan.TlsScanner
+	HttpScan httpscan.HttpScanner
+
+	// HttpConcurrency is how many ports are sent HTTP requests at once. It
+	// is 1 when not set.
+	HttpConcurrency int
 }
 
 func (ps *ProcessScanner) ScanProcesses(logger scanlog.Logger) ([]scantron.Process, error) {
@@ -29,6 +51,11 @@ func (ps *ProcessScanner) ScanProcesses(logger scanlog.Logger) ([]scantron.Proce
 	}
 
 	ports := ps.SysRes.GetPorts()
+	unixSockets := ps.SysRes.GetUnixSockets()
+	rawSockets := ps.SysRes.GetRawSockets()
+
+	listening := []*scantron.Port{}
+
 	for i := range processes {
 		portsForPid := ports.LocalPortsForPID(processes[i].PID)
 
@@ -42,15 +69,49 @@ func (ps *ProcessScanner) ScanProcesses(logger scanlog.Logger) ([]scantron.Proce
 				continue
 			}
 
-			portsForPid[j].TLSInformation = ps.getTLSInformation(logger, portsForPid[j])
+			portsForPid[j].ProbedAddress = probeAddress(portsForPid[j].Address)
+			portsForPid[j].TLSInformation = ps.getTLSInformation(logger, processes[i].CommandName, portsForPid[j])
+			listening = append(listening, &portsForPid[j])
 		}
 
 		processes[i].Ports = portsForPid
+		processes[i].UnixSockets = unixSockets.SocketsForPID(processes[i].PID)
+		processes[i].RawSockets = rawSockets.SocketsForPID(processes[i].PID)
 	}
 
+	ps.probeHTTP(logger, listening)
+
 	return processes, nil
 }
 
+// probeHTTP sends requests to several ports at once since a port which does
+// not speak HTTP usually keeps its request waiting until it times out.
+func (ps *ProcessScanner) probeHTTP(logger scanlog.Logger, ports []*scantron.Port) {
+	concurrency := ps.HttpConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	slots := make(chan struct{}, concurrency)
+	wg := &sync.WaitGroup{}
+
+	for _, port := range ports {
+		port := port
+
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			port.HTTPInformation = ps.getHTTPInformation(logger, *port)
+		}()
+	}
+
+	wg.Wait()
+}
+
 func (ps ProcessPorts) LocalPortsForPID(pid int) []scantron.Port {
 	result := []scantron.Port{}
 
@@ -63,6 +124,48 @@ func (ps ProcessPorts) LocalPortsForPID(pid int) []scantron.Port {
 	return result
 }
 
+func (ps ProcessUnixSockets) SocketsForPID(pid int) []scantron.UnixSocket {
+	result := []scantron.UnixSocket{}
+
+	for _, socket := range ps {
+		if socket.PID == pid {
+			result = append(result, socket.Socket)
+		}
+	}
+
+	return result
+}
+
+func (ps ProcessRawSockets) SocketsForPID(pid int) []scantron.RawSocket {
+	result := []scantron.RawSocket{}
+
+	for _, socket := range ps {
+		if socket.PID == pid {
+			result = append(result, socket.Socket)
+		}
+	}
+
+	return result
+}
+
+// probeAddress returns the address to connect to a port bound to address.
+// Wildcard binds are probed over loopback: ::1 for IPv6 since the socket may
+// not accept IPv4 connections.
+func probeAddress(address string) string {
+	ip := net.ParseIP(strings.SplitN(address, "%", 2)[0])
+
+	switch {
+	case ip == nil:
+		return "localhost"
+	case ip.IsUnspecified() && ip.To4() == nil:
+		return "::1"
+	case ip.IsUnspecified():
+		return "localhost"
+	default:
+		return address
+	}
+}
+
 func readFile(path string) ([]string, error) {
 	bs, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -81,28 +184,39 @@ func readFile(path string) ([]string, error) {
 	return output, nil
 }
 
-func (ps *ProcessScanner) getTLSInformation(logger scanlog.Logger, port scantron.Port) *scantron.TLSInformation {
+func (ps *ProcessScanner) getTLSInformation(logger scanlog.Logger, processName string, port scantron.Port) *scantron.TLSInformation {
 	portNum := strconv.Itoa(port.Number)
 
 	portLogger := logger.With("port", portNum)
 
 	tlsInformation := &scantron.TLSInformation{}
 
-	results, err := ps.TlsScan.Scan(portLogger, "localhost", portNum)
+	results, err := ps.TlsScan.Scan(portLogger, port.ProbedAddress, portNum, processName)
 	if err != nil {
-		tlsInformation.ScanError = err
+		tlsInformation.ScanError = err.Error()
 		return tlsInformation
 	}
 
-	if !results.HasTLS() {
+	tlsInformation.ProbeErrors = results.ProbeErrors
+
+	// A port which timed out on every protocol version may still speak TLS,
+	// so its probe errors are kept.
+	if !results.CipherInformation.HasTLS() {
+		if len(results.ProbeErrors) > 0 {
+			return tlsInformation
+		}
+
 		return nil
 	}
 
-	tlsInformation.CipherInformation = results
+	tlsInformation.CipherInformation = results.CipherInformation
+	tlsInformation.ServerCipherPreference = results.ServerCipherPreference
+	tlsInformation.KeyExchange = results.KeyExchange
+	tlsInformation.ALPNProtocols = results.ALPNProtocols
 
-	cert, mutual, err := ps.TlsScan.FetchTLSInformation("localhost", portNum)
+	cert, mutual, err := ps.TlsScan.FetchTLSInformation(port.ProbedAddress, portNum, processName)
 	if err != nil {
-		tlsInformation.ScanError = err
+		tlsInformation.ScanError = err.Error()
 		return tlsInformation
 	}
 
@@ -111,3 +225,19 @@ func (ps *ProcessScanner) getTLSInformation(logger scanlog.Logger, port scantron
 
 	return tlsInformation
 }
+
+func (ps *ProcessScanner) getHTTPInformation(logger scanlog.Logger, port scantron.Port) *scantron.HTTPInformation {
+	portNum := strconv.Itoa(port.Number)
+
+	portLogger := logger.With("port", portNum)
+
+	useTLS := port.TLSInformation != nil && port.TLSInformation.CipherInformation.HasTLS()
+
+	httpInformation, err := ps.HttpScan.Scan(portLogger, port.ProbedAddress, portNum, useTLS)
+	if err != nil {
+		portLogger.Debugf("Port does not speak HTTP: %s", err)
+		return nil
+	}
+
+	return httpInformation
+}
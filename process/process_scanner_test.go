@@ -1,15 +1,18 @@
 package process_test
 
 import (
+	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/httpscan"
 	"github.com/pivotal-cf/scantron/process"
 	"github.com/pivotal-cf/scantron/scanlog"
 	"github.com/pivotal-cf/scantron/tlsscan"
+	"sync"
 	"time"
 )
 
@@ -19,6 +22,7 @@ var _ = Describe("ProcessScanner", func() {
 		mockCtrl            *gomock.Controller
 		mockSystemResources *process.MockSystemResources
 		mockTlsScanner      *tlsscan.MockTlsScanner
+		mockHttpScanner     *httpscan.MockHttpScanner
 		subject             *process.ProcessScanner
 	)
 
@@ -26,9 +30,11 @@ var _ = Describe("ProcessScanner", func() {
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockSystemResources = process.NewMockSystemResources(mockCtrl)
 		mockTlsScanner = tlsscan.NewMockTlsScanner(mockCtrl)
+		mockHttpScanner = httpscan.NewMockHttpScanner(mockCtrl)
 		subject = &process.ProcessScanner{
-			SysRes:  mockSystemResources,
-			TlsScan: mockTlsScanner,
+			SysRes:   mockSystemResources,
+			TlsScan:  mockTlsScanner,
+			HttpScan: mockHttpScanner,
 		}
 	})
 
@@ -38,13 +44,24 @@ var _ = Describe("ProcessScanner", func() {
 
 	It("Should associate ports with processes", func() {
 
+		startTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
 		systemProcesses := []scantron.Process{
 			{
-				CommandName: "command",
-				PID:         123,
-				User:        "user",
-				Cmdline:     []string{"cmd", "arg"},
-				Env:         []string{"foo=bar"},
+				CommandName:           "command",
+				PID:                   123,
+				PPID:                  1,
+				User:                  "user",
+				Cmdline:               []string{"cmd", "arg"},
+				Env:                   []string{"foo=bar"},
+				StartTime:             startTime,
+				Executable:            "/usr/bin/command",
+				ExecutableSHA256:      "abc123",
+				ExecutableDeleted:     true,
+				EffectiveCapabilities: []string{"CAP_NET_ADMIN"},
+				PermittedCapabilities: []string{"CAP_NET_ADMIN", "CAP_SYS_ADMIN"},
+				NoNewPrivs:            true,
+				Seccomp:               "filter",
+				Namespaces:            scantron.Namespaces{Net: 1, PID: 2, Mount: 3},
 			},
 		}
 
@@ -69,26 +86,42 @@ var _ = Describe("ProcessScanner", func() {
 
 		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
 		mockSystemResources.EXPECT().GetPorts().Return(systemPorts).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(nil).Times(1)
+		mockSystemResources.EXPECT().GetRawSockets().Return(nil).Times(1)
 
 		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
 
 		Expect(err).Should(BeNil())
 		Expect(processes).Should(HaveLen(1))
 		Expect(processes[0]).Should(MatchAllFields(Fields{
-			"CommandName": Equal("command"),
-			"PID":         Equal(123),
-			"User":        Equal("user"),
-			"Cmdline":     Equal([]string{"cmd", "arg"}),
-			"Env":         Equal([]string{"foo=bar"}),
+			"CommandName":           Equal("command"),
+			"PID":                   Equal(123),
+			"User":                  Equal("user"),
+			"Cmdline":               Equal([]string{"cmd", "arg"}),
+			"Env":                   Equal([]string{"foo=bar"}),
+			"PPID":                  Equal(1),
+			"StartTime":             Equal(startTime),
+			"Executable":            Equal("/usr/bin/command"),
+			"ExecutableSHA256":      Equal("abc123"),
+			"ExecutableDeleted":     BeTrue(),
+			"EffectiveCapabilities": Equal([]string{"CAP_NET_ADMIN"}),
+			"PermittedCapabilities": Equal([]string{"CAP_NET_ADMIN", "CAP_SYS_ADMIN"}),
+			"NoNewPrivs":            BeTrue(),
+			"Seccomp":               Equal("filter"),
+			"Namespaces":            Equal(scantron.Namespaces{Net: 1, PID: 2, Mount: 3}),
+			"UnixSockets":           BeEmpty(),
+			"RawSockets":            BeEmpty(),
 			"Ports": MatchAllElements(portIdFn, Elements{
 				"4567": MatchAllFields(Fields{
-					"Protocol":       Equal("tcp"),
-					"Address":        Equal("1.2.3.4"),
-					"Number":         Equal(4567),
-					"ForeignAddress": Equal("2.3.4.5"),
-					"ForeignNumber":  Equal(6789),
-					"State":          Equal("Established"),
-					"TLSInformation": BeNil(),
+					"Protocol":        Equal("tcp"),
+					"Address":         Equal("1.2.3.4"),
+					"Number":          Equal(4567),
+					"ForeignAddress":  Equal("2.3.4.5"),
+					"ForeignNumber":   Equal(6789),
+					"State":           Equal("Established"),
+					"ProbedAddress":   BeEmpty(),
+					"TLSInformation":  BeNil(),
+					"HTTPInformation": BeNil(),
 				}),
 			}),
 		}))
@@ -127,11 +160,27 @@ var _ = Describe("ProcessScanner", func() {
 
 		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
 		mockSystemResources.EXPECT().GetPorts().Return(systemPorts).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(nil).Times(1)
+		mockSystemResources.EXPECT().GetRawSockets().Return(nil).Times(1)
 
 		cipherInformation := scantron.CipherInformation{
 			"VersionSSL30": []string{"cipher"},
 		}
-		mockTlsScanner.EXPECT().Scan(gomock.Any(), gomock.Eq("localhost"), gomock.Eq("4567")).Return(cipherInformation, nil).Times(1)
+		keyExchange := scantron.KeyExchange{
+			Groups: []string{"x25519"},
+			DHBits: 2048,
+		}
+		serverCipherPreference := true
+		scanResult := tlsscan.ScanResult{
+			CipherInformation:      cipherInformation,
+			ServerCipherPreference: &serverCipherPreference,
+			KeyExchange:            keyExchange,
+			ALPNProtocols:          []string{"h2", "http/1.1"},
+			ProbeErrors: []scantron.ProbeError{
+				{Protocol: "VersionTLS12", Error: "i/o timeout"},
+			},
+		}
+		mockTlsScanner.EXPECT().Scan(gomock.Any(), gomock.Eq("1.2.3.4"), gomock.Eq("4567"), gomock.Eq("command")).Return(scanResult, nil).Times(1)
 
 		certificate := &scantron.Certificate{
 			Expiration: time.Time{},
@@ -145,19 +194,38 @@ var _ = Describe("ProcessScanner", func() {
 				CommonName:   "",
 			},
 		}
-		mockTlsScanner.EXPECT().FetchTLSInformation("localhost", "4567").Return(
+		mockTlsScanner.EXPECT().FetchTLSInformation("1.2.3.4", "4567", "command").Return(
 			certificate, false, nil).Times(1)
 
+		httpInformation := &scantron.HTTPInformation{
+			TLS:        true,
+			StatusCode: 200,
+			HSTS:       "max-age=31536000",
+		}
+		mockHttpScanner.EXPECT().Scan(gomock.Any(), "1.2.3.4", "4567", true).Return(httpInformation, nil).Times(1)
+
 		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
 
 		Expect(err).Should(BeNil())
 		Expect(processes).Should(HaveLen(1))
 		Expect(processes[0]).Should(MatchAllFields(Fields{
-			"CommandName": Equal("command"),
-			"PID":         Equal(123),
-			"User":        Equal("user"),
-			"Cmdline":     Equal([]string{"cmd", "arg"}),
-			"Env":         Equal([]string{"foo=bar"}),
+			"CommandName":           Equal("command"),
+			"PID":                   Equal(123),
+			"User":                  Equal("user"),
+			"Cmdline":               Equal([]string{"cmd", "arg"}),
+			"Env":                   Equal([]string{"foo=bar"}),
+			"PPID":                  BeZero(),
+			"StartTime":             BeZero(),
+			"Executable":            BeEmpty(),
+			"ExecutableSHA256":      BeEmpty(),
+			"ExecutableDeleted":     BeFalse(),
+			"EffectiveCapabilities": BeEmpty(),
+			"PermittedCapabilities": BeEmpty(),
+			"NoNewPrivs":            BeFalse(),
+			"Seccomp":               BeEmpty(),
+			"Namespaces":            BeZero(),
+			"UnixSockets":           BeEmpty(),
+			"RawSockets":            BeEmpty(),
 			"Ports": MatchAllElements(portIdFn, Elements{
 				"4567": MatchAllFields(Fields{
 					"Protocol":       Equal("tcp"),
@@ -166,14 +234,283 @@ var _ = Describe("ProcessScanner", func() {
 					"ForeignAddress": Equal("0.0.0.0"),
 					"ForeignNumber":  Equal(-1),
 					"State":          Equal("Listen"),
+					"ProbedAddress":  Equal("1.2.3.4"),
 					"TLSInformation": PointTo(MatchAllFields(Fields{
-						"Certificate":       Equal(certificate),
-						"CipherInformation": Equal(cipherInformation),
-						"Mutual":            BeFalse(),
-						"ScanError":         BeNil(),
+						"Certificate":            Equal(certificate),
+						"CipherInformation":      Equal(cipherInformation),
+						"Mutual":                 BeFalse(),
+						"ServerCipherPreference": PointTo(BeTrue()),
+						"KeyExchange":            Equal(keyExchange),
+						"ALPNProtocols":          Equal([]string{"h2", "http/1.1"}),
+						"ProbeErrors":            Equal(scanResult.ProbeErrors),
+						"ScanError":              BeEmpty(),
 					})),
+					"HTTPInformation": Equal(httpInformation),
 				}),
 			}),
 		}))
 	})
+
+	It("Should keep the probe errors of ports which did not answer any TLS handshake", func() {
+		systemProcesses := []scantron.Process{
+			{
+				CommandName: "command",
+				PID:         123,
+			},
+		}
+
+		systemPorts := []process.ProcessPort{
+			{
+				PID: 123,
+				Port: scantron.Port{
+					Protocol: "tcp",
+					Number:   8443,
+					State:    "Listen",
+				},
+			},
+		}
+
+		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(systemPorts).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(nil).Times(1)
+		mockSystemResources.EXPECT().GetRawSockets().Return(nil).Times(1)
+
+		probeErrors := []scantron.ProbeError{
+			{Protocol: "VersionTLS12", Error: "i/o timeout"},
+			{Protocol: "VersionTLS13", Error: "i/o timeout"},
+		}
+		mockTlsScanner.EXPECT().Scan(gomock.Any(), "localhost", "8443", "command").Return(tlsscan.ScanResult{
+			CipherInformation: scantron.CipherInformation{"VersionTLS12": []string{}, "VersionTLS13": []string{}},
+			ProbeErrors:       probeErrors,
+		}, nil).Times(1)
+		mockHttpScanner.EXPECT().Scan(gomock.Any(), "localhost", "8443", false).Return(nil, errors.New("EOF")).Times(1)
+
+		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes[0].Ports).To(HaveLen(1))
+		Expect(processes[0].Ports[0].TLSInformation).NotTo(BeNil())
+		Expect(processes[0].Ports[0].TLSInformation.ProbeErrors).To(Equal(probeErrors))
+		Expect(processes[0].Ports[0].TLSInformation.Certificate).To(BeNil())
+	})
+
+	It("Should probe plain HTTP on listening TCP ports without TLS", func() {
+		systemProcesses := []scantron.Process{
+			{
+				CommandName: "command",
+				PID:         123,
+			},
+		}
+
+		systemPorts := []process.ProcessPort{
+			{
+				PID: 123,
+				Port: scantron.Port{
+					Protocol: "tcp",
+					Number:   8080,
+					State:    "Listen",
+				},
+			},
+			{
+				PID: 123,
+				Port: scantron.Port{
+					Protocol: "tcp",
+					Number:   5432,
+					State:    "Listen",
+				},
+			},
+		}
+
+		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(systemPorts).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(nil).Times(1)
+		mockSystemResources.EXPECT().GetRawSockets().Return(nil).Times(1)
+
+		mockTlsScanner.EXPECT().Scan(gomock.Any(), "localhost", gomock.Any(), "command").Return(tlsscan.ScanResult{
+			CipherInformation: scantron.CipherInformation{"VersionTLS12": []string{}},
+		}, nil).Times(2)
+
+		httpInformation := &scantron.HTTPInformation{
+			StatusCode:       301,
+			RedirectsToHTTPS: true,
+		}
+		mockHttpScanner.EXPECT().Scan(gomock.Any(), "localhost", "8080", false).Return(httpInformation, nil).Times(1)
+		mockHttpScanner.EXPECT().Scan(gomock.Any(), "localhost", "5432", false).Return(nil, errors.New("malformed HTTP response")).Times(1)
+
+		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes[0].Ports).To(HaveLen(2))
+		Expect(processes[0].Ports[0].TLSInformation).To(BeNil())
+		Expect(processes[0].Ports[0].HTTPInformation).To(Equal(httpInformation))
+		Expect(processes[0].Ports[1].HTTPInformation).To(BeNil())
+	})
+
+	It("Should probe ports for HTTP at the same time", func() {
+		subject.HttpConcurrency = 2
+
+		systemProcesses := []scantron.Process{
+			{
+				CommandName: "command",
+				PID:         123,
+			},
+		}
+
+		systemPorts := []process.ProcessPort{
+			{
+				PID: 123,
+				Port: scantron.Port{
+					Protocol: "tcp",
+					Number:   8080,
+					State:    "Listen",
+				},
+			},
+			{
+				PID: 123,
+				Port: scantron.Port{
+					Protocol: "tcp",
+					Number:   8081,
+					State:    "Listen",
+				},
+			},
+		}
+
+		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(systemPorts).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(nil).Times(1)
+		mockSystemResources.EXPECT().GetRawSockets().Return(nil).Times(1)
+
+		mockTlsScanner.EXPECT().Scan(gomock.Any(), "localhost", gomock.Any(), "command").Return(tlsscan.ScanResult{
+			CipherInformation: scantron.CipherInformation{"VersionTLS12": []string{}},
+		}, nil).Times(2)
+
+		arrived := &sync.WaitGroup{}
+		arrived.Add(2)
+		together := make(chan struct{})
+		go func() {
+			arrived.Wait()
+			close(together)
+		}()
+
+		httpInformation := &scantron.HTTPInformation{StatusCode: 200}
+		mockHttpScanner.EXPECT().Scan(gomock.Any(), "localhost", gomock.Any(), false).DoAndReturn(
+			func(scanlog.Logger, string, string, bool) (*scantron.HTTPInformation, error) {
+				arrived.Done()
+
+				select {
+				case <-together:
+					return httpInformation, nil
+				case <-time.After(5 * time.Second):
+					return nil, errors.New("the other port was not probed at the same time")
+				}
+			},
+		).Times(2)
+
+		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes[0].Ports).To(HaveLen(2))
+		Expect(processes[0].Ports[0].HTTPInformation).To(Equal(httpInformation))
+		Expect(processes[0].Ports[1].HTTPInformation).To(Equal(httpInformation))
+	})
+
+	It("Should probe wildcard binds over loopback", func() {
+		systemProcesses := []scantron.Process{
+			{
+				CommandName: "command",
+				PID:         123,
+			},
+		}
+
+		systemPorts := []process.ProcessPort{
+			{
+				PID: 123,
+				Port: scantron.Port{
+					Protocol: "tcp",
+					Address:  "0.0.0.0",
+					Number:   8443,
+					State:    "LISTEN",
+				},
+			},
+			{
+				PID: 123,
+				Port: scantron.Port{
+					Protocol: "tcp6",
+					Address:  "::",
+					Number:   9443,
+					State:    "LISTEN",
+				},
+			},
+			{
+				PID: 123,
+				Port: scantron.Port{
+					Protocol: "tcp6",
+					Address:  "fd00::5",
+					Number:   10443,
+					State:    "LISTEN",
+				},
+			},
+		}
+
+		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(systemPorts).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(nil).Times(1)
+		mockSystemResources.EXPECT().GetRawSockets().Return(nil).Times(1)
+
+		noTLS := tlsscan.ScanResult{
+			CipherInformation: scantron.CipherInformation{"VersionTLS12": []string{}},
+		}
+		mockTlsScanner.EXPECT().Scan(gomock.Any(), "localhost", "8443", "command").Return(noTLS, nil).Times(1)
+		mockTlsScanner.EXPECT().Scan(gomock.Any(), "::1", "9443", "command").Return(noTLS, nil).Times(1)
+		mockTlsScanner.EXPECT().Scan(gomock.Any(), "fd00::5", "10443", "command").Return(noTLS, nil).Times(1)
+
+		mockHttpScanner.EXPECT().Scan(gomock.Any(), "localhost", "8443", false).Return(nil, errors.New("EOF")).Times(1)
+		mockHttpScanner.EXPECT().Scan(gomock.Any(), "::1", "9443", false).Return(nil, errors.New("EOF")).Times(1)
+		mockHttpScanner.EXPECT().Scan(gomock.Any(), "fd00::5", "10443", false).Return(nil, errors.New("EOF")).Times(1)
+
+		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes[0].Ports).To(HaveLen(3))
+		Expect(processes[0].Ports[0].ProbedAddress).To(Equal("localhost"))
+		Expect(processes[0].Ports[1].ProbedAddress).To(Equal("::1"))
+		Expect(processes[0].Ports[2].ProbedAddress).To(Equal("fd00::5"))
+	})
+
+	It("Should associate Unix and raw sockets with processes", func() {
+		systemProcesses := []scantron.Process{
+			{CommandName: "dockerd", PID: 123, User: "root"},
+			{CommandName: "dhclient", PID: 456, User: "root"},
+		}
+
+		dockerSocket := scantron.UnixSocket{
+			Path:        "/var/run/docker.sock",
+			Type:        "stream",
+			Permissions: 0660,
+			User:        "root",
+			Group:       "docker",
+		}
+		packetSocket := scantron.RawSocket{
+			Family:   "packet",
+			Protocol: 0x0800,
+		}
+
+		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(nil).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(process.ProcessUnixSockets{
+			{PID: 123, Socket: dockerSocket},
+		}).Times(1)
+		mockSystemResources.EXPECT().GetRawSockets().Return(process.ProcessRawSockets{
+			{PID: 456, Socket: packetSocket},
+		}).Times(1)
+
+		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes).To(HaveLen(2))
+		Expect(processes[0].UnixSockets).To(Equal([]scantron.UnixSocket{dockerSocket}))
+		Expect(processes[0].RawSockets).To(BeEmpty())
+		Expect(processes[1].UnixSockets).To(BeEmpty())
+		Expect(processes[1].RawSockets).To(Equal([]scantron.RawSocket{packetSocket}))
+	})
 })
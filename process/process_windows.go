@@ -13,8 +13,10 @@ import (
 type WinProcess struct {
 	CommandName string `json:"name"`
 	PID         int    `json:"pid"`
+	PPID        int    `json:"ppid"`
 	User        string `json:"user"`
 	Cmdline     string `json:"cmdline"`
+	Executable  string `json:"executable"`
 }
 
 type WinEnv struct {
@@ -35,7 +37,7 @@ type SystemResourceImpl struct {
 
 func (s *SystemResourceImpl) GetProcesses() ([]scantron.Process, error) {
 
-	cmd := exec.Command("powershell", "get-wmiobject win32_process | select @{Name='pid'; Expression={$_.ProcessId}}, @{Name='name'; Expression={$_.Name}}, @{Name='user'; Expression={$_.GetOwner().User }}, @{Name='cmdline'; Expression={$_.Commandline}} | ConvertTo-Json")
+	cmd := exec.Command("powershell", "get-wmiobject win32_process | select @{Name='pid'; Expression={$_.ProcessId}}, @{Name='name'; Expression={$_.Name}}, @{Name='user'; Expression={$_.GetOwner().User }}, @{Name='cmdline'; Expression={$_.Commandline}}, @{Name='ppid'; Expression={$_.ParentProcessId}}, @{Name='executable'; Expression={$_.ExecutablePath}} | ConvertTo-Json")
 
 	out, e := cmd.Output()
 	if e != nil {
@@ -54,9 +56,11 @@ func (s *SystemResourceImpl) GetProcesses() ([]scantron.Process, error) {
 		process := scantron.Process{
 			CommandName: rawProcess.CommandName,
 			PID:         pid,
+			PPID:        rawProcess.PPID,
 			User:        rawProcess.User,
 			Cmdline:     strings.Split(rawProcess.Cmdline, " "),
 			Env:         getEnv(pid),
+			Executable:  rawProcess.Executable,
 		}
 		processes = append(processes, process)
 	}
@@ -119,6 +123,15 @@ func (s *SystemResourceImpl) GetPorts() ProcessPorts {
 	return ports
 }
 
+// Windows has no Unix domain or raw sockets which scantron inspects.
+func (s *SystemResourceImpl) GetUnixSockets() ProcessUnixSockets {
+	return nil
+}
+
+func (s *SystemResourceImpl) GetRawSockets() ProcessRawSockets {
+	return nil
+}
+
 func getEnv(pid int) []string {
 	cmd := exec.Command("powershell", fmt.Sprintf("(get-process -id %d).StartInfo.EnvironmentVariables | Convertto-json", pid))
 
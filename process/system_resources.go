@@ -5,4 +5,6 @@ import "github.com/pivotal-cf/scantron"
 type SystemResources interface {
 	GetProcesses() ([]scantron.Process, error)
 	GetPorts() ProcessPorts
+	GetUnixSockets() ProcessUnixSockets
+	GetRawSockets() ProcessRawSockets
 }
@@ -0,0 +1,132 @@
+// +build !windows
+
+package procfs
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of the times in /proc/<pid>/stat. The
+// kernel always reports them at 100 ticks a second regardless of its HZ.
+const clockTicks = 100
+
+const deletedSuffix = " (deleted)"
+
+type Executable struct {
+	Path    string
+	SHA256  string
+	Deleted bool
+}
+
+// BootTime reads the time the machine booted from the proc filesystem mounted
+// at procRoot.
+func BootTime(procRoot string) (time.Time, error) {
+	f, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid btime %q: %s", fields[1], err)
+		}
+
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Time{}, errors.New("no btime in /proc/stat")
+}
+
+// StartTime reads the time the process with the given PID started. The stat
+// file only has the number of ticks since the machine booted.
+func StartTime(procRoot string, pid int, bootTime time.Time) (time.Time, error) {
+	bs, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	ticks, err := parseStartTicks(string(bs))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return bootTime.Add(time.Duration(ticks) * time.Second / clockTicks), nil
+}
+
+// parseStartTicks finds the starttime field of a stat file. The command name
+// before it is in parentheses and may itself contain spaces or parentheses.
+func parseStartTicks(stat string) (uint64, error) {
+	end := strings.LastIndex(stat, ")")
+	if end == -1 {
+		return 0, fmt.Errorf("invalid stat %q", stat)
+	}
+
+	// The fields after the command name start with the state, field 3.
+	// starttime is field 22.
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("too few fields in stat %q", stat)
+	}
+
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid starttime %q: %s", fields[19], err)
+	}
+
+	return ticks, nil
+}
+
+// ReadExecutable finds the executable the process with the given PID is
+// running and hashes it. The executable can still be read through the proc
+// filesystem when it has been deleted or replaced on disk.
+func ReadExecutable(procRoot string, pid int) (Executable, error) {
+	exe := filepath.Join(procRoot, strconv.Itoa(pid), "exe")
+
+	target, err := os.Readlink(exe)
+	if err != nil {
+		return Executable{}, err
+	}
+
+	executable := Executable{
+		Path:    strings.TrimSuffix(target, deletedSuffix),
+		Deleted: strings.HasSuffix(target, deletedSuffix),
+	}
+
+	f, err := os.Open(exe)
+	if err != nil {
+		return executable, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return executable, err
+	}
+
+	executable.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return executable, nil
+}
@@ -0,0 +1,13 @@
+package procfs_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestProcfs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Procfs Suite")
+}
@@ -0,0 +1,177 @@
+package procfs_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/procfs"
+)
+
+var _ = Describe("Procfs", func() {
+	var procRoot string
+
+	BeforeEach(func() {
+		var err error
+		procRoot, err = ioutil.TempDir("", "procfs")
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(filepath.Join("testdata", "stat"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(procRoot, "stat"), contents, 0644)).To(Succeed())
+
+		Expect(os.MkdirAll(filepath.Join(procRoot, "123"), 0755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(procRoot)).To(Succeed())
+	})
+
+	Describe("BootTime", func() {
+		It("reads btime", func() {
+			bootTime, err := procfs.BootTime(procRoot)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bootTime).To(Equal(time.Unix(1700000000, 0).UTC()))
+		})
+
+		It("fails without btime", func() {
+			Expect(ioutil.WriteFile(filepath.Join(procRoot, "stat"), []byte("cpu 1 2 3\n"), 0644)).To(Succeed())
+
+			_, err := procfs.BootTime(procRoot)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("StartTime", func() {
+		bootTime := time.Unix(1700000000, 0).UTC()
+
+		It("adds the ticks since boot to the boot time", func() {
+			stat := "123 (my (odd) proc) S 1 123 123 0 -1 4194560 100 0 0 0 5 3 0 0 20 0 1 0 12345 1000 200\n"
+			Expect(ioutil.WriteFile(filepath.Join(procRoot, "123", "stat"), []byte(stat), 0644)).To(Succeed())
+
+			startTime, err := procfs.StartTime(procRoot, 123, bootTime)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(startTime).To(Equal(bootTime.Add(123450 * time.Millisecond)))
+		})
+
+		It("fails when the stat file is truncated", func() {
+			Expect(ioutil.WriteFile(filepath.Join(procRoot, "123", "stat"), []byte("123 (proc) S 1 123\n"), 0644)).To(Succeed())
+
+			_, err := procfs.StartTime(procRoot, 123, bootTime)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ReadExecutable", func() {
+		// sha256("hello\n")
+		const helloSHA256 = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+		It("resolves and hashes the executable", func() {
+			path := filepath.Join(procRoot, "server")
+			Expect(ioutil.WriteFile(path, []byte("hello\n"), 0755)).To(Succeed())
+			Expect(os.Symlink(path, filepath.Join(procRoot, "123", "exe"))).To(Succeed())
+
+			executable, err := procfs.ReadExecutable(procRoot, 123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(executable).To(Equal(procfs.Executable{
+				Path:    path,
+				SHA256:  helloSHA256,
+				Deleted: false,
+			}))
+		})
+
+		It("notices when the executable has been deleted", func() {
+			// The kernel adds " (deleted)" to the link but the file can still be
+			// opened through it. Make a file with that name to do the same.
+			path := filepath.Join(procRoot, "server")
+			Expect(ioutil.WriteFile(path+" (deleted)", []byte("hello\n"), 0755)).To(Succeed())
+			Expect(os.Symlink(path+" (deleted)", filepath.Join(procRoot, "123", "exe"))).To(Succeed())
+
+			executable, err := procfs.ReadExecutable(procRoot, 123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(executable).To(Equal(procfs.Executable{
+				Path:    path,
+				SHA256:  helloSHA256,
+				Deleted: true,
+			}))
+		})
+
+		It("fails when the process has no executable", func() {
+			_, err := procfs.ReadExecutable(procRoot, 123)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ReadStatus", func() {
+		It("reads the capabilities, no_new_privs and seccomp mode", func() {
+			contents, err := ioutil.ReadFile(filepath.Join("testdata", "status"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(procRoot, "123", "status"), contents, 0644)).To(Succeed())
+
+			status, err := procfs.ReadStatus(procRoot, 123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(procfs.Status{
+				EffectiveCapabilities: []string{"CAP_NET_BIND_SERVICE", "CAP_NET_ADMIN", "CAP_NET_RAW"},
+				PermittedCapabilities: []string{"CAP_NET_BIND_SERVICE", "CAP_NET_ADMIN", "CAP_NET_RAW", "CAP_SYS_ADMIN"},
+				NoNewPrivs:            true,
+				Seccomp:               "filter",
+			}))
+		})
+
+		It("names capabilities it does not know by their number", func() {
+			status := "CapPrm:\t0000020000000001\nCapEff:\t0000000000000000\nSeccomp:\t0\n"
+			Expect(ioutil.WriteFile(filepath.Join(procRoot, "123", "status"), []byte(status), 0644)).To(Succeed())
+
+			s, err := procfs.ReadStatus(procRoot, 123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.PermittedCapabilities).To(Equal([]string{"CAP_CHOWN", "CAP_41"}))
+			Expect(s.EffectiveCapabilities).To(BeEmpty())
+			Expect(s.NoNewPrivs).To(BeFalse())
+			Expect(s.Seccomp).To(Equal("disabled"))
+		})
+
+		It("fails when a capability mask is not hex", func() {
+			Expect(ioutil.WriteFile(filepath.Join(procRoot, "123", "status"), []byte("CapEff:\tzzz\n"), 0644)).To(Succeed())
+
+			_, err := procfs.ReadStatus(procRoot, 123)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ReadNamespaces", func() {
+		var nsDir string
+
+		BeforeEach(func() {
+			nsDir = filepath.Join(procRoot, "123", "ns")
+			Expect(os.MkdirAll(nsDir, 0755)).To(Succeed())
+		})
+
+		It("reads the inode of each namespace", func() {
+			Expect(os.Symlink("net:[4026531992]", filepath.Join(nsDir, "net"))).To(Succeed())
+			Expect(os.Symlink("pid:[4026531836]", filepath.Join(nsDir, "pid"))).To(Succeed())
+			Expect(os.Symlink("mnt:[4026532201]", filepath.Join(nsDir, "mnt"))).To(Succeed())
+
+			namespaces, err := procfs.ReadNamespaces(procRoot, 123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(namespaces).To(Equal(scantron.Namespaces{
+				Net:   4026531992,
+				PID:   4026531836,
+				Mount: 4026532201,
+			}))
+		})
+
+		It("fails when a link is not a namespace", func() {
+			Expect(os.Symlink("net:[4026531992]", filepath.Join(nsDir, "net"))).To(Succeed())
+			Expect(os.Symlink("pid:[4026531836]", filepath.Join(nsDir, "pid"))).To(Succeed())
+			Expect(os.Symlink("/dev/null", filepath.Join(nsDir, "mnt"))).To(Succeed())
+
+			_, err := procfs.ReadNamespaces(procRoot, 123)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,188 @@
+// +build !windows
+
+package procfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+// capabilityNames are the names of the capabilities in the order of their bits
+// in the CapEff and CapPrm masks.
+var capabilityNames = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_DAC_READ_SEARCH",
+	"CAP_FOWNER",
+	"CAP_FSETID",
+	"CAP_KILL",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETPCAP",
+	"CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN",
+	"CAP_NET_RAW",
+	"CAP_IPC_LOCK",
+	"CAP_IPC_OWNER",
+	"CAP_SYS_MODULE",
+	"CAP_SYS_RAWIO",
+	"CAP_SYS_CHROOT",
+	"CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT",
+	"CAP_SYS_ADMIN",
+	"CAP_SYS_BOOT",
+	"CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME",
+	"CAP_SYS_TTY_CONFIG",
+	"CAP_MKNOD",
+	"CAP_LEASE",
+	"CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL",
+	"CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE",
+	"CAP_MAC_ADMIN",
+	"CAP_SYSLOG",
+	"CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND",
+	"CAP_AUDIT_READ",
+	"CAP_PERFMON",
+	"CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+var seccompModes = map[string]string{
+	"0": "disabled",
+	"1": "strict",
+	"2": "filter",
+}
+
+type Status struct {
+	EffectiveCapabilities []string
+	PermittedCapabilities []string
+	NoNewPrivs            bool
+	Seccomp               string
+}
+
+// ReadStatus reads the capabilities and sandboxing of the process with the
+// given PID from its status file. Kernels older than 4.10 do not show
+// NoNewPrivs, in which case it is false.
+func ReadStatus(procRoot string, pid int) (Status, error) {
+	f, err := os.Open(filepath.Join(procRoot, strconv.Itoa(pid), "status"))
+	if err != nil {
+		return Status{}, err
+	}
+	defer f.Close()
+
+	return parseStatus(f)
+}
+
+func parseStatus(r io.Reader) (Status, error) {
+	status := Status{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+
+		var err error
+		switch parts[0] {
+		case "CapEff":
+			status.EffectiveCapabilities, err = parseCapabilities(value)
+		case "CapPrm":
+			status.PermittedCapabilities, err = parseCapabilities(value)
+		case "NoNewPrivs":
+			status.NoNewPrivs = value == "1"
+		case "Seccomp":
+			mode, ok := seccompModes[value]
+			if !ok {
+				mode = value
+			}
+			status.Seccomp = mode
+		}
+
+		if err != nil {
+			return Status{}, err
+		}
+	}
+
+	return status, scanner.Err()
+}
+
+// parseCapabilities turns a capability mask such as 0000000000003000 into the
+// names of the capabilities in it.
+func parseCapabilities(mask string) ([]string, error) {
+	bits, err := strconv.ParseUint(mask, 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid capability mask %q: %s", mask, err)
+	}
+
+	names := []string{}
+	for i := uint(0); i < 64; i++ {
+		if bits&(1<<i) == 0 {
+			continue
+		}
+
+		if int(i) < len(capabilityNames) {
+			names = append(names, capabilityNames[i])
+		} else {
+			names = append(names, fmt.Sprintf("CAP_%d", i))
+		}
+	}
+
+	return names, nil
+}
+
+// ReadNamespaces reads the inode numbers of the network, PID and mount
+// namespaces of the process with the given PID. Processes in the same
+// namespace have the same inode number.
+func ReadNamespaces(procRoot string, pid int) (scantron.Namespaces, error) {
+	nsDir := filepath.Join(procRoot, strconv.Itoa(pid), "ns")
+
+	namespaces := scantron.Namespaces{}
+	for name, inode := range map[string]*uint64{
+		"net": &namespaces.Net,
+		"pid": &namespaces.PID,
+		"mnt": &namespaces.Mount,
+	} {
+		target, err := os.Readlink(filepath.Join(nsDir, name))
+		if err != nil {
+			return scantron.Namespaces{}, err
+		}
+
+		*inode, err = namespaceInode(name, target)
+		if err != nil {
+			return scantron.Namespaces{}, err
+		}
+	}
+
+	return namespaces, nil
+}
+
+// namespaceInode parses a namespace link such as net:[4026531992].
+func namespaceInode(name, target string) (uint64, error) {
+	prefix := name + ":["
+	if !strings.HasPrefix(target, prefix) || !strings.HasSuffix(target, "]") {
+		return 0, fmt.Errorf("invalid %s namespace %q", name, target)
+	}
+
+	inode, err := strconv.ParseUint(target[len(prefix):len(target)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s namespace %q", name, target)
+	}
+
+	return inode, nil
+}
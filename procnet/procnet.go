@@ -0,0 +1,241 @@
+// +build !windows
+
+package procnet
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+// Protocols are the files in /proc/net which are read, named after the
+// protocol netstat shows for them.
+var Protocols = []string{"tcp", "tcp6", "udp", "udp6"}
+
+type ProcNetPort struct {
+	PID  int
+	Port scantron.Port
+}
+
+// Socket is a line of a /proc/net/{tcp,tcp6,udp,udp6} file.
+type Socket struct {
+	Port  scantron.Port
+	Inode uint64
+}
+
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+	"0C": "NEW_SYN_RECV",
+}
+
+// ReadPorts reads the sockets of every protocol from the proc filesystem
+// mounted at procRoot and finds the process which owns each of them. Sockets
+// which no process could be found for are left out, as netstat does.
+func ReadPorts(procRoot string) ([]ProcNetPort, error) {
+	owners, err := SocketOwners(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []ProcNetPort{}
+
+	for _, protocol := range Protocols {
+		f, err := os.Open(filepath.Join(procRoot, "net", protocol))
+		if os.IsNotExist(err) {
+			// IPv6 may be disabled.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sockets, err := ParseSockets(f, protocol)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse /proc/net/%s: %s", protocol, err)
+		}
+
+		for _, socket := range sockets {
+			pid, ok := owners[socket.Inode]
+			if !ok {
+				continue
+			}
+
+			ports = append(ports, ProcNetPort{
+				PID:  pid,
+				Port: socket.Port,
+			})
+		}
+	}
+
+	return ports, nil
+}
+
+// ParseSockets parses a /proc/net/{tcp,tcp6,udp,udp6} file. Addresses are
+// formatted as netstat does: a foreign port of 0 is -1 (netstat's "*") and
+// UDP sockets only have a state if they are connected.
+func ParseSockets(r io.Reader, protocol string) ([]Socket, error) {
+	scanner := bufio.NewScanner(r)
+	sockets := []Socket{}
+
+	// The first line is the header.
+	scanner.Scan()
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 10 {
+			return nil, fmt.Errorf("too few fields in line %q", scanner.Text())
+		}
+
+		localAddress, localNumber, err := parseAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		foreignAddress, foreignNumber, err := parseAddress(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		if foreignNumber == 0 {
+			foreignNumber = -1
+		}
+
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode %q: %s", fields[9], err)
+		}
+
+		sockets = append(sockets, Socket{
+			Port: scantron.Port{
+				Protocol:       protocol,
+				Address:        localAddress,
+				Number:         localNumber,
+				ForeignAddress: foreignAddress,
+				ForeignNumber:  foreignNumber,
+				State:          state(protocol, fields[3]),
+			},
+			Inode: inode,
+		})
+	}
+
+	return sockets, scanner.Err()
+}
+
+func state(protocol, code string) string {
+	if strings.HasPrefix(protocol, "udp") {
+		if code == "01" {
+			return "ESTABLISHED"
+		}
+
+		return ""
+	}
+
+	return tcpStates[strings.ToUpper(code)]
+}
+
+// parseAddress parses an address such as 0100007F:1F90. The IP is written as
+// 32-bit words in host byte order, which is little-endian on every platform
+// that BOSH runs on.
+func parseAddress(field string) (string, int, error) {
+	parts := strings.Split(field, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid address %q", field)
+	}
+
+	raw, err := hex.DecodeString(parts[0])
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address %q", field)
+	}
+
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in address %q", field)
+	}
+
+	return ip.String(), int(port), nil
+}
+
+// SocketOwners maps the inode of every socket which a process has open to the
+// process. A socket shared by several processes, such as a listener inherited
+// by worker processes, belongs to the one with the lowest PID.
+func SocketOwners(procRoot string) (map[uint64]int, error) {
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := map[uint64]int{}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join(procRoot, entry.Name(), "fd")
+
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			// The process has exited or we may not look at it.
+			continue
+		}
+
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+
+			inode, ok := socketInode(target)
+			if !ok {
+				continue
+			}
+
+			if owner, seen := owners[inode]; !seen || pid < owner {
+				owners[inode] = pid
+			}
+		}
+	}
+
+	return owners, nil
+}
+
+func socketInode(target string) (uint64, bool) {
+	if !strings.HasPrefix(target, "socket:[") || !strings.HasSuffix(target, "]") {
+		return 0, false
+	}
+
+	inode, err := strconv.ParseUint(target[len("socket:["):len(target)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return inode, true
+}
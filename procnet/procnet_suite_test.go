@@ -0,0 +1,13 @@
+package procnet_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestProcnet(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Procnet Suite")
+}
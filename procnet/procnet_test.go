@@ -0,0 +1,116 @@
+package procnet_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/procnet"
+)
+
+var _ = Describe("Procnet", func() {
+	DescribeTable("parsing captured /proc/net files",
+		func(protocol string, expected []procnet.Socket) {
+			f, err := os.Open(filepath.Join("testdata", protocol))
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+
+			sockets, err := procnet.ParseSockets(f, protocol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sockets).To(Equal(expected))
+		},
+		Entry("tcp", "tcp", []procnet.Socket{
+			{Inode: 16204, Port: scantron.Port{Protocol: "tcp", Address: "0.0.0.0", Number: 22, ForeignAddress: "0.0.0.0", ForeignNumber: -1, State: "LISTEN"}},
+			{Inode: 20512, Port: scantron.Port{Protocol: "tcp", Address: "127.0.0.1", Number: 3306, ForeignAddress: "0.0.0.0", ForeignNumber: -1, State: "LISTEN"}},
+			{Inode: 20533, Port: scantron.Port{Protocol: "tcp", Address: "10.0.0.5", Number: 8443, ForeignAddress: "0.0.0.0", ForeignNumber: -1, State: "LISTEN"}},
+			{Inode: 30871, Port: scantron.Port{Protocol: "tcp", Address: "10.0.0.5", Number: 22, ForeignAddress: "10.0.0.2", ForeignNumber: 50030, State: "ESTABLISHED"}},
+		}),
+		Entry("tcp6", "tcp6", []procnet.Socket{
+			{Inode: 16206, Port: scantron.Port{Protocol: "tcp6", Address: "::", Number: 22, ForeignAddress: "::", ForeignNumber: -1, State: "LISTEN"}},
+			{Inode: 20540, Port: scantron.Port{Protocol: "tcp6", Address: "::1", Number: 8443, ForeignAddress: "::", ForeignNumber: -1, State: "LISTEN"}},
+			{Inode: 20541, Port: scantron.Port{Protocol: "tcp6", Address: "fe80::1", Number: 50000, ForeignAddress: "::", ForeignNumber: -1, State: "LISTEN"}},
+		}),
+		Entry("udp", "udp", []procnet.Socket{
+			{Inode: 15120, Port: scantron.Port{Protocol: "udp", Address: "127.0.0.53", Number: 53, ForeignAddress: "0.0.0.0", ForeignNumber: -1, State: ""}},
+			{Inode: 30990, Port: scantron.Port{Protocol: "udp", Address: "10.0.0.5", Number: 41170, ForeignAddress: "192.168.1.1", ForeignNumber: 53, State: "ESTABLISHED"}},
+		}),
+		Entry("udp6", "udp6", []procnet.Socket{
+			{Inode: 15550, Port: scantron.Port{Protocol: "udp6", Address: "::", Number: 111, ForeignAddress: "::", ForeignNumber: -1, State: ""}},
+		}),
+	)
+
+	DescribeTable("rejecting malformed lines",
+		func(line string) {
+			input := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" + line + "\n"
+
+			_, err := procnet.ParseSockets(strings.NewReader(input), "tcp")
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("too few fields", "   0: 00000000:0016 00000000:0000 0A"),
+		Entry("address without a port", "   0: 00000000 00000000:0000 0A 00000000:00000000 00:00000000 00000000 0 0 16204 1"),
+		Entry("address of the wrong length", "   0: 000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000 0 0 16204 1"),
+		Entry("port which is not hex", "   0: 00000000:XY16 00000000:0000 0A 00000000:00000000 00:00000000 00000000 0 0 16204 1"),
+		Entry("inode which is not a number", "   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000 0 0 abc 1"),
+	)
+
+	Describe("ReadPorts", func() {
+		var procRoot string
+
+		BeforeEach(func() {
+			var err error
+			procRoot, err = ioutil.TempDir("", "procnet")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.MkdirAll(filepath.Join(procRoot, "net"), 0755)).To(Succeed())
+			for _, protocol := range []string{"tcp", "udp"} {
+				contents, err := ioutil.ReadFile(filepath.Join("testdata", protocol))
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(procRoot, "net", protocol), contents, 0644)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			fds := map[string]map[string]string{
+				"100": {"0": "/dev/null", "3": "socket:[16204]"},
+				"200": {"3": "socket:[16204]", "4": "socket:[20512]", "5": "pipe:[999]"},
+				"300": {"7": "socket:[15120]"},
+			}
+			for pid, links := range fds {
+				fdDir := filepath.Join(procRoot, pid, "fd")
+				Expect(os.MkdirAll(fdDir, 0755)).To(Succeed())
+
+				for fd, target := range links {
+					Expect(os.Symlink(target, filepath.Join(fdDir, fd))).To(Succeed())
+				}
+			}
+
+			Expect(os.MkdirAll(filepath.Join(procRoot, "sys"), 0755)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(procRoot)).To(Succeed())
+		})
+
+		It("finds the process which owns each socket", func() {
+			ports, err := procnet.ReadPorts(procRoot)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ports).To(Equal([]procnet.ProcNetPort{
+				{PID: 100, Port: scantron.Port{Protocol: "tcp", Address: "0.0.0.0", Number: 22, ForeignAddress: "0.0.0.0", ForeignNumber: -1, State: "LISTEN"}},
+				{PID: 200, Port: scantron.Port{Protocol: "tcp", Address: "127.0.0.1", Number: 3306, ForeignAddress: "0.0.0.0", ForeignNumber: -1, State: "LISTEN"}},
+				{PID: 300, Port: scantron.Port{Protocol: "udp", Address: "127.0.0.53", Number: 53, ForeignAddress: "0.0.0.0", ForeignNumber: -1, State: ""}},
+			}))
+		})
+
+		It("fails when the proc filesystem cannot be read", func() {
+			_, err := procnet.ReadPorts(filepath.Join(procRoot, "missing"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,276 @@
+// +build !windows
+
+package procnet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+const unixAcceptCon = 0x10000
+
+var unixSocketTypes = map[string]string{
+	"0001": "stream",
+	"0002": "dgram",
+	"0005": "seqpacket",
+}
+
+type ProcNetUnixSocket struct {
+	PID    int
+	Socket scantron.UnixSocket
+}
+
+type ProcNetRawSocket struct {
+	PID    int
+	Socket scantron.RawSocket
+}
+
+// UnixSocketEntry is a line of /proc/net/unix.
+type UnixSocketEntry struct {
+	Socket scantron.UnixSocket
+	Inode  uint64
+}
+
+// RawSocketEntry is a line of /proc/net/{raw,raw6,packet}.
+type RawSocketEntry struct {
+	Socket scantron.RawSocket
+	Inode  uint64
+}
+
+// ReadUnixSockets reads the Unix sockets which processes are listening on
+// from the proc filesystem mounted at procRoot. The permissions and owner of
+// each socket's file are looked up; abstract sockets have no file.
+func ReadUnixSockets(procRoot string) ([]ProcNetUnixSocket, error) {
+	owners, err := SocketOwners(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(procRoot, "net", "unix"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := ParseUnixSockets(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse /proc/net/unix: %s", err)
+	}
+
+	sockets := []ProcNetUnixSocket{}
+
+	for _, entry := range entries {
+		pid, ok := owners[entry.Inode]
+		if !ok {
+			continue
+		}
+
+		socket := entry.Socket
+		if !strings.HasPrefix(socket.Path, "@") {
+			info, err := os.Stat(socket.Path)
+			if err == nil {
+				socket.Permissions = info.Mode().Perm()
+				socket.User, socket.Group = fileOwner(info)
+			}
+		}
+
+		sockets = append(sockets, ProcNetUnixSocket{
+			PID:    pid,
+			Socket: socket,
+		})
+	}
+
+	return sockets, nil
+}
+
+// ReadRawSockets reads the raw IP and packet sockets from the proc filesystem
+// mounted at procRoot.
+func ReadRawSockets(procRoot string) ([]ProcNetRawSocket, error) {
+	owners, err := SocketOwners(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	sockets := []ProcNetRawSocket{}
+
+	for _, family := range []string{"raw", "raw6", "packet"} {
+		f, err := os.Open(filepath.Join(procRoot, "net", family))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var entries []RawSocketEntry
+		if family == "packet" {
+			entries, err = ParsePacketSockets(f)
+		} else {
+			entries, err = ParseRawSockets(f, family)
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse /proc/net/%s: %s", family, err)
+		}
+
+		for _, entry := range entries {
+			pid, ok := owners[entry.Inode]
+			if !ok {
+				continue
+			}
+
+			sockets = append(sockets, ProcNetRawSocket{
+				PID:    pid,
+				Socket: entry.Socket,
+			})
+		}
+	}
+
+	return sockets, nil
+}
+
+// ParseUnixSockets parses /proc/net/unix. Only sockets which are listening are
+// returned: stream and seqpacket sockets which accept connections, and
+// datagram sockets bound to a path. Abstract socket paths start with @.
+func ParseUnixSockets(r io.Reader) ([]UnixSocketEntry, error) {
+	scanner := bufio.NewScanner(r)
+	entries := []UnixSocketEntry{}
+
+	// The first line is the header.
+	scanner.Scan()
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("too few fields in line %q", scanner.Text())
+		}
+		if len(fields) == 7 {
+			// Unbound sockets have no path.
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags %q: %s", fields[3], err)
+		}
+
+		socketType, ok := unixSocketTypes[fields[4]]
+		if !ok {
+			socketType = fields[4]
+		}
+
+		if flags&unixAcceptCon == 0 && socketType != "dgram" {
+			continue
+		}
+
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode %q: %s", fields[6], err)
+		}
+
+		entries = append(entries, UnixSocketEntry{
+			Socket: scantron.UnixSocket{
+				Path: strings.Join(fields[7:], " "),
+				Type: socketType,
+			},
+			Inode: inode,
+		})
+	}
+
+	return entries, scanner.Err()
+}
+
+// ParseRawSockets parses /proc/net/raw or /proc/net/raw6. The IP protocol of
+// a raw socket is shown as the port of its local address.
+func ParseRawSockets(r io.Reader, family string) ([]RawSocketEntry, error) {
+	sockets, err := ParseSockets(r, family)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []RawSocketEntry{}
+	for _, socket := range sockets {
+		entries = append(entries, RawSocketEntry{
+			Socket: scantron.RawSocket{
+				Family:   family,
+				Protocol: socket.Port.Number,
+			},
+			Inode: socket.Inode,
+		})
+	}
+
+	return entries, nil
+}
+
+// ParsePacketSockets parses /proc/net/packet. The protocol of a packet socket
+// is an EtherType, such as 0x0003 for every protocol.
+func ParsePacketSockets(r io.Reader) ([]RawSocketEntry, error) {
+	scanner := bufio.NewScanner(r)
+	entries := []RawSocketEntry{}
+
+	// The first line is the header.
+	scanner.Scan()
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 9 {
+			return nil, fmt.Errorf("too few fields in line %q", scanner.Text())
+		}
+
+		protocol, err := strconv.ParseUint(fields[3], 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protocol %q: %s", fields[3], err)
+		}
+
+		inode, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode %q: %s", fields[8], err)
+		}
+
+		entries = append(entries, RawSocketEntry{
+			Socket: scantron.RawSocket{
+				Family:   "packet",
+				Protocol: int(protocol),
+			},
+			Inode: inode,
+		})
+	}
+
+	return entries, scanner.Err()
+}
+
+func fileOwner(info os.FileInfo) (string, string) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+
+	uid := fmt.Sprint(stat.Uid)
+	owner := uid
+	if u, err := user.LookupId(uid); err == nil {
+		owner = u.Username
+	}
+
+	gid := fmt.Sprint(stat.Gid)
+	group := gid
+	if g, err := user.LookupGroupId(gid); err == nil {
+		group = g.Name
+	}
+
+	return owner, group
+}
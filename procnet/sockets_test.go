@@ -0,0 +1,160 @@
+package procnet_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/procnet"
+)
+
+var _ = Describe("Sockets", func() {
+	Describe("ParseUnixSockets", func() {
+		It("finds the listening sockets", func() {
+			f, err := os.Open(filepath.Join("testdata", "unix"))
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+
+			entries, err := procnet.ParseUnixSockets(f)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(entries).To(Equal([]procnet.UnixSocketEntry{
+				{Inode: 16320, Socket: scantron.UnixSocket{Path: "/run/systemd/private", Type: "stream"}},
+				{Inode: 11885, Socket: scantron.UnixSocket{Path: "/run/systemd/journal/dev-log", Type: "dgram"}},
+				{Inode: 17001, Socket: scantron.UnixSocket{Path: "@/tmp/.X11-unix/X0", Type: "stream"}},
+				{Inode: 17002, Socket: scantron.UnixSocket{Path: "/run/udev/control", Type: "seqpacket"}},
+				{Inode: 20210, Socket: scantron.UnixSocket{Path: "/var/vcap/data/sys/run/my job.sock", Type: "stream"}},
+			}))
+		})
+
+		It("rejects malformed lines", func() {
+			input := "Num       RefCount Protocol Flags    Type St Inode Path\n" +
+				"0000000000000000: 00000002 00000000 00010000 0001 01 abc /run/foo.sock\n"
+
+			_, err := procnet.ParseUnixSockets(strings.NewReader(input))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ParseRawSockets", func() {
+		It("reads the protocol from the local port", func() {
+			for family, expected := range map[string]procnet.RawSocketEntry{
+				"raw":  {Inode: 25001, Socket: scantron.RawSocket{Family: "raw", Protocol: 1}},
+				"raw6": {Inode: 25002, Socket: scantron.RawSocket{Family: "raw6", Protocol: 58}},
+			} {
+				f, err := os.Open(filepath.Join("testdata", family))
+				Expect(err).NotTo(HaveOccurred())
+
+				entries, err := procnet.ParseRawSockets(f, family)
+				f.Close()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(Equal([]procnet.RawSocketEntry{expected}))
+			}
+		})
+	})
+
+	Describe("ParsePacketSockets", func() {
+		It("reads the EtherType of each socket", func() {
+			f, err := os.Open(filepath.Join("testdata", "packet"))
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+
+			entries, err := procnet.ParsePacketSockets(f)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(entries).To(Equal([]procnet.RawSocketEntry{
+				{Inode: 25003, Socket: scantron.RawSocket{Family: "packet", Protocol: 0x0003}},
+				{Inode: 25004, Socket: scantron.RawSocket{Family: "packet", Protocol: 0x0800}},
+			}))
+		})
+	})
+
+	Describe("reading sockets from the proc filesystem", func() {
+		var (
+			procRoot   string
+			socketPath string
+			listener   net.Listener
+		)
+
+		BeforeEach(func() {
+			var err error
+			procRoot, err = ioutil.TempDir("", "procnet")
+			Expect(err).NotTo(HaveOccurred())
+
+			socketPath = filepath.Join(procRoot, "test.sock")
+			listener, err = net.Listen("unix", socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Chmod(socketPath, 0666)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(procRoot, "net"), 0755)).To(Succeed())
+
+			unix := "Num       RefCount Protocol Flags    Type St Inode Path\n" +
+				fmt.Sprintf("0000000000000000: 00000002 00000000 00010000 0001 01 40001 %s\n", socketPath) +
+				"0000000000000000: 00000002 00000000 00010000 0001 01 40002 @abstract\n" +
+				"0000000000000000: 00000002 00000000 00010000 0001 01 40003 /run/orphan.sock\n"
+			Expect(ioutil.WriteFile(filepath.Join(procRoot, "net", "unix"), []byte(unix), 0644)).To(Succeed())
+
+			for _, family := range []string{"raw", "packet"} {
+				contents, err := ioutil.ReadFile(filepath.Join("testdata", family))
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(procRoot, "net", family), contents, 0644)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			fds := map[string]map[string]string{
+				"100": {"3": "socket:[40001]", "4": "socket:[25001]"},
+				"200": {"3": "socket:[40002]", "4": "socket:[25004]"},
+			}
+			for pid, links := range fds {
+				fdDir := filepath.Join(procRoot, pid, "fd")
+				Expect(os.MkdirAll(fdDir, 0755)).To(Succeed())
+
+				for fd, target := range links {
+					Expect(os.Symlink(target, filepath.Join(fdDir, fd))).To(Succeed())
+				}
+			}
+		})
+
+		AfterEach(func() {
+			listener.Close()
+			Expect(os.RemoveAll(procRoot)).To(Succeed())
+		})
+
+		It("finds the process, permissions and owner of each Unix socket", func() {
+			sockets, err := procnet.ReadUnixSockets(procRoot)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sockets).To(HaveLen(2))
+
+			Expect(sockets[0].PID).To(Equal(100))
+			Expect(sockets[0].Socket.Path).To(Equal(socketPath))
+			Expect(sockets[0].Socket.Type).To(Equal("stream"))
+			Expect(sockets[0].Socket.Permissions).To(Equal(os.FileMode(0666)))
+			Expect(sockets[0].Socket.User).NotTo(BeEmpty())
+			Expect(sockets[0].Socket.Group).NotTo(BeEmpty())
+
+			Expect(sockets[1]).To(Equal(procnet.ProcNetUnixSocket{
+				PID:    200,
+				Socket: scantron.UnixSocket{Path: "@abstract", Type: "stream"},
+			}))
+		})
+
+		It("finds the process of each raw and packet socket", func() {
+			sockets, err := procnet.ReadRawSockets(procRoot)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sockets).To(Equal([]procnet.ProcNetRawSocket{
+				{PID: 100, Socket: scantron.RawSocket{Family: "raw", Protocol: 1}},
+				{PID: 200, Socket: scantron.RawSocket{Family: "packet", Protocol: 0x0800}},
+			}))
+		})
+	})
+})
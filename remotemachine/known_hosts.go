@@ -0,0 +1,99 @@
+package remotemachine
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+type HostKeyMismatchError struct {
+	Hostname string
+	Err      error
+}
+
+func (e HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key verification failed for %s: %s", e.Hostname, e.Err)
+}
+
+// KnownHostsCallback verifies host keys against an OpenSSH known_hosts file.
+// When trustOnFirstUse is set, keys for hosts which are not in the file yet
+// are accepted and appended to it; otherwise they are rejected.
+func KnownHostsCallback(path string, trustOnFirstUse bool) (ssh.HostKeyCallback, error) {
+	if trustOnFirstUse {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		f.Close()
+	}
+
+	known, err := knownhosts.New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &sync.Mutex{}
+	trusted := map[string]ssh.PublicKey{}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := known(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		keyErr, ok := err.(*knownhosts.KeyError)
+		if !ok {
+			return HostKeyMismatchError{Hostname: hostname, Err: err}
+		}
+
+		if len(keyErr.Want) > 0 || !trustOnFirstUse {
+			return HostKeyMismatchError{Hostname: hostname, Err: err}
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		address := knownhosts.Normalize(hostname)
+
+		if trustedKey, found := trusted[address]; found {
+			if string(trustedKey.Marshal()) != string(key.Marshal()) {
+				return HostKeyMismatchError{Hostname: hostname, Err: err}
+			}
+
+			return nil
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = fmt.Fprintln(f, knownhosts.Line([]string{address}, key))
+		if err != nil {
+			return err
+		}
+
+		trusted[address] = key
+
+		return nil
+	}, nil
+}
+
+// PinnedHostKeyCallback only accepts the given key for the host.
+func PinnedHostKeyCallback(key ssh.PublicKey) ssh.HostKeyCallback {
+	fixed := ssh.FixedHostKey(key)
+
+	return func(hostname string, remote net.Addr, actual ssh.PublicKey) error {
+		err := fixed(hostname, remote, actual)
+		if err != nil {
+			return HostKeyMismatchError{Hostname: hostname, Err: err}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package remotemachine_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/pivotal-cf/scantron/remotemachine"
+)
+
+var _ = Describe("Host key verification", func() {
+	var (
+		tmpdir         string
+		knownHostsPath string
+
+		hostKey, otherKey ssh.PublicKey
+		remote            net.Addr
+	)
+
+	generateKey := func() ssh.PublicKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		return publicKey
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "known-hosts")
+		Expect(err).NotTo(HaveOccurred())
+
+		knownHostsPath = filepath.Join(tmpdir, "known_hosts")
+
+		hostKey = generateKey()
+		otherKey = generateKey()
+		remote = &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	Describe("KnownHostsCallback", func() {
+		Context("when the host is already known", func() {
+			BeforeEach(func() {
+				line := knownhosts.Line([]string{"10.0.0.1"}, hostKey) + "\n"
+				err := ioutil.WriteFile(knownHostsPath, []byte(line), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("accepts the matching key", func() {
+				callback, err := remotemachine.KnownHostsCallback(knownHostsPath, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(callback("10.0.0.1:22", remote, hostKey)).To(Succeed())
+			})
+
+			It("rejects a different key even when trusting on first use", func() {
+				callback, err := remotemachine.KnownHostsCallback(knownHostsPath, true)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = callback("10.0.0.1:22", remote, otherKey)
+				Expect(err).To(BeAssignableToTypeOf(remotemachine.HostKeyMismatchError{}))
+				Expect(err.Error()).To(ContainSubstring("host key verification failed for 10.0.0.1:22"))
+			})
+		})
+
+		Context("in strict mode", func() {
+			It("fails if the known hosts file does not exist", func() {
+				_, err := remotemachine.KnownHostsCallback(knownHostsPath, false)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("rejects unknown hosts", func() {
+				err := ioutil.WriteFile(knownHostsPath, []byte{}, 0600)
+				Expect(err).NotTo(HaveOccurred())
+
+				callback, err := remotemachine.KnownHostsCallback(knownHostsPath, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(callback("10.0.0.1:22", remote, hostKey)).NotTo(Succeed())
+			})
+		})
+
+		Context("when trusting on first use", func() {
+			It("records unknown hosts in the known hosts file", func() {
+				callback, err := remotemachine.KnownHostsCallback(knownHostsPath, true)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(callback("10.0.0.1:22", remote, hostKey)).To(Succeed())
+
+				strict, err := remotemachine.KnownHostsCallback(knownHostsPath, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(strict("10.0.0.1:22", remote, hostKey)).To(Succeed())
+			})
+
+			It("rejects a different key for a host it has just trusted", func() {
+				callback, err := remotemachine.KnownHostsCallback(knownHostsPath, true)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(callback("10.0.0.1:22", remote, hostKey)).To(Succeed())
+				Expect(callback("10.0.0.1:22", remote, otherKey)).NotTo(Succeed())
+			})
+		})
+	})
+
+	Describe("PinnedHostKeyCallback", func() {
+		It("only accepts the pinned key", func() {
+			callback := remotemachine.PinnedHostKeyCallback(hostKey)
+
+			Expect(callback("10.0.0.1:22", remote, hostKey)).To(Succeed())
+			Expect(callback("10.0.0.1:22", remote, otherKey)).To(BeAssignableToTypeOf(remotemachine.HostKeyMismatchError{}))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net"
 	"os"
 
 	"github.com/pivotal-cf/scantron"
@@ -25,10 +26,21 @@ type RemoteMachine interface {
 	Close() error
 }
 
+// ConnectionError is returned when an SSH connection to the machine, or to one
+// of its gateways, could not be established.
+type ConnectionError struct {
+	Err error
+}
+
+func (e ConnectionError) Error() string {
+	return e.Err.Error()
+}
+
 type remoteMachine struct {
 	machine scantron.Machine
 
-	conn *ssh.Client
+	conn     *ssh.Client
+	gateways []*ssh.Client
 }
 
 func NewRemoteMachine(machine scantron.Machine) RemoteMachine {
@@ -135,6 +147,14 @@ func (r *remoteMachine) auth() []ssh.AuthMethod {
 	}
 }
 
+func (r *remoteMachine) hostKeyCallback() ssh.HostKeyCallback {
+	if r.machine.HostKeyCallback != nil {
+		return r.machine.HostKeyCallback
+	}
+
+	return ssh.InsecureIgnoreHostKey()
+}
+
 func (r *remoteMachine) sshConn() (*ssh.Client, error) {
 	if r.conn != nil {
 		return r.conn, nil
@@ -143,12 +163,43 @@ func (r *remoteMachine) sshConn() (*ssh.Client, error) {
 	config := &ssh.ClientConfig{
 		User:            r.machine.Username,
 		Auth:            r.auth(),
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: r.hostKeyCallback(),
+	}
+
+	var gateway *ssh.Client
+	for _, gw := range r.machine.Gateways {
+		if gw.Key == nil {
+			r.closeGateways()
+			return nil, ConnectionError{Err: fmt.Errorf("no private key given for gateway %s", gw.Address)}
+		}
+
+		// Gateways see the credentials for every hop after them so their
+		// host keys are always checked.
+		if gw.HostKeyCallback == nil {
+			r.closeGateways()
+			return nil, ConnectionError{Err: fmt.Errorf("no way to verify the host key of gateway %s", gw.Address)}
+		}
+
+		gatewayConfig := &ssh.ClientConfig{
+			User:            gw.Username,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(gw.Key)},
+			HostKeyCallback: gw.HostKeyCallback,
+		}
+
+		client, err := dialThrough(gateway, GatewayAddress(gw.Address), gatewayConfig)
+		if err != nil {
+			r.closeGateways()
+			return nil, ConnectionError{Err: fmt.Errorf("failed to connect to gateway %s: %s", gw.Address, err)}
+		}
+
+		r.gateways = append(r.gateways, client)
+		gateway = client
 	}
 
-	conn, err := ssh.Dial("tcp", r.Address(), config)
+	conn, err := dialThrough(gateway, r.Address(), config)
 	if err != nil {
-		return nil, err
+		r.closeGateways()
+		return nil, ConnectionError{Err: err}
 	}
 
 	r.conn = conn
@@ -156,7 +207,45 @@ func (r *remoteMachine) sshConn() (*ssh.Client, error) {
 	return conn, nil
 }
 
+func dialThrough(gateway *ssh.Client, address string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	if gateway == nil {
+		return ssh.Dial("tcp", address, config)
+	}
+
+	netConn, err := gateway.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, chans, reqs, err := ssh.NewClientConn(netConn, address, config)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(conn, chans, reqs), nil
+}
+
+// GatewayAddress defaults the port of a gateway address to 22.
+func GatewayAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+
+	return net.JoinHostPort(address, "22")
+}
+
+func (r *remoteMachine) closeGateways() {
+	for i := len(r.gateways) - 1; i >= 0; i-- {
+		r.gateways[i].Close()
+	}
+
+	r.gateways = nil
+}
+
 func (r *remoteMachine) Close() error {
+	defer r.closeGateways()
+
 	if r.conn != nil {
 		return r.conn.Close()
 	}
@@ -0,0 +1,258 @@
+package remotemachine_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/remotemachine"
+)
+
+var _ = Describe("RemoteMachine", func() {
+	Describe("connecting through SSH gateways", func() {
+		var (
+			clientKey ssh.Signer
+
+			target, gateway1, gateway2 net.Listener
+
+			m         *sync.Mutex
+			forwarded []string
+		)
+
+		BeforeEach(func() {
+			clientKey = generateSigner()
+			m = &sync.Mutex{}
+			forwarded = []string{}
+
+			target = listen()
+			gateway1 = listen()
+			gateway2 = listen()
+
+			startTargetServer(target, clientKey.PublicKey())
+
+			// Gateways forward to the next hop no matter which address is
+			// requested so that the target does not need to be on port 22.
+			route := func(next net.Listener) func(string) string {
+				return func(requested string) string {
+					m.Lock()
+					defer m.Unlock()
+					forwarded = append(forwarded, requested)
+
+					return next.Addr().String()
+				}
+			}
+
+			startGatewayServer(gateway1, clientKey.PublicKey(), route(gateway2))
+			startGatewayServer(gateway2, clientKey.PublicKey(), route(target))
+		})
+
+		AfterEach(func() {
+			target.Close()
+			gateway1.Close()
+			gateway2.Close()
+		})
+
+		It("hops through each gateway in order", func() {
+			machine := remotemachine.NewRemoteMachine(scantron.Machine{
+				Address:  "10.0.0.5",
+				Username: "scanner",
+				Key:      clientKey,
+				Gateways: []scantron.Gateway{
+					{Address: gateway1.Addr().String(), Username: "jump", Key: clientKey, HostKeyCallback: ssh.InsecureIgnoreHostKey()},
+					{Address: "10.0.0.2", Username: "jump", Key: clientKey, HostKeyCallback: ssh.InsecureIgnoreHostKey()},
+				},
+			})
+			defer machine.Close()
+
+			output, err := machine.RunCommand("hostname")
+			Expect(err).NotTo(HaveOccurred())
+
+			bs, err := ioutil.ReadAll(output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(bs)).To(Equal("ran: hostname"))
+
+			Expect(forwarded).To(Equal([]string{"10.0.0.2:22", "10.0.0.5:22"}))
+		})
+
+		It("returns an error if a gateway cannot be reached", func() {
+			gateway1.Close()
+
+			machine := remotemachine.NewRemoteMachine(scantron.Machine{
+				Address:  "10.0.0.5",
+				Username: "scanner",
+				Key:      clientKey,
+				Gateways: []scantron.Gateway{
+					{Address: gateway1.Addr().String(), Username: "jump", Key: clientKey, HostKeyCallback: ssh.InsecureIgnoreHostKey()},
+				},
+			})
+			defer machine.Close()
+
+			_, err := machine.RunCommand("hostname")
+			Expect(err).To(MatchError(ContainSubstring("failed to connect to gateway")))
+		})
+
+		It("refuses to use a gateway whose host key cannot be verified", func() {
+			machine := remotemachine.NewRemoteMachine(scantron.Machine{
+				Address:  "10.0.0.5",
+				Username: "scanner",
+				Key:      clientKey,
+				Gateways: []scantron.Gateway{
+					{Address: gateway1.Addr().String(), Username: "jump", Key: clientKey},
+				},
+			})
+			defer machine.Close()
+
+			_, err := machine.RunCommand("hostname")
+			Expect(err).To(MatchError(ContainSubstring("no way to verify the host key of gateway")))
+			Expect(forwarded).To(BeEmpty())
+		})
+	})
+
+	Describe("GatewayAddress", func() {
+		It("defaults the port to 22", func() {
+			Expect(remotemachine.GatewayAddress("10.0.0.1")).To(Equal("10.0.0.1:22"))
+			Expect(remotemachine.GatewayAddress("jumpbox.example.com")).To(Equal("jumpbox.example.com:22"))
+			Expect(remotemachine.GatewayAddress("::1")).To(Equal("[::1]:22"))
+		})
+
+		It("keeps an explicit port", func() {
+			Expect(remotemachine.GatewayAddress("10.0.0.1:2222")).To(Equal("10.0.0.1:2222"))
+		})
+	})
+})
+
+func generateSigner() ssh.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	signer, err := ssh.NewSignerFromKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return signer
+}
+
+func listen() net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	return listener
+}
+
+func serverConfig(authorizedKey ssh.PublicKey) *ssh.ServerConfig {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorizedKey.Marshal()) {
+				return nil, fmt.Errorf("unknown key for %s", conn.User())
+			}
+
+			return nil, nil
+		},
+	}
+	config.AddHostKey(generateSigner())
+
+	return config
+}
+
+func serve(listener net.Listener, config *ssh.ServerConfig, handle func(ssh.NewChannel)) {
+	go func() {
+		defer GinkgoRecover()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+
+				for newChannel := range chans {
+					go handle(newChannel)
+				}
+			}()
+		}
+	}()
+}
+
+func startTargetServer(listener net.Listener, authorizedKey ssh.PublicKey) {
+	serve(listener, serverConfig(authorizedKey), func(newChannel ssh.NewChannel) {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			return
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		defer channel.Close()
+
+		for req := range requests {
+			if req.Type != "exec" {
+				req.Reply(false, nil)
+				continue
+			}
+
+			var payload struct{ Command string }
+			ssh.Unmarshal(req.Payload, &payload)
+			req.Reply(true, nil)
+
+			io.WriteString(channel, "ran: "+payload.Command)
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+			return
+		}
+	})
+}
+
+func startGatewayServer(listener net.Listener, authorizedKey ssh.PublicKey, route func(string) string) {
+	serve(listener, serverConfig(authorizedKey), func(newChannel ssh.NewChannel) {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			return
+		}
+
+		var payload struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		ssh.Unmarshal(newChannel.ExtraData(), &payload)
+
+		requested := net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port)))
+		conn, err := net.Dial("tcp", route(requested))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			return
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			conn.Close()
+			return
+		}
+		go ssh.DiscardRequests(requests)
+
+		go func() {
+			io.Copy(conn, channel)
+			conn.Close()
+		}()
+		io.Copy(channel, conn)
+		channel.Close()
+	})
+}
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+func BuildCertificateExpiryReport(database *db.Database, scanID int, warning time.Duration) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, po.number, pr.name, t.cert_common_name, t.cert_expiration
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+      JOIN ports po
+        ON po.process_id = pr.id
+      JOIN tls_certificates t
+        ON t.port_id = po.id
+    WHERE h.scan_id = ?
+    AND t.cert_expiration IS NOT NULL
+	`, scanID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:          "Certificates expiring soon or expired:",
+		Header:         []string{"Identity", "Port", "Process Name", "Common Name", "Expiration", "Days Remaining", "Status"},
+		RuleID:         "certificate-expiry",
+		Severity:       SeverityHigh,
+		LocationColumn: 1,
+	}
+
+	type certificate struct {
+		hostname    string
+		portNumber  int
+		processName string
+		commonName  string
+		expiration  time.Time
+	}
+
+	now := time.Now()
+	certificates := []certificate{}
+
+	for rows.Next() {
+		var c certificate
+
+		err := rows.Scan(&c.hostname, &c.portNumber, &c.processName, &c.commonName, &c.expiration)
+		if err != nil {
+			return Report{}, err
+		}
+
+		if c.expiration.After(now.Add(warning)) {
+			continue
+		}
+
+		certificates = append(certificates, c)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return Report{}, err
+	}
+
+	sort.SliceStable(certificates, func(i, j int) bool {
+		return certificates[i].expiration.Before(certificates[j].expiration)
+	})
+
+	for _, c := range certificates {
+		status := "expiring"
+		if !c.expiration.After(now) {
+			status = "expired"
+		}
+
+		report.Rows = append(report.Rows, []string{
+			c.hostname,
+			fmt.Sprintf("%d", c.portNumber),
+			c.processName,
+			c.commonName,
+			c.expiration.UTC().Format("2006-01-02"),
+			fmt.Sprintf("%d", daysBetween(now, c.expiration)),
+			status,
+		})
+	}
+
+	return report, nil
+}
+
+// daysBetween counts whole days, rounding towards the past so that a
+// certificate which expired an hour ago has -1 days remaining.
+func daysBetween(from, to time.Time) int {
+	return int(math.Floor(to.Sub(from).Hours() / 24))
+}
@@ -0,0 +1,64 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildCertificateExpiryReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows certificates which have expired or expire within the warning window", func() {
+		r, err := report.BuildCertificateExpiryReport(database, scan.ID, 30*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Certificates expiring soon or expired:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Port", "Process Name", "Common Name", "Expiration", "Days Remaining", "Status"}))
+
+		expired := time.Now().AddDate(0, 0, -10).Add(time.Hour).UTC().Format("2006-01-02")
+		expiring := time.Now().AddDate(0, 0, 5).Add(time.Hour).UTC().Format("2006-01-02")
+
+		Expect(r.Rows).To(Equal([][]string{
+			{"host3", "7890", "command1", "host3.example.com", expired, "-10", "expired"},
+			{"host1", "7890", "command1", "host1.example.com", expiring, "5", "expiring"},
+		}))
+	})
+
+	It("only shows expired certificates when the warning window is empty", func() {
+		r, err := report.BuildCertificateExpiryReport(database, scan.ID, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Rows).To(HaveLen(1))
+		Expect(r.Rows[0][0]).To(Equal("host3"))
+	})
+})
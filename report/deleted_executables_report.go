@@ -0,0 +1,57 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+func BuildDeletedExecutablesReport(database *db.Database, scanID int) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT h.name, pr.name, pr.pid, pr.executable
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+    WHERE h.scan_id = ?
+      AND pr.executable_deleted
+    ORDER BY h.name, pr.executable, pr.pid
+	`, scanID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:          "Processes running deleted executables:",
+		Header:         []string{"Identity", "Process Name", "PID", "Executable"},
+		Footnote:       "The executable was removed or replaced after the process started, usually by an upgrade which did not restart it.",
+		RuleID:         "deleted-executable",
+		Severity:       SeverityMedium,
+		LocationColumn: 3,
+		KeyColumns:     []int{2},
+	}
+
+	for rows.Next() {
+		var (
+			hostname    string
+			processName string
+			pid         int
+			executable  string
+		)
+
+		err := rows.Scan(&hostname, &processName, &pid, &executable)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			processName,
+			fmt.Sprintf("%d", pid),
+			executable,
+		})
+	}
+
+	return report, nil
+}
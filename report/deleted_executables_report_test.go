@@ -0,0 +1,51 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildDeletedExecutablesReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows processes whose executable was deleted", func() {
+		r, err := report.BuildDeletedExecutablesReport(database, scan.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Processes running deleted executables:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Process Name", "PID", "Executable"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host1", "rpcbind", "812", "/sbin/rpcbind"},
+			{"host2", "some-non-root-process", "2001", "/var/vcap/packages/app/bin/app"},
+		}))
+	})
+})
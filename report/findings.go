@@ -0,0 +1,93 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Finding struct {
+	ID       string            `json:"id"`
+	RuleID   string            `json:"rule_id"`
+	Rule     string            `json:"rule"`
+	Severity string            `json:"severity"`
+	Host     string            `json:"host"`
+	Location string            `json:"location,omitempty"`
+	Message  string            `json:"message"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// Name identifies what the finding is about: the host, followed by the port
+// or path if there is one.
+func (f Finding) Name() string {
+	if f.Location == "" {
+		return f.Host
+	}
+
+	return f.Host + ":" + f.Location
+}
+
+func (r Report) Rule() string {
+	return strings.TrimSuffix(r.Title, ":")
+}
+
+func (r Report) Findings() []Finding {
+	findings := []Finding{}
+
+	for _, row := range r.Rows {
+		finding := Finding{
+			RuleID:   r.RuleID,
+			Rule:     r.Rule(),
+			Severity: r.Severity,
+			Host:     row[0],
+		}
+
+		if r.LocationColumn > 0 {
+			finding.Location = row[r.LocationColumn]
+		}
+
+		id := []string{finding.Name()}
+		for _, column := range r.KeyColumns {
+			id = append(id, row[column])
+		}
+		finding.ID = strings.Join(id, ":")
+
+		details := []string{}
+		for i := 1; i < len(row) && i < len(r.Header); i++ {
+			if i == r.LocationColumn || row[i] == "" {
+				continue
+			}
+
+			if finding.Details == nil {
+				finding.Details = map[string]string{}
+			}
+
+			finding.Details[r.Header[i]] = row[i]
+			details = append(details, fmt.Sprintf("%s: %s", r.Header[i], row[i]))
+		}
+
+		finding.Message = fmt.Sprintf("%s on %s", finding.Rule, finding.Name())
+		if len(details) > 0 {
+			finding.Message += " (" + strings.Join(details, ", ") + ")"
+		}
+
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+func WriteJSON(writer io.Writer, reports []Report) error {
+	findings := []Finding{}
+	for _, r := range reports {
+		findings = append(findings, r.Findings()...)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(struct {
+		Findings []Finding `json:"findings"`
+	}{findings})
+}
@@ -0,0 +1,207 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("Findings", func() {
+	var reports []report.Report
+
+	BeforeEach(func() {
+		reports = []report.Report{
+			{
+				Title:          "Externally-accessible processes running as root:",
+				Header:         []string{"Identity", "Port", "Process Name"},
+				Rows:           [][]string{{"host1", "7890", "command1"}},
+				RuleID:         "root-process",
+				Severity:       report.SeverityHigh,
+				LocationColumn: 1,
+			},
+			{
+				Title:    "Duplicate SSH keys:",
+				Header:   []string{"Identity"},
+				RuleID:   "duplicate-ssh-key",
+				Severity: report.SeverityMedium,
+			},
+		}
+	})
+
+	It("has a finding for each row", func() {
+		Expect(reports[0].Findings()).To(Equal([]report.Finding{
+			{
+				ID:       "host1:7890",
+				RuleID:   "root-process",
+				Rule:     "Externally-accessible processes running as root",
+				Severity: "high",
+				Host:     "host1",
+				Location: "7890",
+				Message:  "Externally-accessible processes running as root on host1:7890 (Process Name: command1)",
+				Details:  map[string]string{"Process Name": "command1"},
+			},
+		}))
+
+		Expect(reports[1].Findings()).To(BeEmpty())
+	})
+
+	It("tells apart findings with the same host and location by their key columns", func() {
+		r := report.Report{
+			Title:          "Processes running deleted executables:",
+			Header:         []string{"Identity", "Process Name", "PID", "Executable"},
+			Rows:           [][]string{{"host1", "app", "100", "/bin/app"}, {"host1", "app", "200", "/bin/app"}},
+			RuleID:         "deleted-executable",
+			Severity:       report.SeverityMedium,
+			LocationColumn: 3,
+			KeyColumns:     []int{2},
+		}
+
+		findings := r.Findings()
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].Name()).To(Equal("host1:/bin/app"))
+		Expect(findings[0].ID).To(Equal("host1:/bin/app:100"))
+		Expect(findings[1].ID).To(Equal("host1:/bin/app:200"))
+
+		buffer := &bytes.Buffer{}
+		err := report.WriteSARIF(buffer, []report.Report{r})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buffer.String()).To(ContainSubstring(`"scantron/v1": "deleted-executable:host1:/bin/app:100"`))
+		Expect(buffer.String()).To(ContainSubstring(`"scantron/v1": "deleted-executable:host1:/bin/app:200"`))
+
+		buffer.Reset()
+		err = report.WriteJUnit(buffer, []report.Report{r})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buffer.String()).To(ContainSubstring(`name="host1:/bin/app:100"`))
+		Expect(buffer.String()).To(ContainSubstring(`name="host1:/bin/app:200"`))
+	})
+
+	It("writes the findings as json", func() {
+		buffer := &bytes.Buffer{}
+
+		err := report.WriteJSON(buffer, reports)
+		Expect(err).NotTo(HaveOccurred())
+
+		var output struct {
+			Findings []report.Finding `json:"findings"`
+		}
+
+		err = json.Unmarshal(buffer.Bytes(), &output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Findings).To(Equal(reports[0].Findings()))
+	})
+
+	It("writes the findings as sarif", func() {
+		buffer := &bytes.Buffer{}
+
+		err := report.WriteSARIF(buffer, reports)
+		Expect(err).NotTo(HaveOccurred())
+
+		var output struct {
+			Version string `json:"version"`
+			Runs    []struct {
+				Tool struct {
+					Driver struct {
+						Name  string `json:"name"`
+						Rules []struct {
+							ID                   string `json:"id"`
+							DefaultConfiguration struct {
+								Level string `json:"level"`
+							} `json:"defaultConfiguration"`
+						} `json:"rules"`
+					} `json:"driver"`
+				} `json:"tool"`
+				Results []struct {
+					RuleID    string `json:"ruleId"`
+					Level     string `json:"level"`
+					Locations []struct {
+						LogicalLocations []struct {
+							Name string `json:"name"`
+						} `json:"logicalLocations"`
+					} `json:"locations"`
+				} `json:"results"`
+			} `json:"runs"`
+		}
+
+		err = json.Unmarshal(buffer.Bytes(), &output)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(output.Version).To(Equal("2.1.0"))
+		Expect(output.Runs).To(HaveLen(1))
+
+		run := output.Runs[0]
+		Expect(run.Tool.Driver.Name).To(Equal("scantron"))
+		Expect(run.Tool.Driver.Rules).To(HaveLen(2))
+		Expect(run.Tool.Driver.Rules[0].ID).To(Equal("root-process"))
+		Expect(run.Tool.Driver.Rules[0].DefaultConfiguration.Level).To(Equal("error"))
+		Expect(run.Tool.Driver.Rules[1].ID).To(Equal("duplicate-ssh-key"))
+		Expect(run.Tool.Driver.Rules[1].DefaultConfiguration.Level).To(Equal("warning"))
+
+		Expect(run.Results).To(HaveLen(1))
+		Expect(run.Results[0].RuleID).To(Equal("root-process"))
+		Expect(run.Results[0].Level).To(Equal("error"))
+		Expect(run.Results[0].Locations[0].LogicalLocations[0].Name).To(Equal("host1:7890"))
+	})
+
+	It("writes the findings as junit xml", func() {
+		buffer := &bytes.Buffer{}
+
+		err := report.WriteJUnit(buffer, reports)
+		Expect(err).NotTo(HaveOccurred())
+
+		var output struct {
+			Tests    int `xml:"tests,attr"`
+			Failures int `xml:"failures,attr"`
+			Suites   []struct {
+				Name  string `xml:"name,attr"`
+				Cases []struct {
+					ClassName string `xml:"classname,attr"`
+					Name      string `xml:"name,attr"`
+					Failure   *struct {
+						Type string `xml:"type,attr"`
+					} `xml:"failure"`
+				} `xml:"testcase"`
+			} `xml:"testsuite"`
+		}
+
+		err = xml.Unmarshal(buffer.Bytes(), &output)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(output.Tests).To(Equal(2))
+		Expect(output.Failures).To(Equal(1))
+		Expect(output.Suites).To(HaveLen(2))
+
+		Expect(output.Suites[0].Name).To(Equal("Externally-accessible processes running as root"))
+		Expect(output.Suites[0].Cases).To(HaveLen(1))
+		Expect(output.Suites[0].Cases[0].ClassName).To(Equal("root-process"))
+		Expect(output.Suites[0].Cases[0].Name).To(Equal("host1:7890"))
+		Expect(output.Suites[0].Cases[0].Failure.Type).To(Equal("high"))
+
+		Expect(output.Suites[1].Cases).To(HaveLen(1))
+		Expect(output.Suites[1].Cases[0].Failure).To(BeNil())
+	})
+
+	It("writes the reports as markdown", func() {
+		buffer := &bytes.Buffer{}
+
+		for _, r := range reports {
+			r.WriteMarkdown(buffer)
+		}
+
+		Expect(buffer.String()).To(Equal(`### Externally-accessible processes running as root
+
+| Identity | Port | Process Name |
+| --- | --- | --- |
+| host1 | 7890 | command1 |
+
+### Duplicate SSH keys
+
+None found.
+
+`))
+	})
+})
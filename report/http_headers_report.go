@@ -0,0 +1,92 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+func BuildHTTPHeadersReport(database *db.Database, scanID int) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, po.number, pr.name, e.tls, e.hsts, e.server, e.powered_by, e.redirects_to_https
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+      JOIN ports po
+        ON po.process_id = pr.id
+      JOIN http_endpoints e
+        ON e.port_id = po.id
+    WHERE h.scan_id = ?
+    ORDER BY h.name, po.number
+	`, scanID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:          "HTTP endpoints missing security headers:",
+		Header:         []string{"Identity", "Port", "Process Name", "Scheme", "Problem(s)"},
+		Footnote:       "HTTPS endpoints should send a Strict-Transport-Security header and plain HTTP endpoints should redirect to HTTPS. The Server and X-Powered-By headers should not give away what software, or which version of it, is running.",
+		RuleID:         "http-security-headers",
+		Severity:       SeverityLow,
+		LocationColumn: 1,
+	}
+
+	for rows.Next() {
+		var (
+			hostname         string
+			portNumber       int
+			processName      string
+			tls              bool
+			hsts             string
+			server           string
+			poweredBy        string
+			redirectsToHTTPS bool
+		)
+
+		err := rows.Scan(&hostname, &portNumber, &processName, &tls, &hsts, &server, &poweredBy, &redirectsToHTTPS)
+		if err != nil {
+			return Report{}, err
+		}
+
+		scheme := "http"
+		problems := []string{}
+
+		if tls {
+			scheme = "https"
+
+			if hsts == "" {
+				problems = append(problems, "no Strict-Transport-Security header")
+			}
+		} else if !redirectsToHTTPS {
+			problems = append(problems, "does not redirect to HTTPS")
+		}
+
+		// A bare product name is common and harmless; a version number tells
+		// an attacker which vulnerabilities to try.
+		if strings.ContainsAny(server, "0123456789") {
+			problems = append(problems, fmt.Sprintf("Server: %s", server))
+		}
+
+		if poweredBy != "" {
+			problems = append(problems, fmt.Sprintf("X-Powered-By: %s", poweredBy))
+		}
+
+		if len(problems) == 0 {
+			continue
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			fmt.Sprintf("%d", portNumber),
+			processName,
+			scheme,
+			strings.Join(problems, ", "),
+		})
+	}
+
+	return report, rows.Err()
+}
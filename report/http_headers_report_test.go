@@ -0,0 +1,51 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildHTTPHeadersReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows HTTP endpoints without HSTS or a redirect to HTTPS, or which give away their software", func() {
+		r, err := report.BuildHTTPHeadersReport(database, scan.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("HTTP endpoints missing security headers:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Port", "Process Name", "Scheme", "Problem(s)"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host1", "7890", "command1", "https", "no Strict-Transport-Security header, Server: nginx/1.18.0"},
+			{"host2", "12345", "some-non-root-process", "http", "does not redirect to HTTPS, X-Powered-By: PHP/7.4.3"},
+		}))
+	})
+})
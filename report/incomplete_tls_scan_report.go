@@ -0,0 +1,86 @@
+package report
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+func BuildIncompleteTLSScanReport(database *db.Database, scanID int) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT h.name, po.number, pr.name, e.protocol, e.cert_scan_error
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+      JOIN ports po
+        ON po.process_id = pr.id
+      JOIN tls_scan_errors e
+        ON e.port_id = po.id
+    WHERE h.scan_id = ?
+    ORDER BY h.name, po.number, e.id
+	`, scanID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:          "Ports with incomplete TLS scans:",
+		Header:         []string{"Identity", "Port", "Process Name", "Error(s)"},
+		Footnote:       "The TLS settings of these ports could not all be checked, so the other TLS sections may be missing problems with them. Try scanning them again.",
+		RuleID:         "incomplete-tls-scan",
+		Severity:       SeverityMedium,
+		LocationColumn: 1,
+	}
+
+	type port struct {
+		hostname    string
+		portNumber  int
+		processName string
+	}
+
+	var ports []port
+	var portErrors = map[port][]string{}
+
+	for rows.Next() {
+		var (
+			p        port
+			protocol sql.NullString
+			scanErr  string
+		)
+
+		err := rows.Scan(&p.hostname, &p.portNumber, &p.processName, &protocol, &scanErr)
+		if err != nil {
+			return Report{}, err
+		}
+
+		// Errors without a protocol are from fetching the certificate.
+		if protocol.Valid {
+			scanErr = fmt.Sprintf("%s: %s", protocol.String, scanErr)
+		}
+
+		if _, ok := portErrors[p]; !ok {
+			ports = append(ports, p)
+		}
+		portErrors[p] = append(portErrors[p], scanErr)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return Report{}, err
+	}
+
+	for _, p := range ports {
+		report.Rows = append(report.Rows, []string{
+			p.hostname,
+			fmt.Sprintf("%d", p.portNumber),
+			p.processName,
+			strings.Join(portErrors[p], "; "),
+		})
+	}
+
+	return report, nil
+}
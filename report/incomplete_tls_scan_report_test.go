@@ -0,0 +1,51 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildIncompleteTLSScanReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows ports whose cipher suites or certificate could not all be scanned", func() {
+		r, err := report.BuildIncompleteTLSScanReport(database, scan.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Ports with incomplete TLS scans:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Port", "Process Name", "Error(s)"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host1", "8890", "command1", "VersionTLS12: read: connection reset by peer; VersionTLS13: i/o timeout"},
+			{"winhost1", "19999", "command2.exe", "tls: handshake timeout"},
+		}))
+	})
+})
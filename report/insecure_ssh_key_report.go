@@ -2,17 +2,21 @@ package report
 
 import "github.com/pivotal-cf/scantron/db"
 
-func BuildInsecureSshKeyReport(database *db.Database) (Report, error) {
+func BuildInsecureSshKeyReport(database *db.Database, scanID int) (Report, error) {
 	rows, err := database.DB().Query(`
-    SELECT h.name
+    SELECT DISTINCT h.name
     FROM ssh_keys s1
       CROSS JOIN ssh_keys s2
       JOIN hosts h
         ON s1.host_id = h.id
+      JOIN hosts h2
+        ON s2.host_id = h2.id
     WHERE s1.key = s2.key
       AND s1.id != s2.id
+      AND h.scan_id = ?
+      AND h2.scan_id = h.scan_id
     ORDER BY h.name
-    `)
+    `, scanID)
 	if err != nil {
 		return Report{}, err
 	}
@@ -20,8 +24,10 @@ func BuildInsecureSshKeyReport(database *db.Database) (Report, error) {
 	defer rows.Close()
 
 	report := Report{
-		Title:  "Duplicate SSH keys:",
-		Header: []string{"Identity"},
+		Title:    "Duplicate SSH keys:",
+		Header:   []string{"Identity"},
+		RuleID:   "duplicate-ssh-key",
+		Severity: SeverityMedium,
 	}
 
 	for rows.Next() {
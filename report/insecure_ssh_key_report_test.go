@@ -16,6 +16,7 @@ var _ = Describe("BuildInsecureSshKeyReport", func() {
 	var (
 		databasePath, tmpdir string
 		database             *db.Database
+		scan                 db.Scan
 	)
 
 	BeforeEach(func() {
@@ -24,7 +25,7 @@ var _ = Describe("BuildInsecureSshKeyReport", func() {
 		Expect(err).NotTo(HaveOccurred())
 		databasePath = filepath.Join(tmpdir, "db.db")
 
-		database, err = createTestDatabase(databasePath)
+		database, scan, err = createTestDatabase(databasePath)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -37,7 +38,7 @@ var _ = Describe("BuildInsecureSshKeyReport", func() {
 	})
 
 	It("shows insecure and duplicate ssh keys", func() {
-		r, err := report.BuildInsecureSshKeyReport(database)
+		r, err := report.BuildInsecureSshKeyReport(database, scan.ID)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(r.Title).To(Equal("Duplicate SSH keys:"))
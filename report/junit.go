@@ -0,0 +1,86 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the reports as JUnit XML so that CI systems can show the
+// findings as failed tests. Each report is a test suite with a failed test
+// case for every finding, or a single passing test case if it has none.
+func WriteJUnit(writer io.Writer, reports []Report) error {
+	suites := junitTestSuites{Name: "scantron"}
+
+	for _, r := range reports {
+		suite := junitTestSuite{Name: r.Rule()}
+
+		for _, finding := range r.Findings() {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				ClassName: finding.RuleID,
+				Name:      finding.ID,
+				Failure: &junitFailure{
+					Message: finding.Message,
+					Type:    finding.Severity,
+					Text:    finding.Message,
+				},
+			})
+			suite.Failures++
+		}
+
+		if len(suite.Cases) == 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				ClassName: r.RuleID,
+				Name:      r.Rule(),
+			})
+		}
+
+		suite.Tests = len(suite.Cases)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	_, err := io.WriteString(writer, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+
+	err = encoder.Encode(suites)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(writer, "\n")
+	return err
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+// BuildPrivilegedProcessesReport lists processes which do not run as root but
+// hold capabilities, which can give them much of root's power. Binding to a
+// port below 1024 is left out since non-root servers commonly need it.
+func BuildPrivilegedProcessesReport(database *db.Database, scanID int) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT h.name, pr.id, pr.name, pr.pid, pr.user, c.name
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+      JOIN process_capabilities c
+        ON c.process_id = pr.id
+    WHERE h.scan_id = ?
+      AND pr.user NOT IN ("root", "SYSTEM")
+      AND c.name != "CAP_NET_BIND_SERVICE"
+    ORDER BY h.name, pr.name, pr.id, c.name
+	`, scanID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:      "Privileged non-root processes:",
+		Header:     []string{"Identity", "Process Name", "PID", "User", "Capabilities"},
+		Footnote:   "Capabilities which are permitted but not effective can be made effective by the process at any time.",
+		RuleID:     "privileged-non-root-process",
+		Severity:   SeverityHigh,
+		KeyColumns: []int{1, 2},
+	}
+
+	type privilegedProcess struct {
+		hostname     string
+		name         string
+		pid          int
+		user         string
+		capabilities []string
+	}
+
+	processes := []*privilegedProcess{}
+	byID := map[int]*privilegedProcess{}
+
+	for rows.Next() {
+		var (
+			hostname    string
+			processID   int
+			processName string
+			pid         int
+			user        string
+			capability  string
+		)
+
+		err := rows.Scan(&hostname, &processID, &processName, &pid, &user, &capability)
+		if err != nil {
+			return Report{}, err
+		}
+
+		process, ok := byID[processID]
+		if !ok {
+			process = &privilegedProcess{hostname: hostname, name: processName, pid: pid, user: user}
+			byID[processID] = process
+			processes = append(processes, process)
+		}
+
+		// Capabilities are listed once for each set they are in.
+		n := len(process.capabilities)
+		if n == 0 || process.capabilities[n-1] != capability {
+			process.capabilities = append(process.capabilities, capability)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return Report{}, err
+	}
+
+	for _, process := range processes {
+		report.Rows = append(report.Rows, []string{
+			process.hostname,
+			process.name,
+			fmt.Sprintf("%d", process.pid),
+			process.user,
+			strings.Join(process.capabilities, ", "),
+		})
+	}
+
+	return report, nil
+}
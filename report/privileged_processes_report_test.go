@@ -0,0 +1,50 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildPrivilegedProcessesReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows non-root processes which hold capabilities", func() {
+		r, err := report.BuildPrivilegedProcessesReport(database, scan.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Privileged non-root processes:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Process Name", "PID", "User", "Capabilities"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host2", "some-non-root-process", "2001", "vcap", "CAP_NET_ADMIN, CAP_SYS_ADMIN"},
+		}))
+	})
+})
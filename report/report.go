@@ -3,15 +3,33 @@ package report
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/olekukonko/tablewriter"
 )
 
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
 type Report struct {
 	Header   []string
 	Rows     [][]string
 	Title    string
 	Footnote string
+
+	// RuleID and Severity are attached to every finding in the report when it
+	// is written in a machine-readable format. LocationColumn is the index of
+	// the column holding the port or path of each finding; 0 means the
+	// findings have no location beyond the host in the Identity column.
+	// KeyColumns are the other columns which tell apart findings with the
+	// same host and location, such as the PID of a process.
+	RuleID         string
+	Severity       string
+	LocationColumn int
+	KeyColumns     []int
 }
 
 func (r Report) IsEmpty() bool {
@@ -34,3 +52,30 @@ func (r Report) WriteTo(writer io.Writer) {
 
 	fmt.Println("")
 }
+
+func (r Report) WriteMarkdown(writer io.Writer) {
+	fmt.Fprintf(writer, "### %s\n\n", strings.TrimSuffix(r.Title, ":"))
+
+	if r.IsEmpty() {
+		fmt.Fprintf(writer, "None found.\n\n")
+		return
+	}
+
+	fmt.Fprintf(writer, "| %s |\n", strings.Join(r.Header, " | "))
+	fmt.Fprintf(writer, "|%s\n", strings.Repeat(" --- |", len(r.Header)))
+
+	for _, row := range r.Rows {
+		cells := []string{}
+		for _, cell := range row {
+			cells = append(cells, strings.Replace(cell, "|", "\\|", -1))
+		}
+
+		fmt.Fprintf(writer, "| %s |\n", strings.Join(cells, " | "))
+	}
+
+	fmt.Fprintln(writer)
+
+	if r.Footnote != "" {
+		fmt.Fprintf(writer, "%s\n\n", r.Footnote)
+	}
+}
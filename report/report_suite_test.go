@@ -5,6 +5,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"testing"
+	"time"
 
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/db"
@@ -16,7 +17,12 @@ func TestReport(t *testing.T) {
 	RunSpecs(t, "Report Suite")
 }
 
-func createTestDatabase(databasePath string) (*db.Database, error) {
+func createTestDatabase(databasePath string) (*db.Database, db.Scan, error) {
+	expiredExpiration := time.Now().AddDate(0, 0, -10).Add(time.Hour)
+	expiringExpiration := time.Now().AddDate(0, 0, 5).Add(time.Hour)
+	validExpiration := time.Now().AddDate(1, 0, 0)
+	serverOrder, clientOrder := true, false
+
 	hosts := scanner.ScanResult{
 		JobResults: []scanner.JobResult{
 			{
@@ -41,10 +47,25 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 						Key:  "SSH KEY 1",
 					},
 				},
+				SSHConfiguration: &scantron.SSHConfiguration{
+					Banner:       "SSH-2.0-OpenSSH_5.3",
+					KeyExchanges: []string{"curve25519-sha256", "diffie-hellman-group14-sha1", "diffie-hellman-group1-sha1"},
+					HostKeys:     []string{"ssh-ed25519", "ssh-dss"},
+					Ciphers:      []string{"aes256-ctr", "aes128-cbc", "arcfour"},
+					MACs:         []string{"hmac-sha2-256", "hmac-sha2-256-etm@openssh.com"},
+				},
 				Services: []scantron.Process{
 					{
-						CommandName: "command1",
-						User:        "root",
+						CommandName:           "command1",
+						User:                  "root",
+						EffectiveCapabilities: []string{"CAP_SYS_ADMIN"},
+						PermittedCapabilities: []string{"CAP_SYS_ADMIN"},
+						UnixSockets: []scantron.UnixSocket{
+							{Path: "/var/vcap/data/sys/run/command1.sock", Type: "stream", Permissions: 0666, User: "root", Group: "root"},
+						},
+						RawSockets: []scantron.RawSocket{
+							{Family: "packet", Protocol: 0x0003},
+						},
 						Ports: []scantron.Port{
 							{
 								State:          "LISTEN",
@@ -53,10 +74,16 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
 								TLSInformation: &scantron.TLSInformation{
-									Certificate: &scantron.Certificate{},
+									Certificate: &scantron.Certificate{
+										Expiration: expiredExpiration,
+										Bits:       1024,
+										KeyType:    "RSA",
+										Subject:    scantron.CertificateSubject{CommonName: "host3.example.com"},
+									},
 									CipherInformation: scantron.CipherInformation{
 										"VersionSSL30": []string{"Just the worst"},
 									},
+									ServerCipherPreference: &clientOrder,
 								},
 							},
 						},
@@ -85,6 +112,12 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 					{
 						CommandName: "command2",
 						User:        "root",
+						UnixSockets: []scantron.UnixSocket{
+							{Path: "/var/run/docker.sock", Type: "stream", Permissions: 0660, User: "root", Group: "docker"},
+							{Path: "/var/vcap/sys/run/command2/private.sock", Type: "stream", Permissions: 0660, User: "root", Group: "root"},
+							{Path: "/var/vcap/sys/run/command2/vcap.sock", Type: "stream", Permissions: 0600, User: "vcap", Group: "vcap"},
+							{Path: "@command2", Type: "stream"},
+						},
 						Ports: []scantron.Port{
 							{
 								State:          "LISTEN",
@@ -92,6 +125,10 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								Number:         19999,
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
+								HTTPInformation: &scantron.HTTPInformation{
+									StatusCode:       301,
+									RedirectsToHTTPS: true,
+								},
 							},
 						},
 					},
@@ -106,10 +143,21 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
 								TLSInformation: &scantron.TLSInformation{
-									Certificate: &scantron.Certificate{},
+									Certificate: &scantron.Certificate{
+										Expiration: expiringExpiration,
+										Bits:       256,
+										KeyType:    "ECDSA",
+										Subject:    scantron.CertificateSubject{CommonName: "host1.example.com"},
+									},
 									CipherInformation: scantron.CipherInformation{
 										"VersionSSL30": []string{"TLS_DHE_RSA_WITH_AES_128_GCM_SHA256"},
 									},
+									ServerCipherPreference: &clientOrder,
+								},
+								HTTPInformation: &scantron.HTTPInformation{
+									TLS:        true,
+									StatusCode: 200,
+									Server:     "nginx/1.18.0",
 								},
 							},
 							{
@@ -126,10 +174,19 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
 								TLSInformation: &scantron.TLSInformation{
-									Certificate: &scantron.Certificate{},
+									Certificate: &scantron.Certificate{
+										Expiration: validExpiration,
+										Bits:       224,
+										KeyType:    "ECDSA",
+									},
 									CipherInformation: scantron.CipherInformation{
 										"VersionTLS12": []string{"Bad Cipher"},
 									},
+									ServerCipherPreference: &clientOrder,
+									ProbeErrors: []scantron.ProbeError{
+										{Protocol: "VersionTLS12", Error: "read: connection reset by peer"},
+										{Protocol: "VersionTLS13", Error: "i/o timeout"},
+									},
 								},
 							},
 							{
@@ -142,7 +199,9 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 					},
 					{
 						CommandName: "sshd",
+						PID:         700,
 						User:        "root",
+						Executable:  "/usr/sbin/sshd",
 						Ports: []scantron.Port{
 							{
 								State:          "LISTEN",
@@ -154,8 +213,17 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 						},
 					},
 					{
-						CommandName: "rpcbind",
-						User:        "root",
+						CommandName:           "haproxy",
+						User:                  "vcap",
+						EffectiveCapabilities: []string{"CAP_NET_BIND_SERVICE"},
+						PermittedCapabilities: []string{"CAP_NET_BIND_SERVICE"},
+					},
+					{
+						CommandName:       "rpcbind",
+						PID:               812,
+						User:              "root",
+						Executable:        "/sbin/rpcbind",
+						ExecutableDeleted: true,
 						Ports: []scantron.Port{
 							{
 								State:          "LISTEN",
@@ -176,6 +244,13 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 						Key:  "SSH KEY 2",
 					},
 				},
+				SSHConfiguration: &scantron.SSHConfiguration{
+					Banner:       "SSH-2.0-OpenSSH_8.9",
+					KeyExchanges: []string{"curve25519-sha256", "ext-info-s"},
+					HostKeys:     []string{"ssh-ed25519", "rsa-sha2-512"},
+					Ciphers:      []string{"chacha20-poly1305@openssh.com", "aes256-gcm@openssh.com"},
+					MACs:         []string{"hmac-sha2-512-etm@openssh.com", "umac-128-etm@openssh.com"},
+				},
 				Services: []scantron.Process{
 					{
 						CommandName: "command2",
@@ -188,17 +263,38 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
 								TLSInformation: &scantron.TLSInformation{
-									Certificate: &scantron.Certificate{},
+									Certificate: &scantron.Certificate{
+										Expiration: validExpiration,
+										Bits:       256,
+										KeyType:    "Ed25519",
+									},
 									CipherInformation: scantron.CipherInformation{
 										"VersionTLS12": []string{"TLS_DHE_RSA_WITH_AES_128_GCM_SHA256"},
+										"VersionTLS13": []string{"TLS_AES_128_GCM_SHA256"},
 									},
+									ServerCipherPreference: &serverOrder,
+									KeyExchange:            scantron.KeyExchange{DHBits: 1024},
+								},
+								HTTPInformation: &scantron.HTTPInformation{
+									TLS:        true,
+									StatusCode: 200,
+									HSTS:       "max-age=31536000",
+									Server:     "nginx",
 								},
 							},
 						},
 					},
 					{
-						CommandName: "some-non-root-process",
-						User:        "vcap",
+						CommandName:           "some-non-root-process",
+						PID:                   2001,
+						User:                  "vcap",
+						EffectiveCapabilities: []string{"CAP_NET_BIND_SERVICE", "CAP_NET_ADMIN"},
+						PermittedCapabilities: []string{"CAP_NET_BIND_SERVICE", "CAP_NET_ADMIN", "CAP_SYS_ADMIN"},
+						Executable:            "/var/vcap/packages/app/bin/app",
+						ExecutableDeleted:     true,
+						UnixSockets: []scantron.UnixSocket{
+							{Path: "/var/vcap/sys/run/app/app.sock", Type: "stream", Permissions: 0600, User: "vcap", Group: "vcap"},
+						},
 						Ports: []scantron.Port{
 							{
 								State:          "LISTEN",
@@ -206,6 +302,10 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								Number:         12345,
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
+								HTTPInformation: &scantron.HTTPInformation{
+									StatusCode: 200,
+									PoweredBy:  "PHP/7.4.3",
+								},
 							},
 						},
 					},
@@ -231,10 +331,16 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
 								TLSInformation: &scantron.TLSInformation{
-									Certificate: &scantron.Certificate{},
+									Certificate: &scantron.Certificate{
+										Expiration: validExpiration,
+										Bits:       2048,
+										KeyType:    "RSA",
+									},
 									CipherInformation: scantron.CipherInformation{
 										"VersionTLS12": []string{"TLS_DHE_RSA_WITH_AES_128_GCM_SHA256"},
 									},
+									ServerCipherPreference: &serverOrder,
+									KeyExchange:            scantron.KeyExchange{DHBits: 2048},
 								},
 							},
 						},
@@ -250,10 +356,11 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
 								TLSInformation: &scantron.TLSInformation{
-									Certificate: &scantron.Certificate{},
+									Certificate: &scantron.Certificate{Expiration: validExpiration},
 									CipherInformation: scantron.CipherInformation{
 										"VersionTLS12": []string{"TLS_DHE_RSA_WITH_AES_128_GCM_SHA256"},
 									},
+									ScanError: "tls: handshake timeout",
 								},
 							},
 						},
@@ -274,17 +381,41 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 				},
 			},
 		},
+		Failures: []scanner.FailureResult{
+			{
+				Job:   "host5",
+				IP:    "10.0.5.27",
+				Stage: scanner.StageRun,
+				Error: "sudo: incorrect password",
+			},
+			{
+				Job:   "host4",
+				IP:    "10.0.5.26",
+				Stage: scanner.StageConnect,
+				Error: "connection refused",
+			},
+		},
 	}
 
 	database, err := db.CreateDatabase(databasePath)
 	if err != nil {
-		return nil, err
+		return nil, db.Scan{}, err
+	}
+
+	scan, err := database.StartScan("scantron bosh-scan", "operator")
+	if err != nil {
+		return nil, db.Scan{}, err
+	}
+
+	err = database.SaveReport(scan.ID, "cf1", hosts)
+	if err != nil {
+		return nil, db.Scan{}, err
 	}
 
-	err = database.SaveReport("cf1", hosts)
+	err = database.FinishScan(scan.ID)
 	if err != nil {
-		return nil, err
+		return nil, db.Scan{}, err
 	}
 
-	return database, nil
+	return database, scan, nil
 }
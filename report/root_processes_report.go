@@ -5,7 +5,7 @@ import (
 	"github.com/pivotal-cf/scantron/db"
 )
 
-func BuildRootProcessesReport(database *db.Database) (Report, error) {
+func BuildRootProcessesReport(database *db.Database, scanID int) (Report, error) {
 	rows, err := database.DB().Query(`
 	SELECT DISTINCT h.name, po.number, pr.name
     FROM hosts h
@@ -13,14 +13,15 @@ func BuildRootProcessesReport(database *db.Database) (Report, error) {
         ON h.id = pr.host_id
       JOIN ports po
         ON po.process_id = pr.id
-	WHERE (upper(po.state) = "LISTEN" OR po.state = "Bound")
+	WHERE h.scan_id = ?
+    AND (upper(po.state) = "LISTEN" OR po.state = "Bound")
     AND po.address != "127.0.0.1" 
     AND po.address NOT LIKE "172.%"
     AND po.address NOT LIKE "169.%"
     AND (pr.user = "root" OR pr.user = "SYSTEM")
     AND pr.name NOT IN ('sshd', 'rpcbind')
     ORDER BY h.name, po.number
-	`)
+	`, scanID)
 	if err != nil {
 		return Report{}, err
 	}
@@ -28,8 +29,11 @@ func BuildRootProcessesReport(database *db.Database) (Report, error) {
 	defer rows.Close()
 
 	report := Report{
-		Title:  "Externally-accessible processes running as root:",
-		Header: []string{"Identity", "Port", "Process Name"},
+		Title:          "Externally-accessible processes running as root:",
+		Header:         []string{"Identity", "Port", "Process Name"},
+		RuleID:         "root-process",
+		Severity:       SeverityHigh,
+		LocationColumn: 1,
 	}
 
 	for rows.Next() {
@@ -16,6 +16,7 @@ var _ = Describe("BuildRootProcessesReport", func() {
 	var (
 		databasePath, tmpdir string
 		database             *db.Database
+		scan                 db.Scan
 	)
 
 	BeforeEach(func() {
@@ -24,7 +25,7 @@ var _ = Describe("BuildRootProcessesReport", func() {
 		Expect(err).NotTo(HaveOccurred())
 		databasePath = filepath.Join(tmpdir, "db.db")
 
-		database, err = createTestDatabase(databasePath)
+		database, scan, err = createTestDatabase(databasePath)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -37,7 +38,7 @@ var _ = Describe("BuildRootProcessesReport", func() {
 	})
 
 	It("shows externally-accessible processes running as root", func() {
-		r, err := report.BuildRootProcessesReport(database)
+		r, err := report.BuildRootProcessesReport(database, scan.ID)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(r.Title).To(Equal("Externally-accessible processes running as root:"))
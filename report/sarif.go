@@ -0,0 +1,157 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+var sarifLevels = map[string]string{
+	SeverityHigh:   "error",
+	SeverityMedium: "warning",
+	SeverityLow:    "note",
+}
+
+// Code scanning dashboards rank findings by this score rather than by level.
+var sarifSecuritySeverities = map[string]string{
+	SeverityHigh:   "8.0",
+	SeverityMedium: "5.0",
+	SeverityLow:    "2.0",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	Help                 *sarifMessage          `json:"help,omitempty"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties    `json:"properties"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	Tags             []string `json:"tags"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes the findings of the reports as a SARIF 2.1.0 log. Each
+// report is a rule, and each row of a report is a result of that rule.
+func WriteSARIF(writer io.Writer, reports []Report) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "scantron",
+				Version:        scantron.Version,
+				InformationURI: "https://github.com/pivotal-cf/scantron",
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	for _, r := range reports {
+		rule := sarifRule{
+			ID:               r.RuleID,
+			ShortDescription: sarifMessage{Text: r.Rule()},
+			DefaultConfiguration: sarifRuleConfiguration{
+				Level: sarifLevels[r.Severity],
+			},
+			Properties: sarifRuleProperties{
+				Tags:             []string{"security"},
+				SecuritySeverity: sarifSecuritySeverities[r.Severity],
+			},
+		}
+
+		if r.Footnote != "" {
+			rule.Help = &sarifMessage{Text: r.Footnote}
+		}
+
+		ruleIndex := len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+
+		for _, finding := range r.Findings() {
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    finding.RuleID,
+				RuleIndex: ruleIndex,
+				Level:     sarifLevels[finding.Severity],
+				Message:   sarifMessage{Text: finding.Message},
+				Locations: []sarifLocation{
+					{
+						LogicalLocations: []sarifLogicalLocation{
+							{
+								Name:               finding.Name(),
+								FullyQualifiedName: finding.Name(),
+								Kind:               "resource",
+							},
+						},
+					},
+				},
+				PartialFingerprints: map[string]string{
+					"scantron/v1": finding.RuleID + ":" + finding.ID,
+				},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,44 @@
+package report
+
+import "github.com/pivotal-cf/scantron/db"
+
+func BuildScanFailuresReport(database *db.Database, scanID int) (Report, error) {
+	rows, err := database.DB().Query(`
+    SELECT f.name, f.ip, f.stage, f.error
+    FROM scan_failures f
+    WHERE f.scan_id = ?
+    ORDER BY f.name, f.ip
+    `, scanID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:      "Hosts which could not be scanned:",
+		Header:     []string{"Identity", "IP", "Stage", "Error"},
+		Footnote:   "Nothing else in this report covers these hosts.",
+		RuleID:     "scan-failure",
+		Severity:   SeverityHigh,
+		KeyColumns: []int{1},
+	}
+
+	for rows.Next() {
+		var hostname, ip, stage, scanError string
+
+		err := rows.Scan(&hostname, &ip, &stage, &scanError)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			ip,
+			stage,
+			scanError,
+		})
+	}
+
+	return report, nil
+}
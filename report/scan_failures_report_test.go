@@ -0,0 +1,51 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildScanFailuresReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows the hosts which could not be scanned", func() {
+		r, err := report.BuildScanFailuresReport(database, scan.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Hosts which could not be scanned:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "IP", "Stage", "Error"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host4", "10.0.5.26", "connect", "connection refused"},
+			{"host5", "10.0.5.27", "run", "sudo: incorrect password"},
+		}))
+	})
+})
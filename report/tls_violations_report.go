@@ -1,10 +1,12 @@
 package report
 
 import (
+	"database/sql"
 	"fmt"
-	"github.com/pivotal-cf/scantron/db"
-	"github.com/pivotal-cf/scantron/tlsscan"
 	"strings"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/tlspolicy"
 )
 
 type stringSlice []string
@@ -18,31 +20,9 @@ func (s stringSlice) contains(str string) bool {
 	return false
 }
 
-var goodSuites = stringSlice{
-	"VersionTLS12",
-}
-
-func buildGoodCiphers() (stringSlice, error) {
-	allCiphers, err := tlsscan.BuildCipherSuites()
-	if err != nil {
-		return nil, err
-	}
-
-	goodCiphers := make(stringSlice, 0)
-	for _, c := range allCiphers {
-		if c.Recommended {
-			goodCiphers = append(goodCiphers, c.Name)
-		}
-	}
-	return goodCiphers, nil
-}
-
-func BuildTLSViolationsReport(database *db.Database) (Report, error) {
-	goodCiphers, err := buildGoodCiphers()
-	if err != nil {
-		return Report{}, err
-	}
-	query := fmt.Sprintf(`SELECT DISTINCT h.name, po.number, pr.name, s.suite, c.cipher
+func BuildTLSViolationsReport(database *db.Database, scanID int, policy tlspolicy.Policy) (Report, error) {
+	rows, err := database.DB().Query(`SELECT DISTINCT h.name, po.number, pr.name, COALESCE(t.key_type, ''), COALESCE(t.cert_bits, 0),
+		COALESCE(t.mutual, 0), t.cert_bits IS NULL, t.server_cipher_preference, COALESCE(t.dh_bits, 0), s.suite, c.cipher
 	FROM hosts h
 	JOIN processes pr
 	ON h.id = pr.host_id
@@ -50,16 +30,14 @@ func BuildTLSViolationsReport(database *db.Database) (Report, error) {
 	ON po.process_id = pr.id
 	JOIN tls_certificates t
 	ON t.port_id = po.id
-	JOIN certificate_to_ciphersuite ctc
+	LEFT JOIN certificate_to_ciphersuite ctc
 	ON t.id = ctc.certificate_id
-	JOIN tls_suites s
+	LEFT JOIN tls_suites s
 	ON ctc.suite_id = s.id
-	JOIN tls_ciphers c
+	LEFT JOIN tls_ciphers c
 	ON ctc.cipher_id = c.id
-	WHERE s.suite NOT IN(%s) OR c.cipher NOT IN(%s)
-	ORDER BY h.name, po.number`, "'"+strings.Join(goodSuites, "','")+"'", "'"+strings.Join(goodCiphers, "','")+"'") // sql binding doesn't play nice with array args
-	rows, err := database.DB().Query(query)
-
+	WHERE h.scan_id = ?
+	ORDER BY h.name, po.number`, scanID)
 	if err != nil {
 		return Report{}, err
 	}
@@ -74,8 +52,12 @@ func BuildTLSViolationsReport(database *db.Database) (Report, error) {
 			"Process Name",
 			"Non-approved Protocol(s)",
 			"Non-approved Cipher(s)",
+			"Other Violation(s)",
 		},
-		Footnote: "If this is not an internal endpoint then please check with your PM and the security team before applying this change. This change is not backwards compatible.",
+		Footnote:       "If this is not an internal endpoint then please check with your PM and the security team before applying this change. This change is not backwards compatible.",
+		RuleID:         "non-approved-tls",
+		Severity:       SeverityMedium,
+		LocationColumn: 1,
 	}
 
 	type Host struct {
@@ -85,53 +67,82 @@ func BuildTLSViolationsReport(database *db.Database) (Report, error) {
 	}
 
 	type cipherSuites struct {
-		suites  stringSlice
-		ciphers stringSlice
+		suites     stringSlice
+		ciphers    stringSlice
+		violations stringSlice
 	}
 
 	var hostMap = map[Host]cipherSuites{}
+	var hosts = []Host{}
 
 	for rows.Next() {
 		var (
 			hostname    string
 			processName string
 			portNumber  int
-			suite       string
-			cipher      string
+			endpoint    tlspolicy.Endpoint
+			preference  sql.NullBool
+			suite       sql.NullString
+			cipher      sql.NullString
 		)
 
-		err := rows.Scan(&hostname, &portNumber, &processName, &suite, &cipher)
+		err := rows.Scan(&hostname, &portNumber, &processName, &endpoint.KeyType, &endpoint.KeyBits, &endpoint.Mutual,
+			&endpoint.CertificateUnknown, &preference, &endpoint.DHBits, &suite, &cipher)
 		if err != nil {
 			return Report{}, err
 		}
 
+		if preference.Valid {
+			endpoint.ServerCipherPreference = &preference.Bool
+		}
+
 		host := Host{
 			hostname,
 			portNumber,
 			processName,
 		}
 
-		cs := hostMap[host]
-		if cs.suites == nil {
+		rules := policy.RulesFor(processName, portNumber)
+
+		cs, ok := hostMap[host]
+		if !ok {
+			hosts = append(hosts, host)
 			cs.suites = []string{}
 			cs.ciphers = []string{}
+			cs.violations = []string{}
+		}
+		if suite.Valid && !rules.ProtocolAllowed(suite.String) && !cs.suites.contains(suite.String) {
+			cs.suites = append(cs.suites, suite.String)
 		}
-		if !goodSuites.contains(suite) && !cs.suites.contains(suite) {
-			cs.suites = append(cs.suites, suite)
+		if cipher.Valid && !rules.CipherAllowed(cipher.String) && !cs.ciphers.contains(cipher.String) {
+			cs.ciphers = append(cs.ciphers, cipher.String)
 		}
-		if !goodCiphers.contains(cipher) && !cs.ciphers.contains(cipher) {
-			cs.ciphers = append(cs.ciphers, cipher)
+		for _, violation := range rules.Violations(endpoint) {
+			if !cs.violations.contains(violation) {
+				cs.violations = append(cs.violations, violation)
+			}
 		}
 		hostMap[host] = cs
 	}
 
-	for host, cs := range hostMap {
+	err = rows.Err()
+	if err != nil {
+		return Report{}, err
+	}
+
+	for _, host := range hosts {
+		cs := hostMap[host]
+		if len(cs.suites) == 0 && len(cs.ciphers) == 0 && len(cs.violations) == 0 {
+			continue
+		}
+
 		report.Rows = append(report.Rows, []string{
 			host.hostname,
 			fmt.Sprintf("%d", host.portNumber),
 			host.processName,
 			strings.Join(cs.suites, " "),
 			strings.Join(cs.ciphers, " "),
+			strings.Join(cs.violations, ", "),
 		})
 	}
 	return report, nil
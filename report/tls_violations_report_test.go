@@ -10,12 +10,14 @@ import (
 
 	"github.com/pivotal-cf/scantron/db"
 	"github.com/pivotal-cf/scantron/report"
+	"github.com/pivotal-cf/scantron/tlspolicy"
 )
 
 var _ = Describe("BuildTLSViolationsReport", func() {
 	var (
 		databasePath, tmpdir string
 		database             *db.Database
+		scan                 db.Scan
 	)
 
 	BeforeEach(func() {
@@ -24,7 +26,7 @@ var _ = Describe("BuildTLSViolationsReport", func() {
 		Expect(err).NotTo(HaveOccurred())
 		databasePath = filepath.Join(tmpdir, "db.db")
 
-		database, err = createTestDatabase(databasePath)
+		database, scan, err = createTestDatabase(databasePath)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -37,7 +39,10 @@ var _ = Describe("BuildTLSViolationsReport", func() {
 	})
 
 	It("shows processes using non-approved protocols or cipher suites", func() {
-		r, err := report.BuildTLSViolationsReport(database)
+		policy, err := tlspolicy.Default()
+		Expect(err).NotTo(HaveOccurred())
+
+		r, err := report.BuildTLSViolationsReport(database, scan.ID, policy)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(r.Title).To(Equal("Processes using non-approved SSL/TLS settings:"))
@@ -48,13 +53,84 @@ var _ = Describe("BuildTLSViolationsReport", func() {
 			"Process Name",
 			"Non-approved Protocol(s)",
 			"Non-approved Cipher(s)",
+			"Other Violation(s)",
 		}))
 
 		Expect(r.Rows).To(HaveLen(3))
 		Expect(r.Rows).To(ConsistOf(
-			[]string{"host1", "7890", "command1", "VersionSSL30", ""},
-			[]string{"host1", "8890", "command1", "", "Bad Cipher"},
-			[]string{"host3", "7890", "command1", "VersionSSL30", "Just the worst"},
+			[]string{"host1", "7890", "command1", "VersionSSL30", "", ""},
+			[]string{"host1", "8890", "command1", "", "Bad Cipher", ""},
+			[]string{"host3", "7890", "command1", "VersionSSL30", "Just the worst", ""},
 		))
 	})
+
+	Context("with a custom policy", func() {
+		It("shows the processes which do not meet the policy", func() {
+			minimumKeyBits := 2048
+			minimumECDSAKeyBits := 256
+
+			policy := tlspolicy.Policy{
+				Rules: tlspolicy.Rules{
+					Protocols:           []string{"VersionSSL30", "VersionTLS12", "VersionTLS13"},
+					DeniedCiphers:       []string{"Just the worst"},
+					MinimumKeyBits:      &minimumKeyBits,
+					MinimumECDSAKeyBits: &minimumECDSAKeyBits,
+				},
+				Overrides: []tlspolicy.Override{
+					{
+						Process: "command2",
+						Rules: tlspolicy.Rules{
+							MinimumKeyBits: new(int),
+						},
+					},
+					{
+						Process: "command.exe",
+						Rules: tlspolicy.Rules{
+							MinimumKeyBits: new(int),
+						},
+					},
+					{
+						Process: "command2.exe",
+						Rules: tlspolicy.Rules{
+							MinimumKeyBits: new(int),
+						},
+					},
+				},
+			}
+
+			r, err := report.BuildTLSViolationsReport(database, scan.ID, policy)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(r.Rows).To(ConsistOf(
+				[]string{"host1", "8890", "command1", "", "", "ECDSA key is 224 bits (minimum 256)"},
+				[]string{"host3", "7890", "command1", "", "Just the worst", "RSA key is 1024 bits (minimum 2048)"},
+			))
+		})
+
+		It("checks the cipher order and key exchange", func() {
+			requireServerCipherOrder := true
+			requireECDHE := true
+			minimumDHBits := 2048
+
+			policy := tlspolicy.Policy{
+				Rules: tlspolicy.Rules{
+					RequireServerCipherOrder: &requireServerCipherOrder,
+					RequireECDHE:             &requireECDHE,
+					MinimumDHBits:            &minimumDHBits,
+				},
+			}
+
+			r, err := report.BuildTLSViolationsReport(database, scan.ID, policy)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(r.Rows).To(ConsistOf(
+				[]string{"host1", "7890", "command1", "", "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", "server cipher order is not enforced"},
+				[]string{"host1", "8890", "command1", "", "Bad Cipher", "server cipher order is not enforced"},
+				[]string{"host2", "19999", "command2", "", "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", "DH parameters are 1024 bits (minimum 2048)"},
+				[]string{"host3", "7890", "command1", "", "Just the worst", "server cipher order is not enforced"},
+				[]string{"winhost1", "19998", "command.exe", "", "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", ""},
+				[]string{"winhost1", "19999", "command2.exe", "", "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", "server cipher order could not be checked"},
+			))
+		})
+	})
 })
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+const (
+	minimumRSAKeyBits   = 2048
+	minimumECDSAKeyBits = 256
+)
+
+func BuildWeakKeyReport(database *db.Database, scanID int) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, po.number, pr.name, t.key_type, t.cert_bits
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+      JOIN ports po
+        ON po.process_id = pr.id
+      JOIN tls_certificates t
+        ON t.port_id = po.id
+    WHERE h.scan_id = ?
+    AND ((t.key_type = 'RSA' AND t.cert_bits < ?)
+      OR (t.key_type = 'ECDSA' AND t.cert_bits < ?))
+    ORDER BY h.name, po.number
+	`, scanID, minimumRSAKeyBits, minimumECDSAKeyBits)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:          "Certificates with weak keys:",
+		Header:         []string{"Identity", "Port", "Process Name", "Key Type", "Key Bits"},
+		Footnote:       fmt.Sprintf("RSA keys should be at least %d bits and ECDSA keys at least %d bits.", minimumRSAKeyBits, minimumECDSAKeyBits),
+		RuleID:         "weak-certificate-key",
+		Severity:       SeverityHigh,
+		LocationColumn: 1,
+	}
+
+	for rows.Next() {
+		var (
+			hostname    string
+			portNumber  int
+			processName string
+			keyType     string
+			keyBits     int
+		)
+
+		err := rows.Scan(&hostname, &portNumber, &processName, &keyType, &keyBits)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			fmt.Sprintf("%d", portNumber),
+			processName,
+			keyType,
+			fmt.Sprintf("%d", keyBits),
+		})
+	}
+
+	return report, rows.Err()
+}
@@ -0,0 +1,51 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildWeakKeyReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows certificates with RSA keys under 2048 bits or ECDSA keys under 256 bits", func() {
+		r, err := report.BuildWeakKeyReport(database, scan.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Certificates with weak keys:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Port", "Process Name", "Key Type", "Key Bits"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host1", "8890", "command1", "ECDSA", "224"},
+			{"host3", "7890", "command1", "RSA", "1024"},
+		}))
+	})
+})
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"strings"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+// sshAlgorithmKinds are the kinds of algorithm in the ssh_algorithms table in
+// the order they are shown, along with the name used in the report.
+var sshAlgorithmKinds = []struct {
+	kind string
+	name string
+}{
+	{"kex", "key exchange"},
+	{"host_key", "host key"},
+	{"cipher", "cipher"},
+	{"mac", "MAC"},
+}
+
+func BuildWeakSSHReport(database *db.Database, scanID int) (Report, error) {
+	rows, err := database.DB().Query(`
+    SELECT h.name, a.kind, a.name
+    FROM hosts h
+      JOIN ssh_servers s
+        ON s.host_id = h.id
+      JOIN ssh_algorithms a
+        ON a.ssh_server_id = s.id
+    WHERE h.scan_id = ?
+    ORDER BY h.name, a.position
+    `, scanID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:      "Weak SSH configuration:",
+		Header:     []string{"Identity", "Algorithm Type", "Weak Algorithm(s)"},
+		Footnote:   "SSH servers should not accept SHA-1 key exchanges, DSA or SHA-1 RSA host keys, CBC or RC4 ciphers, or MD5, SHA-1 or truncated MACs.",
+		RuleID:     "weak-ssh-configuration",
+		Severity:   SeverityMedium,
+		KeyColumns: []int{1},
+	}
+
+	hostnames := []string{}
+	weak := map[string]map[string][]string{}
+
+	for rows.Next() {
+		var hostname, kind, name string
+
+		err := rows.Scan(&hostname, &kind, &name)
+		if err != nil {
+			return Report{}, err
+		}
+
+		if !weakSSHAlgorithm(kind, name) {
+			continue
+		}
+
+		if _, ok := weak[hostname]; !ok {
+			hostnames = append(hostnames, hostname)
+			weak[hostname] = map[string][]string{}
+		}
+
+		weak[hostname][kind] = append(weak[hostname][kind], name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return Report{}, err
+	}
+
+	for _, hostname := range hostnames {
+		for _, kind := range sshAlgorithmKinds {
+			names := weak[hostname][kind.kind]
+			if len(names) == 0 {
+				continue
+			}
+
+			report.Rows = append(report.Rows, []string{
+				hostname,
+				kind.name,
+				strings.Join(names, ", "),
+			})
+		}
+	}
+
+	return report, nil
+}
+
+func weakSSHAlgorithm(kind, name string) bool {
+	if name == "none" {
+		return true
+	}
+
+	switch kind {
+	case "kex":
+		return strings.Contains(name, "-sha1")
+	case "host_key":
+		return strings.HasPrefix(name, "ssh-dss") || name == "ssh-rsa" || strings.HasPrefix(name, "ssh-rsa-cert-")
+	case "cipher":
+		return strings.Contains(name, "-cbc") ||
+			hasAnyPrefix(name, "arcfour", "3des", "des", "blowfish", "cast128")
+	case "mac":
+		return hasAnyPrefix(name, "hmac-md5", "hmac-sha1", "hmac-ripemd160", "umac-64") ||
+			strings.Contains(name, "-96")
+	default:
+		return false
+	}
+}
+
+func hasAnyPrefix(name string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
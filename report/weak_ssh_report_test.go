@@ -0,0 +1,52 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildWeakSSHReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows the weak algorithms each SSH server accepts", func() {
+		r, err := report.BuildWeakSSHReport(database, scan.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Weak SSH configuration:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Algorithm Type", "Weak Algorithm(s)"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host3", "key exchange", "diffie-hellman-group14-sha1, diffie-hellman-group1-sha1"},
+			{"host3", "host key", "ssh-dss"},
+			{"host3", "cipher", "aes128-cbc, arcfour"},
+		}))
+	})
+})
@@ -2,16 +2,17 @@ package report
 
 import "github.com/pivotal-cf/scantron/db"
 
-func BuildWorldReadableFilesReport(database *db.Database) (Report, error) {
+func BuildWorldReadableFilesReport(database *db.Database, scanID int) (Report, error) {
 	rows, err := database.DB().Query(`
 	SELECT DISTINCT h.name, f.path
     FROM hosts h
       JOIN files f
         ON h.id = f.host_id
-    WHERE f.path LIKE "/var/vcap/data/jobs/%"
+    WHERE h.scan_id = ?
+      AND f.path LIKE "/var/vcap/data/jobs/%"
       AND f.permissions & 04 != 0
     ORDER BY h.name, f.path
-	`)
+	`, scanID)
 	if err != nil {
 		return Report{}, err
 	}
@@ -19,8 +20,11 @@ func BuildWorldReadableFilesReport(database *db.Database) (Report, error) {
 	defer rows.Close()
 
 	report := Report{
-		Title:  "World-readable files:",
-		Header: []string{"Identity", "Path"},
+		Title:          "World-readable files:",
+		Header:         []string{"Identity", "Path"},
+		RuleID:         "world-readable-file",
+		Severity:       SeverityMedium,
+		LocationColumn: 1,
 	}
 
 	for rows.Next() {
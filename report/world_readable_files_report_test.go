@@ -16,6 +16,7 @@ var _ = Describe("BuildWorldReadableFilesReport", func() {
 	var (
 		databasePath, tmpdir string
 		database             *db.Database
+		scan                 db.Scan
 	)
 
 	BeforeEach(func() {
@@ -24,7 +25,7 @@ var _ = Describe("BuildWorldReadableFilesReport", func() {
 		Expect(err).NotTo(HaveOccurred())
 		databasePath = filepath.Join(tmpdir, "db.db")
 
-		database, err = createTestDatabase(databasePath)
+		database, scan, err = createTestDatabase(databasePath)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -37,7 +38,7 @@ var _ = Describe("BuildWorldReadableFilesReport", func() {
 	})
 
 	It("shows world-readable configuration files", func() {
-		r, err := report.BuildWorldReadableFilesReport(database)
+		r, err := report.BuildWorldReadableFilesReport(database, scan.ID)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(r.Title).To(Equal("World-readable files:"))
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+// BuildWritableSocketsReport lists the Unix sockets on the filesystem which a
+// non-root user can connect to: those writable by everyone, by a group other
+// than root, or by a non-root owner while a root process is listening.
+func BuildWritableSocketsReport(database *db.Database, scanID int) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, s.path, s.permissions, s.user, s.socket_group, pr.name
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+      JOIN sockets s
+        ON s.process_id = pr.id
+    WHERE h.scan_id = ?
+      AND s.family = "unix"
+      AND s.path NOT LIKE "@%"
+      -- SQLite has no octal literals: 2 is o+w, 16 is g+w and 128 is u+w.
+      AND (s.permissions & 2 != 0
+        OR (s.permissions & 16 != 0 AND s.socket_group != "root")
+        OR (s.permissions & 128 != 0 AND s.user != "root" AND pr.user = "root"))
+    ORDER BY h.name, s.path
+	`, scanID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:          "Sockets writable by non-root users:",
+		Header:         []string{"Identity", "Path", "Permissions", "Owner", "Process Name"},
+		Footnote:       "Processes listening on these sockets can be sent requests by unprivileged users.",
+		RuleID:         "writable-unix-socket",
+		Severity:       SeverityHigh,
+		LocationColumn: 1,
+		KeyColumns:     []int{4},
+	}
+
+	for rows.Next() {
+		var (
+			hostname    string
+			path        string
+			permissions int
+			user        string
+			group       string
+			processName string
+		)
+
+		err := rows.Scan(&hostname, &path, &permissions, &user, &group, &processName)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			path,
+			fmt.Sprintf("%04o", permissions),
+			fmt.Sprintf("%s:%s", user, group),
+			processName,
+		})
+	}
+
+	return report, nil
+}
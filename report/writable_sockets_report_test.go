@@ -0,0 +1,52 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildWritableSocketsReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		scan                 db.Scan
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, scan, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows sockets which non-root users can write to", func() {
+		r, err := report.BuildWritableSocketsReport(database, scan.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Sockets writable by non-root users:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Path", "Permissions", "Owner", "Process Name"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host1", "/var/run/docker.sock", "0660", "root:docker", "command2"},
+			{"host1", "/var/vcap/sys/run/command2/vcap.sock", "0600", "vcap:vcap", "command2"},
+			{"host3", "/var/vcap/data/sys/run/command1.sock", "0666", "root:root", "command1"},
+		}))
+	})
+})
@@ -20,13 +20,14 @@ func Bosh(deployment bosh.TargetDeployment) Scanner {
 	}
 }
 
-func (s *boshScanner) Scan(fileRegexes *scantron.FileMatch, logger scanlog.Logger) (ScanResult, error) {
+func (s *boshScanner) Scan(fileRegexes *scantron.FileMatch, tlsOptions *scantron.TLSScanOptions, logger scanlog.Logger) (ScanResult, error) {
 	vms := s.deployment.VMs()
 
 	wg := &sync.WaitGroup{}
 	wg.Add(len(vms))
 
 	hosts := make(chan JobResult)
+	failures := make(chan FailureResult, len(vms))
 
 	err := s.deployment.Setup()
 	if err != nil {
@@ -52,13 +53,15 @@ func (s *boshScanner) Scan(fileRegexes *scantron.FileMatch, logger scanlog.Logge
 			remoteMachine := s.deployment.ConnectTo(vm)
 			defer remoteMachine.Close()
 
-			systemInfo, err := scanMachine(fileRegexes, machineLogger, remoteMachine)
+			boshName := fmt.Sprintf("%s/%s", vm.JobName, vm.ID)
+
+			systemInfo, err := scanMachine(fileRegexes, tlsOptions, machineLogger, remoteMachine)
 			if err != nil {
 				machineLogger.Errorf("Failed to scan machine: %s", err)
+				failures <- BuildFailureResult(err, boshName, ip)
 				return
 			}
 
-			boshName := fmt.Sprintf("%s/%s", vm.JobName, vm.ID)
 			hosts <- buildJobResult(systemInfo, boshName, ip)
 		}()
 	}
@@ -66,6 +69,7 @@ func (s *boshScanner) Scan(fileRegexes *scantron.FileMatch, logger scanlog.Logge
 	go func() {
 		wg.Wait()
 		close(hosts)
+		close(failures)
 	}()
 
 	var scannedHosts []JobResult
@@ -74,6 +78,12 @@ func (s *boshScanner) Scan(fileRegexes *scantron.FileMatch, logger scanlog.Logge
 		scannedHosts = append(scannedHosts, host)
 	}
 
+	var failedHosts []FailureResult
+
+	for failure := range failures {
+		failedHosts = append(failedHosts, failure)
+	}
+
 	releaseResults := []ReleaseResult{}
 	for _, release := range s.deployment.Releases() {
 		releaseResults = append(releaseResults, ReleaseResult{Name: release.Name(), Version: release.Version().String()})
@@ -82,6 +92,7 @@ func (s *boshScanner) Scan(fileRegexes *scantron.FileMatch, logger scanlog.Logge
 	return ScanResult{
 		JobResults:     scannedHosts,
 		ReleaseResults: releaseResults,
+		Failures:       failedHosts,
 	}, nil
 }
 
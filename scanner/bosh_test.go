@@ -7,6 +7,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/pivotal-cf/scantron/bosh"
 	"github.com/pivotal-cf/scantron/remotemachine"
+	"time"
 
 	"github.com/cppforlife/go-semi-semantic/version"
 	. "github.com/onsi/ginkgo"
@@ -38,7 +39,8 @@ var _ = Describe("Bosh Scanning", func() {
 		logger     scanlog.Logger
 		buffer     *bytes.Buffer
 
-		fileMatch *scantron.FileMatch
+		fileMatch  *scantron.FileMatch
+		tlsOptions *scantron.TLSScanOptions
 	)
 
 	AfterEach(func() {
@@ -65,6 +67,7 @@ var _ = Describe("Bosh Scanning", func() {
 		fileMatch = &scantron.FileMatch{
 			MaxRegexFileSize: int64(1000),
 		}
+		tlsOptions = &scantron.TLSScanOptions{}
 
 		buffer = &bytes.Buffer{}
 		err := json.NewEncoder(buffer).Encode(systemInfo)
@@ -117,7 +120,23 @@ var _ = Describe("Bosh Scanning", func() {
 			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
 			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
 			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
-			scanResult, scanErr = boshScan.Scan(fileMatch, logger)
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
+		})
+	})
+
+	Context("when TLS scan options are specified", func() {
+		BeforeEach(func() {
+			tlsOptions.ProtocolTimeout = 5 * time.Second
+			tlsOptions.CipherTimeout = 30 * time.Second
+			tlsOptions.Concurrency = 4
+			tlsOptions.Retries = 2
+		})
+
+		It("passes them to the proc_scan binary", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
+			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000 --tls-protocol-timeout 5s --tls-cipher-timeout 30s --tls-concurrency 4 --tls-retries 2").Return(buffer, nil).Times(1)
+			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
 		})
 	})
 
@@ -131,7 +150,7 @@ var _ = Describe("Bosh Scanning", func() {
 			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
 			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000 --path \"interesting\" --content \"valuable\"").Return(buffer, nil).Times(1)
 			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
-			scanResult, scanErr = boshScan.Scan(fileMatch, logger)
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
 		})
 	})
 
@@ -140,7 +159,7 @@ var _ = Describe("Bosh Scanning", func() {
 		machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
 		machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
 		machine.EXPECT().DeleteFile("./proc_scan").Times(1)
-		scanResult, scanErr = boshScan.Scan(fileMatch, logger)
+		scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
 		Expect(scanResult).To(Equal(scanner.ScanResult{
 			ReleaseResults: []scanner.ReleaseResult{
 				{
@@ -172,7 +191,7 @@ var _ = Describe("Bosh Scanning", func() {
 		})
 
 		It("all still works", func() {
-			scanResult, scanErr = boshScan.Scan(fileMatch, logger)
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
 			Expect(scanErr).ShouldNot(HaveOccurred())
 		})
 	})
@@ -183,8 +202,40 @@ var _ = Describe("Bosh Scanning", func() {
 		})
 
 		It("keeps going", func() {
-			scanResult, scanErr = boshScan.Scan(fileMatch, logger)
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+
+		It("records the failure", func() {
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+
+			Expect(scanResult.JobResults).To(BeEmpty())
+			Expect(scanResult.Failures).To(ConsistOf(scanner.FailureResult{
+				IP:    "10.0.0.1",
+				Job:   "service/id",
+				Stage: scanner.StageUpload,
+				Error: "disaster",
+			}))
+		})
+	})
+
+	Context("when connecting to the machine fails", func() {
+		BeforeEach(func() {
+			connErr := remotemachine.ConnectionError{Err: errors.New("connection refused")}
+			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(connErr).Times(1)
+		})
+
+		It("records the failure as a connection failure", func() {
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
 			Expect(scanErr).NotTo(HaveOccurred())
+
+			Expect(scanResult.Failures).To(ConsistOf(scanner.FailureResult{
+				IP:    "10.0.0.1",
+				Job:   "service/id",
+				Stage: scanner.StageConnect,
+				Error: "connection refused",
+			}))
 		})
 	})
 
@@ -196,8 +247,36 @@ var _ = Describe("Bosh Scanning", func() {
 		})
 
 		It("keeps going", func() {
-			scanResult, scanErr = boshScan.Scan(fileMatch, logger)
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
 			Expect(scanErr).NotTo(HaveOccurred())
 		})
+
+		It("records the failure", func() {
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+
+			Expect(scanResult.Failures).To(ConsistOf(scanner.FailureResult{
+				IP:    "10.0.0.1",
+				Job:   "service/id",
+				Stage: scanner.StageRun,
+				Error: "disaster",
+			}))
+		})
+	})
+
+	Context("when the scanner output is malformed", func() {
+		BeforeEach(func() {
+			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
+			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(bytes.NewBufferString("not json"), nil).Times(1)
+			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+		})
+
+		It("records the failure", func() {
+			scanResult, scanErr = boshScan.Scan(fileMatch, tlsOptions, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+
+			Expect(scanResult.Failures).To(HaveLen(1))
+			Expect(scanResult.Failures[0].Stage).To(Equal(scanner.StageDecode))
+		})
 	})
 })
@@ -18,12 +18,12 @@ func Direct(machine remotemachine.RemoteMachine) Scanner {
 	}
 }
 
-func (d *direct) Scan(match *scantron.FileMatch, logger scanlog.Logger) (ScanResult, error) {
+func (d *direct) Scan(match *scantron.FileMatch, tlsOptions *scantron.TLSScanOptions, logger scanlog.Logger) (ScanResult, error) {
 	hostLogger := logger.With(
 		"host", d.machine.Address(),
 	)
 
-	systemInfo, err := scanMachine(match, hostLogger, d.machine)
+	systemInfo, err := scanMachine(match, tlsOptions, hostLogger, d.machine)
 	if err != nil {
 		hostLogger.Errorf("Failed to scan machine: %s", err)
 		return ScanResult{}, err
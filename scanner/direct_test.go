@@ -6,6 +6,7 @@ import (
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/pivotal-cf/scantron/remotemachine"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -28,7 +29,8 @@ var _ = Describe("Direct Scanning", func() {
 		logger      scanlog.Logger
 		buffer      *bytes.Buffer
 
-		fileMatch *scantron.FileMatch
+		fileMatch  *scantron.FileMatch
+		tlsOptions *scantron.TLSScanOptions
 	)
 
 	BeforeEach(func() {
@@ -52,6 +54,7 @@ var _ = Describe("Direct Scanning", func() {
 		fileMatch = &scantron.FileMatch{
 			MaxRegexFileSize: int64(1000),
 		}
+		tlsOptions = &scantron.TLSScanOptions{}
 
 		buffer = &bytes.Buffer{}
 		err := json.NewEncoder(buffer).Encode(systemInfo)
@@ -74,7 +77,23 @@ var _ = Describe("Direct Scanning", func() {
 			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
 			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
 			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
-			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			scanResults, scanErr = directScan.Scan(fileMatch, tlsOptions, logger)
+		})
+	})
+
+	Context("when TLS scan options are specified", func() {
+		BeforeEach(func() {
+			tlsOptions.ProtocolTimeout = 5 * time.Second
+			tlsOptions.CipherTimeout = 30 * time.Second
+			tlsOptions.Concurrency = 4
+			tlsOptions.Retries = 2
+		})
+
+		It("passes them to the proc_scan binary", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
+			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000 --tls-protocol-timeout 5s --tls-cipher-timeout 30s --tls-concurrency 4 --tls-retries 2").Return(buffer, nil).Times(1)
+			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, tlsOptions, logger)
 		})
 	})
 
@@ -88,7 +107,7 @@ var _ = Describe("Direct Scanning", func() {
 			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
 			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000 --path \"interesting\" --content \"valuable\"").Return(buffer, nil).Times(1)
 			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
-			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			scanResults, scanErr = directScan.Scan(fileMatch, tlsOptions, logger)
 		})
 	})
 
@@ -96,7 +115,7 @@ var _ = Describe("Direct Scanning", func() {
 		machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
 		machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
 		machine.EXPECT().DeleteFile("./proc_scan").Times(1)
-		scanResults, scanErr = directScan.Scan(fileMatch, logger)
+		scanResults, scanErr = directScan.Scan(fileMatch, tlsOptions, logger)
 		Expect(scanResults.JobResults).To(Equal([]scanner.JobResult{
 			{
 				IP:       "10.0.0.1",
@@ -107,13 +126,37 @@ var _ = Describe("Direct Scanning", func() {
 		}))
 	})
 
+	It("keeps the TLS scan errors of the machine's ports", func() {
+		systemInfo.Processes[0].Ports = []scantron.Port{
+			{
+				Number: 8443,
+				TLSInformation: &scantron.TLSInformation{
+					ScanError: "tls: unsupported public key type: 0",
+				},
+			},
+		}
+
+		buffer.Reset()
+		err := json.NewEncoder(buffer).Encode(systemInfo)
+		Expect(err).NotTo(HaveOccurred())
+
+		machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
+		machine.EXPECT().RunCommand(gomock.Any()).Return(buffer, nil).Times(1)
+		machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+
+		scanResults, scanErr = directScan.Scan(fileMatch, tlsOptions, logger)
+		Expect(scanErr).NotTo(HaveOccurred())
+		Expect(scanResults.JobResults).To(HaveLen(1))
+		Expect(scanResults.JobResults[0].Services[0].Ports[0].TLSInformation.ScanError).To(Equal("tls: unsupported public key type: 0"))
+	})
+
 	Context("when uploading the scanning binary fails", func() {
 		BeforeEach(func() {
 			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(errors.New("disaster")).Times(1)
 		})
 
 		It("fails to scan", func() {
-			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			scanResults, scanErr = directScan.Scan(fileMatch, tlsOptions, logger)
 			Expect(scanErr).To(MatchError("disaster"))
 		})
 	})
@@ -126,7 +169,7 @@ var _ = Describe("Direct Scanning", func() {
 		})
 
 		It("fails to scan", func() {
-			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			scanResults, scanErr = directScan.Scan(fileMatch, tlsOptions, logger)
 			Expect(scanErr).To(MatchError("disaster"))
 		})
 	})
@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/remotemachine"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+type InventoryHost struct {
+	Name     string
+	Machines []remotemachine.RemoteMachine
+}
+
+type inventoryScanner struct {
+	hosts []InventoryHost
+}
+
+func Inventory(hosts []InventoryHost) Scanner {
+	return &inventoryScanner{
+		hosts: hosts,
+	}
+}
+
+func (s *inventoryScanner) Scan(fileRegexes *scantron.FileMatch, tlsOptions *scantron.TLSScanOptions, logger scanlog.Logger) (ScanResult, error) {
+	wg := &sync.WaitGroup{}
+
+	machineCount := 0
+	for _, host := range s.hosts {
+		machineCount += len(host.Machines)
+	}
+
+	results := make(chan JobResult)
+	failures := make(chan FailureResult, machineCount)
+
+	for _, host := range s.hosts {
+		for _, machine := range host.Machines {
+			wg.Add(1)
+
+			go func(name string, machine remotemachine.RemoteMachine) {
+				defer wg.Done()
+				defer machine.Close()
+
+				hostLogger := logger.With("name", name)
+
+				scanResult, err := Direct(machine).Scan(fileRegexes, tlsOptions, hostLogger)
+				if err != nil {
+					jobName := name
+					if jobName == "" {
+						jobName = machine.Host()
+					}
+
+					failures <- BuildFailureResult(err, jobName, machine.Host())
+					return
+				}
+
+				for _, jobResult := range scanResult.JobResults {
+					if name != "" {
+						jobResult.Job = name
+					}
+
+					results <- jobResult
+				}
+			}(host.Name, machine)
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+		close(failures)
+	}()
+
+	var scannedHosts []JobResult
+
+	for result := range results {
+		scannedHosts = append(scannedHosts, result)
+	}
+
+	var failedHosts []FailureResult
+
+	for failure := range failures {
+		failedHosts = append(failedHosts, failure)
+	}
+
+	return ScanResult{
+		JobResults: scannedHosts,
+		Failures:   failedHosts,
+	}, nil
+}
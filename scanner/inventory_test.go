@@ -0,0 +1,138 @@
+package scanner_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pivotal-cf/scantron/remotemachine"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/scanlog"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+var _ = Describe("Inventory Scanning", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		inventoryScan scanner.Scanner
+		machine1      *remotemachine.MockRemoteMachine
+		machine2      *remotemachine.MockRemoteMachine
+
+		systemInfo scantron.SystemInfo
+
+		scanResults scanner.ScanResult
+		scanErr     error
+		logger      scanlog.Logger
+
+		fileMatch  *scantron.FileMatch
+		tlsOptions *scantron.TLSScanOptions
+	)
+
+	systemInfoBuffer := func() *bytes.Buffer {
+		buffer := &bytes.Buffer{}
+		err := json.NewEncoder(buffer).Encode(systemInfo)
+		Expect(err).NotTo(HaveOccurred())
+
+		return buffer
+	}
+
+	expectMachine := func(machine *remotemachine.MockRemoteMachine, address string) {
+		machine.EXPECT().Address().Return(address + ":22").AnyTimes()
+		machine.EXPECT().Host().Return(address).AnyTimes()
+		machine.EXPECT().OSName().Return("trusty").AnyTimes()
+		machine.EXPECT().Password().Return("password").AnyTimes()
+		machine.EXPECT().Close().Return(nil).Times(1)
+		machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
+		machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(Test)
+		logger = scanlog.NewNopLogger()
+		machine1 = remotemachine.NewMockRemoteMachine(mockCtrl)
+		machine2 = remotemachine.NewMockRemoteMachine(mockCtrl)
+
+		systemInfo = scantron.SystemInfo{
+			Processes: []scantron.Process{
+				{
+					CommandName: "java",
+					PID:         183,
+					User:        "user-name",
+				},
+			},
+		}
+
+		fileMatch = &scantron.FileMatch{
+			MaxRegexFileSize: int64(1000),
+		}
+		tlsOptions = &scantron.TLSScanOptions{}
+
+		expectMachine(machine1, "10.0.0.1")
+		expectMachine(machine2, "10.0.0.2")
+
+		inventoryScan = scanner.Inventory([]scanner.InventoryHost{
+			{
+				Name:     "database",
+				Machines: []remotemachine.RemoteMachine{machine1, machine2},
+			},
+		})
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("scans every address and names the results after the host", func() {
+		machine1.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(systemInfoBuffer(), nil).Times(1)
+		machine2.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.2 --max 1000").Return(systemInfoBuffer(), nil).Times(1)
+
+		scanResults, scanErr = inventoryScan.Scan(fileMatch, tlsOptions, logger)
+		Expect(scanErr).NotTo(HaveOccurred())
+
+		Expect(scanResults.JobResults).To(ConsistOf(
+			scanner.JobResult{
+				IP:       "10.0.0.1",
+				Job:      "database",
+				Services: systemInfo.Processes,
+			},
+			scanner.JobResult{
+				IP:       "10.0.0.2",
+				Job:      "database",
+				Services: systemInfo.Processes,
+			},
+		))
+	})
+
+	Context("when one of the machines fails to scan", func() {
+		It("keeps going", func() {
+			machine1.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(nil, errors.New("disaster")).Times(1)
+			machine2.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.2 --max 1000").Return(systemInfoBuffer(), nil).Times(1)
+
+			scanResults, scanErr = inventoryScan.Scan(fileMatch, tlsOptions, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+
+			Expect(scanResults.JobResults).To(HaveLen(1))
+			Expect(scanResults.JobResults[0].IP).To(Equal("10.0.0.2"))
+		})
+
+		It("records the failure", func() {
+			machine1.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(nil, errors.New("disaster")).Times(1)
+			machine2.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.2 --max 1000").Return(systemInfoBuffer(), nil).Times(1)
+
+			scanResults, scanErr = inventoryScan.Scan(fileMatch, tlsOptions, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+
+			Expect(scanResults.Failures).To(ConsistOf(scanner.FailureResult{
+				IP:    "10.0.0.1",
+				Job:   "database",
+				Stage: scanner.StageRun,
+				Error: "disaster",
+			}))
+		})
+	})
+})
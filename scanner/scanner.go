@@ -16,21 +16,23 @@ import (
 )
 
 type Scanner interface {
-	Scan(*scantron.FileMatch, scanlog.Logger) (ScanResult, error)
+	Scan(*scantron.FileMatch, *scantron.TLSScanOptions, scanlog.Logger) (ScanResult, error)
 }
 
 type ScanResult struct {
 	JobResults     []JobResult
 	ReleaseResults []ReleaseResult
+	Failures       []FailureResult
 }
 
 type JobResult struct {
 	IP  string
 	Job string
 
-	Services []scantron.Process
-	Files    []scantron.File
-	SSHKeys  []scantron.SSHKey
+	Services         []scantron.Process
+	Files            []scantron.File
+	SSHKeys          []scantron.SSHKey
+	SSHConfiguration *scantron.SSHConfiguration
 }
 
 type ReleaseResult struct {
@@ -38,6 +40,47 @@ type ReleaseResult struct {
 	Version string
 }
 
+type FailureResult struct {
+	IP  string
+	Job string
+
+	Stage string
+	Error string
+}
+
+const (
+	StageConnect = "connect"
+	StageUpload  = "upload"
+	StageRun     = "run"
+	StageDecode  = "decode"
+)
+
+// ScanError records the stage of a machine scan which failed.
+type ScanError struct {
+	Stage string
+	Err   error
+}
+
+func (e ScanError) Error() string {
+	return e.Err.Error()
+}
+
+// BuildFailureResult records why a machine could not be scanned. Errors which
+// do not say which stage they came from are treated as connection failures.
+func BuildFailureResult(err error, jobName, address string) FailureResult {
+	stage := StageConnect
+	if scanErr, ok := err.(ScanError); ok {
+		stage = scanErr.Stage
+	}
+
+	return FailureResult{
+		Job:   jobName,
+		IP:    address,
+		Stage: stage,
+		Error: err.Error(),
+	}
+}
+
 func buildJobResult(host scantron.SystemInfo, jobName, address string) JobResult {
 	return JobResult{
 		Job:      jobName,
@@ -45,6 +88,8 @@ func buildJobResult(host scantron.SystemInfo, jobName, address string) JobResult
 		Services: host.Processes,
 		Files:    host.Files,
 		SSHKeys:  host.SSHKeys,
+
+		SSHConfiguration: host.SSHConfiguration,
 	}
 }
 
@@ -76,7 +121,31 @@ func writeProcScanToTempFile(osName string) (string, error) {
 	return tmpFile.Name(), nil
 }
 
-func scanMachine(fileRegexes *scantron.FileMatch, logger scanlog.Logger, remoteMachine remotemachine.RemoteMachine) (scantron.SystemInfo, error) {
+// tlsScanFlags returns the proc_scan flags for the options which are set.
+// proc_scan uses its own defaults for the others.
+func tlsScanFlags(options *scantron.TLSScanOptions) []string {
+	flags := []string{}
+	if options == nil {
+		return flags
+	}
+
+	if options.ProtocolTimeout > 0 {
+		flags = append(flags, "--tls-protocol-timeout", options.ProtocolTimeout.String())
+	}
+	if options.CipherTimeout > 0 {
+		flags = append(flags, "--tls-cipher-timeout", options.CipherTimeout.String())
+	}
+	if options.Concurrency > 0 {
+		flags = append(flags, "--tls-concurrency", strconv.Itoa(options.Concurrency))
+	}
+	if options.Retries > 0 {
+		flags = append(flags, "--tls-retries", strconv.Itoa(options.Retries))
+	}
+
+	return flags
+}
+
+func scanMachine(fileRegexes *scantron.FileMatch, tlsOptions *scantron.TLSScanOptions, logger scanlog.Logger, remoteMachine remotemachine.RemoteMachine) (scantron.SystemInfo, error) {
 	var systemInfo scantron.SystemInfo
 
 	logger.Infof("Starting VM scan")
@@ -87,7 +156,7 @@ func scanMachine(fileRegexes *scantron.FileMatch, logger scanlog.Logger, remoteM
 
 	srcFilePath, err := writeProcScanToTempFile(osName)
 	if err != nil {
-		return systemInfo, err
+		return systemInfo, ScanError{Stage: StageUpload, Err: err}
 	}
 	defer os.Remove(srcFilePath)
 
@@ -120,24 +189,30 @@ func scanMachine(fileRegexes *scantron.FileMatch, logger scanlog.Logger, remoteM
 			"--content", fmt.Sprintf("\"%s\"", r),
 		}, " ")
 	}
+	command = strings.Join(append([]string{command}, tlsScanFlags(tlsOptions)...), " ")
 
 	err = remoteMachine.UploadFile(srcFilePath, dstFilePath)
 	if err != nil {
+		if _, ok := err.(remotemachine.ConnectionError); ok {
+			logger.Errorf("Failed to connect to remote machine: %s", err)
+			return systemInfo, ScanError{Stage: StageConnect, Err: err}
+		}
+
 		logger.Errorf("Failed to upload scanner to remote machine: %s", err)
-		return systemInfo, err
+		return systemInfo, ScanError{Stage: StageUpload, Err: err}
 	}
 
 	defer remoteMachine.DeleteFile(dstFilePath)
 	output, err := remoteMachine.RunCommand(command)
 	if err != nil {
 		logger.Errorf("Failed to run scanner on remote machine: %s", err)
-		return systemInfo, err
+		return systemInfo, ScanError{Stage: StageRun, Err: err}
 	}
 
 	err = json.NewDecoder(output).Decode(&systemInfo)
 	if err != nil {
 		logger.Errorf("Scanner results were malformed: %s", err)
-		return systemInfo, err
+		return systemInfo, ScanError{Stage: StageDecode, Err: err}
 	}
 
 	return systemInfo, nil
@@ -19,8 +19,22 @@ type Machine struct {
 	Password string
 	Key      ssh.Signer
 	OSName   string
+
+	HostKeyCallback ssh.HostKeyCallback
+	Gateways        []Gateway
+}
+
+type Gateway struct {
+	Address  string
+	Username string
+	Key      ssh.Signer
+
+	HostKeyCallback ssh.HostKeyCallback
 }
 
+// Version is set at build time.
+var Version = "dev"
+
 var Debug bool
 
 func SetDebug(debug bool) {
@@ -0,0 +1,170 @@
+package ssh
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+const (
+	msgKexInit = 20
+
+	algorithmsTimeout = 10 * time.Second
+
+	// RFC 4253 allows lines before the version banner but not an unbounded
+	// number of them.
+	maxPreambleLines = 32
+	maxPacketLength  = 35000
+)
+
+// ScanAlgorithms reads the version banner of the SSH server at address and the
+// algorithms which it accepts. The server lists every algorithm it will use in
+// its first key exchange message so one connection finds them all.
+func ScanAlgorithms(address string) (scantron.SSHConfiguration, error) {
+	conn, err := net.DialTimeout("tcp", address, algorithmsTimeout)
+	if err != nil {
+		return scantron.SSHConfiguration{}, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(algorithmsTimeout))
+
+	_, err = io.WriteString(conn, "SSH-2.0-scantron\r\n")
+	if err != nil {
+		return scantron.SSHConfiguration{}, err
+	}
+
+	reader := bufio.NewReader(conn)
+
+	banner, err := readBanner(reader)
+	if err != nil {
+		return scantron.SSHConfiguration{}, err
+	}
+
+	payload, err := readPacket(reader)
+	if err != nil {
+		return scantron.SSHConfiguration{}, err
+	}
+
+	config, err := parseKexInit(payload)
+	if err != nil {
+		return scantron.SSHConfiguration{}, err
+	}
+
+	config.Banner = banner
+
+	return config, nil
+}
+
+func readBanner(reader *bufio.Reader) (string, error) {
+	for i := 0; i < maxPreambleLines; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "SSH-") {
+			return line, nil
+		}
+	}
+
+	return "", errors.New("ssh: server did not send a version banner")
+}
+
+// readPacket reads an unencrypted binary packet and returns its payload.
+func readPacket(reader io.Reader) ([]byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	padding := uint32(header[4])
+	if length > maxPacketLength || padding+1 > length {
+		return nil, fmt.Errorf("ssh: invalid packet length %d", length)
+	}
+
+	rest := make([]byte, length-1)
+	if _, err := io.ReadFull(reader, rest); err != nil {
+		return nil, err
+	}
+
+	return rest[:len(rest)-int(padding)], nil
+}
+
+// parseKexInit reads the algorithms from an SSH_MSG_KEXINIT message. Ciphers
+// and MACs are listed separately for each direction; these are merged.
+func parseKexInit(payload []byte) (scantron.SSHConfiguration, error) {
+	if len(payload) < 17 || payload[0] != msgKexInit {
+		return scantron.SSHConfiguration{}, errors.New("ssh: expected a key exchange message")
+	}
+
+	// message type(1) cookie(16)
+	data := payload[17:]
+	lists := make([][]string, 8)
+
+	for i := range lists {
+		if len(data) < 4 {
+			return scantron.SSHConfiguration{}, errors.New("ssh: key exchange message is truncated")
+		}
+
+		length := binary.BigEndian.Uint32(data[0:4])
+		data = data[4:]
+		if uint32(len(data)) < length {
+			return scantron.SSHConfiguration{}, errors.New("ssh: key exchange message is truncated")
+		}
+
+		if length > 0 {
+			lists[i] = strings.Split(string(data[:length]), ",")
+		}
+		data = data[length:]
+	}
+
+	return scantron.SSHConfiguration{
+		KeyExchanges: withoutExtensions(lists[0]),
+		HostKeys:     merge(lists[1]),
+		Ciphers:      merge(lists[2], lists[3]),
+		MACs:         merge(lists[4], lists[5]),
+	}, nil
+}
+
+// withoutExtensions removes the names which servers put in the key exchange
+// list to signal support for protocol extensions. They are not key exchanges.
+func withoutExtensions(names []string) []string {
+	kexes := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, "ext-info-") || strings.HasPrefix(name, "kex-strict-") {
+			continue
+		}
+
+		kexes = append(kexes, name)
+	}
+
+	return kexes
+}
+
+func merge(lists ...[]string) []string {
+	merged := []string{}
+	seen := map[string]bool{}
+
+	for _, list := range lists {
+		for _, name := range list {
+			if seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			merged = append(merged, name)
+		}
+	}
+
+	return merged
+}
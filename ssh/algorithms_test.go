@@ -0,0 +1,160 @@
+package ssh_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	scantronssh "github.com/pivotal-cf/scantron/ssh"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var _ = Describe("ScanAlgorithms", func() {
+	var listener net.Listener
+
+	BeforeEach(func() {
+		var err error
+
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	Context("with an SSH server", func() {
+		BeforeEach(func() {
+			config := &ssh.ServerConfig{
+				Config: ssh.Config{
+					KeyExchanges: []string{"curve25519-sha256", "diffie-hellman-group1-sha1"},
+					Ciphers:      []string{"aes128-ctr", "aes128-cbc"},
+					MACs:         []string{"hmac-sha2-256", "hmac-sha1"},
+				},
+				ServerVersion: "SSH-2.0-scantron-test",
+				PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+					return nil, errors.New("You shall not pass")
+				},
+			}
+
+			addKey(config, generateEd25519Key())
+
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+
+					go ssh.NewServerConn(conn, config)
+				}
+			}()
+		})
+
+		It("finds the banner and the algorithms the server accepts", func() {
+			config, err := scantronssh.ScanAlgorithms(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Banner).To(Equal("SSH-2.0-scantron-test"))
+			Expect(config.KeyExchanges).To(ContainElement("curve25519-sha256"))
+			Expect(config.KeyExchanges).To(ContainElement("diffie-hellman-group1-sha1"))
+			Expect(config.KeyExchanges).NotTo(ContainElement(HavePrefix("kex-strict-")))
+			Expect(config.HostKeys).To(ContainElement("ssh-ed25519"))
+			Expect(config.Ciphers).To(Equal([]string{"aes128-ctr", "aes128-cbc"}))
+			Expect(config.MACs).To(Equal([]string{"hmac-sha2-256", "hmac-sha1"}))
+		})
+	})
+
+	Context("with a server offering algorithms Go does not implement", func() {
+		BeforeEach(func() {
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+
+					go func() {
+						defer conn.Close()
+
+						io.WriteString(conn, "Welcome to the server\r\nSSH-2.0-OpenSSH_5.3\r\n")
+						conn.Write(kexInitPacket(
+							"diffie-hellman-group1-sha1,ext-info-s",
+							"ssh-dss",
+							"arcfour,3des-cbc",
+							"arcfour,blowfish-cbc",
+							"hmac-md5",
+							"hmac-md5-96",
+						))
+					}()
+				}
+			}()
+		})
+
+		It("finds them", func() {
+			config, err := scantronssh.ScanAlgorithms(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Banner).To(Equal("SSH-2.0-OpenSSH_5.3"))
+			Expect(config.KeyExchanges).To(Equal([]string{"diffie-hellman-group1-sha1"}))
+			Expect(config.HostKeys).To(Equal([]string{"ssh-dss"}))
+			Expect(config.Ciphers).To(Equal([]string{"arcfour", "3des-cbc", "blowfish-cbc"}))
+			Expect(config.MACs).To(Equal([]string{"hmac-md5", "hmac-md5-96"}))
+		})
+	})
+
+	Context("with a server which does not speak SSH", func() {
+		BeforeEach(func() {
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+
+					io.WriteString(conn, "HTTP/1.1 400 Bad Request\r\n\r\n")
+					conn.Close()
+				}
+			}()
+		})
+
+		It("returns an error", func() {
+			_, err := scantronssh.ScanAlgorithms(listener.Addr().String())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+// kexInitPacket builds an unencrypted SSH_MSG_KEXINIT packet. The name lists
+// are given in wire order up to the MACs; compression is none.
+func kexInitPacket(lists ...string) []byte {
+	payload := []byte{20}
+	payload = append(payload, make([]byte, 16)...) // cookie
+
+	lists = append(lists, "none", "none", "", "")
+	for _, list := range lists {
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(list)))
+		payload = append(payload, length...)
+		payload = append(payload, list...)
+	}
+	payload = append(payload, 0, 0, 0, 0, 0) // first_kex_packet_follows, reserved
+
+	padding := 8 - (len(payload)+5)%8
+	if padding < 4 {
+		padding += 8
+	}
+
+	packet := make([]byte, 5)
+	binary.BigEndian.PutUint32(packet, uint32(1+len(payload)+padding))
+	packet[4] = byte(padding)
+	packet = append(packet, payload...)
+
+	return append(packet, []byte(strings.Repeat("\x00", padding))...)
+}
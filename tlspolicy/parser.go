@@ -0,0 +1,84 @@
+package tlspolicy
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pivotal-cf/scantron/tlsscan"
+)
+
+func Parse(filePath string) (Policy, error) {
+	bs, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	var policy Policy
+
+	err = yaml.UnmarshalStrict(bs, &policy)
+	if err != nil {
+		return Policy{}, fmt.Errorf("incorrect yaml format: %s", err)
+	}
+
+	err = validate(policy)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	return policy, nil
+}
+
+func validate(p Policy) error {
+	err := validateRules(p.Rules)
+	if err != nil {
+		return err
+	}
+
+	for _, override := range p.Overrides {
+		if override.Port == 0 && override.Process == "" {
+			return errors.New("override must have a port or a process")
+		}
+
+		err := validateRules(override.Rules)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateRules(r Rules) error {
+	for _, protocol := range r.Protocols {
+		if !knownProtocol(protocol) {
+			return fmt.Errorf("unknown protocol: %s", protocol)
+		}
+	}
+
+	if r.MinimumKeyBits != nil && *r.MinimumKeyBits < 0 {
+		return errors.New("minimum_key_bits must not be negative")
+	}
+
+	if r.MinimumECDSAKeyBits != nil && *r.MinimumECDSAKeyBits < 0 {
+		return errors.New("minimum_ecdsa_key_bits must not be negative")
+	}
+
+	if r.MinimumDHBits != nil && *r.MinimumDHBits < 0 {
+		return errors.New("minimum_dh_bits must not be negative")
+	}
+
+	return nil
+}
+
+func knownProtocol(name string) bool {
+	for _, version := range tlsscan.ProtocolVersions {
+		if version.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
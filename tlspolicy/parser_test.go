@@ -0,0 +1,81 @@
+package tlspolicy_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/tlspolicy"
+)
+
+var _ = Describe("Parser", func() {
+	It("parses the file", func() {
+		p, err := tlspolicy.Parse("example.yml")
+		Expect(err).NotTo(HaveOccurred())
+
+		minimumKeyBits := 2048
+		minimumECDSAKeyBits := 256
+		requireMutualTLS := false
+		overrideMutualTLS := true
+
+		Expect(p).To(Equal(tlspolicy.Policy{
+			Rules: tlspolicy.Rules{
+				Protocols: []string{"VersionTLS12"},
+				AllowedCiphers: []string{
+					"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+					"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+				},
+				DeniedCiphers:       []string{"TLS_RSA_WITH_RC4_128_SHA"},
+				MinimumKeyBits:      &minimumKeyBits,
+				MinimumECDSAKeyBits: &minimumECDSAKeyBits,
+				RequireMutualTLS:    &requireMutualTLS,
+			},
+			Overrides: []tlspolicy.Override{
+				{
+					Port: 8443,
+					Rules: tlspolicy.Rules{
+						RequireMutualTLS: &overrideMutualTLS,
+					},
+				},
+				{
+					Process: "legacy-app",
+					Rules: tlspolicy.Rules{
+						Protocols: []string{"VersionTLS11", "VersionTLS12"},
+					},
+				},
+			},
+		}))
+	})
+
+	Context("when the file does not exist", func() {
+		It("returns an error", func() {
+			_, err := tlspolicy.Parse("this/does/not/exist")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when the file is mangled", func() {
+		It("returns an error", func() {
+			_, err := tlspolicy.Parse("broken.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("incorrect yaml format"))
+		})
+	})
+
+	Context("when the file has semantic errors", func() {
+		It("returns an error when a protocol is unknown", func() {
+			_, err := tlspolicy.Parse("semantic_err_protocol.yml")
+			Expect(err).To(MatchError("unknown protocol: VersionTLS99"))
+		})
+
+		It("returns an error when an override matches everything", func() {
+			_, err := tlspolicy.Parse("semantic_err_override.yml")
+			Expect(err).To(MatchError("override must have a port or a process"))
+		})
+
+		It("returns an error when a field is misnamed", func() {
+			_, err := tlspolicy.Parse("semantic_err_unknown_field.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("incorrect yaml format"))
+		})
+	})
+})
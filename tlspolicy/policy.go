@@ -0,0 +1,227 @@
+package tlspolicy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pivotal-cf/scantron/tlsscan"
+)
+
+type Policy struct {
+	Rules     `yaml:",inline"`
+	Overrides []Override `yaml:"overrides"`
+}
+
+// Rules which are left out (nil) place no restriction on that part of the
+// TLS configuration. In an override they are inherited from the policy
+// instead.
+type Rules struct {
+	Protocols        []string `yaml:"protocols"`
+	AllowedCiphers   []string `yaml:"allowed_ciphers"`
+	DeniedCiphers    []string `yaml:"denied_ciphers"`
+	RequireMutualTLS *bool    `yaml:"require_mutual_tls"`
+
+	// MinimumKeyBits is for RSA and DSA keys. Elliptic curve keys are far
+	// smaller for the same strength and have their own minimum.
+	MinimumKeyBits      *int `yaml:"minimum_key_bits"`
+	MinimumECDSAKeyBits *int `yaml:"minimum_ecdsa_key_bits"`
+
+	RequireServerCipherOrder *bool `yaml:"require_server_cipher_order"`
+	RequireECDHE             *bool `yaml:"require_ecdhe"`
+	MinimumDHBits            *int  `yaml:"minimum_dh_bits"`
+}
+
+// An Override replaces the rules for the ports which it matches. An
+// override with both a port and a process only matches that process
+// listening on that port.
+type Override struct {
+	Port    int    `yaml:"port"`
+	Process string `yaml:"process"`
+	Rules   `yaml:",inline"`
+}
+
+// Default is the policy used when no other is given: only TLS 1.2 and TLS 1.3
+// with the ciphers which IANA recommends.
+func Default() (Policy, error) {
+	suites, err := tlsscan.BuildCipherSuites()
+	if err != nil {
+		return Policy{}, err
+	}
+
+	ciphers := []string{}
+	for _, suite := range suites {
+		if suite.Recommended {
+			ciphers = append(ciphers, suite.Name)
+		}
+	}
+
+	return Policy{
+		Rules: Rules{
+			Protocols:      []string{"VersionTLS12", "VersionTLS13"},
+			AllowedCiphers: ciphers,
+		},
+	}, nil
+}
+
+func (o Override) matches(process string, port int) bool {
+	if o.Port != 0 && o.Port != port {
+		return false
+	}
+
+	if o.Process != "" && o.Process != process {
+		return false
+	}
+
+	return true
+}
+
+// RulesFor returns the rules for a process listening on a port. Every
+// matching override is applied in the order that they appear in the policy.
+func (p Policy) RulesFor(process string, port int) Rules {
+	rules := p.Rules
+
+	for _, override := range p.Overrides {
+		if override.matches(process, port) {
+			rules = rules.merge(override.Rules)
+		}
+	}
+
+	return rules
+}
+
+func (r Rules) merge(other Rules) Rules {
+	if other.Protocols != nil {
+		r.Protocols = other.Protocols
+	}
+
+	if other.AllowedCiphers != nil {
+		r.AllowedCiphers = other.AllowedCiphers
+	}
+
+	if other.DeniedCiphers != nil {
+		r.DeniedCiphers = other.DeniedCiphers
+	}
+
+	if other.MinimumKeyBits != nil {
+		r.MinimumKeyBits = other.MinimumKeyBits
+	}
+
+	if other.MinimumECDSAKeyBits != nil {
+		r.MinimumECDSAKeyBits = other.MinimumECDSAKeyBits
+	}
+
+	if other.RequireMutualTLS != nil {
+		r.RequireMutualTLS = other.RequireMutualTLS
+	}
+
+	if other.RequireServerCipherOrder != nil {
+		r.RequireServerCipherOrder = other.RequireServerCipherOrder
+	}
+
+	if other.RequireECDHE != nil {
+		r.RequireECDHE = other.RequireECDHE
+	}
+
+	if other.MinimumDHBits != nil {
+		r.MinimumDHBits = other.MinimumDHBits
+	}
+
+	return r
+}
+
+func (r Rules) ProtocolAllowed(protocol string) bool {
+	return r.Protocols == nil || contains(r.Protocols, protocol)
+}
+
+func (r Rules) CipherAllowed(cipher string) bool {
+	if contains(r.DeniedCiphers, cipher) {
+		return false
+	}
+
+	if r.RequireECDHE != nil && *r.RequireECDHE && !ephemeralECDH(cipher) {
+		return false
+	}
+
+	return r.AllowedCiphers == nil || contains(r.AllowedCiphers, cipher)
+}
+
+// An Endpoint is what was found on a port besides its protocols and ciphers.
+type Endpoint struct {
+	KeyType string
+	KeyBits int
+	Mutual  bool
+
+	// ServerCipherPreference is nil when the cipher order could not be
+	// checked.
+	ServerCipherPreference *bool
+
+	// CertificateUnknown is set when the certificate could not be fetched, so
+	// neither its key size nor whether a client certificate is asked for are
+	// known.
+	CertificateUnknown bool
+
+	// DHBits is 0 when the port does not accept any DHE cipher suites.
+	DHBits int
+}
+
+// Violations returns the problems with an endpoint's certificate key size,
+// client certificates, cipher order and DH parameters. Protocols and ciphers
+// are checked separately.
+func (r Rules) Violations(endpoint Endpoint) []string {
+	violations := []string{}
+
+	if minimum := r.minimumKeyBits(endpoint.KeyType); minimum != nil && !endpoint.CertificateUnknown && endpoint.KeyBits < *minimum {
+		violations = append(violations, fmt.Sprintf("%s key is %d bits (minimum %d)", endpoint.KeyType, endpoint.KeyBits, *minimum))
+	}
+
+	if r.RequireMutualTLS != nil && *r.RequireMutualTLS && !endpoint.CertificateUnknown && !endpoint.Mutual {
+		violations = append(violations, "mutual TLS is not required")
+	}
+
+	if r.RequireServerCipherOrder != nil && *r.RequireServerCipherOrder {
+		if endpoint.ServerCipherPreference == nil {
+			violations = append(violations, "server cipher order could not be checked")
+		} else if !*endpoint.ServerCipherPreference {
+			violations = append(violations, "server cipher order is not enforced")
+		}
+	}
+
+	if r.MinimumDHBits != nil && endpoint.DHBits != 0 && endpoint.DHBits < *r.MinimumDHBits {
+		violations = append(violations, fmt.Sprintf("DH parameters are %d bits (minimum %d)", endpoint.DHBits, *r.MinimumDHBits))
+	}
+
+	return violations
+}
+
+// minimumKeyBits returns the minimum size of a certificate key of the given
+// type, or nil if keys of that type are not checked.
+func (r Rules) minimumKeyBits(keyType string) *int {
+	switch keyType {
+	case "RSA", "DSA":
+		return r.MinimumKeyBits
+	case "ECDSA":
+		return r.MinimumECDSAKeyBits
+	default:
+		return nil
+	}
+}
+
+// ephemeralECDH is whether a cipher suite uses ECDHE key exchange. TLS 1.3
+// suites do not name a key exchange and always use an ephemeral one.
+func ephemeralECDH(cipher string) bool {
+	if strings.HasPrefix(cipher, "TLS_ECDHE_") {
+		return true
+	}
+
+	return strings.HasPrefix(cipher, "TLS_") && !strings.Contains(cipher, "_WITH_")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
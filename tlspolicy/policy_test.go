@@ -0,0 +1,132 @@
+package tlspolicy_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/tlspolicy"
+)
+
+var _ = Describe("Policy", func() {
+	Describe("Default", func() {
+		It("only allows TLS 1.2 and TLS 1.3 with recommended ciphers", func() {
+			p, err := tlspolicy.Default()
+			Expect(err).NotTo(HaveOccurred())
+
+			rules := p.RulesFor("any-process", 443)
+
+			Expect(rules.ProtocolAllowed("VersionTLS12")).To(BeTrue())
+			Expect(rules.ProtocolAllowed("VersionTLS13")).To(BeTrue())
+			Expect(rules.ProtocolAllowed("VersionTLS11")).To(BeFalse())
+			Expect(rules.CipherAllowed("TLS_DHE_RSA_WITH_AES_128_GCM_SHA256")).To(BeTrue())
+			Expect(rules.CipherAllowed("TLS_AES_128_GCM_SHA256")).To(BeTrue())
+			Expect(rules.CipherAllowed("TLS_RSA_WITH_RC4_128_SHA")).To(BeFalse())
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "RSA", KeyBits: 512})).To(BeEmpty())
+		})
+	})
+
+	Describe("RulesFor", func() {
+		var p tlspolicy.Policy
+
+		BeforeEach(func() {
+			var err error
+			p, err = tlspolicy.Parse("example.yml")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("uses the top-level rules when no override matches", func() {
+			rules := p.RulesFor("app", 443)
+
+			Expect(rules.ProtocolAllowed("VersionTLS11")).To(BeFalse())
+			Expect(rules.CipherAllowed("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")).To(BeTrue())
+			Expect(rules.CipherAllowed("TLS_RSA_WITH_AES_128_GCM_SHA256")).To(BeFalse())
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "RSA", KeyBits: 2048})).To(BeEmpty())
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "RSA", KeyBits: 1024})).To(ConsistOf("RSA key is 1024 bits (minimum 2048)"))
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "DSA", KeyBits: 1024})).To(ConsistOf("DSA key is 1024 bits (minimum 2048)"))
+		})
+
+		It("checks elliptic curve keys against their own minimum", func() {
+			rules := p.RulesFor("app", 443)
+
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "ECDSA", KeyBits: 256})).To(BeEmpty())
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "ECDSA", KeyBits: 224})).To(ConsistOf("ECDSA key is 224 bits (minimum 256)"))
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "Ed25519", KeyBits: 256})).To(BeEmpty())
+		})
+
+		It("applies overrides for the port", func() {
+			rules := p.RulesFor("app", 8443)
+
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "RSA", KeyBits: 2048})).To(ConsistOf("mutual TLS is not required"))
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "RSA", KeyBits: 2048, Mutual: true})).To(BeEmpty())
+			Expect(rules.Violations(tlspolicy.Endpoint{CertificateUnknown: true})).To(BeEmpty())
+			Expect(rules.ProtocolAllowed("VersionTLS11")).To(BeFalse())
+		})
+
+		It("applies overrides for the process", func() {
+			rules := p.RulesFor("legacy-app", 443)
+
+			Expect(rules.ProtocolAllowed("VersionTLS11")).To(BeTrue())
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "RSA", KeyBits: 1024})).To(ConsistOf("RSA key is 1024 bits (minimum 2048)"))
+		})
+
+		It("applies every matching override", func() {
+			rules := p.RulesFor("legacy-app", 8443)
+
+			Expect(rules.ProtocolAllowed("VersionTLS11")).To(BeTrue())
+			Expect(rules.Violations(tlspolicy.Endpoint{KeyType: "RSA", KeyBits: 2048})).To(ConsistOf("mutual TLS is not required"))
+		})
+	})
+
+	Describe("CipherAllowed", func() {
+		It("allows any cipher which is not denied when there is no allow list", func() {
+			rules := tlspolicy.Rules{
+				DeniedCiphers: []string{"TLS_RSA_WITH_RC4_128_SHA"},
+			}
+
+			Expect(rules.CipherAllowed("TLS_RSA_WITH_AES_128_CBC_SHA")).To(BeTrue())
+			Expect(rules.CipherAllowed("TLS_RSA_WITH_RC4_128_SHA")).To(BeFalse())
+		})
+
+		It("only allows ECDHE key exchange when it is required", func() {
+			requireECDHE := true
+			rules := tlspolicy.Rules{
+				RequireECDHE: &requireECDHE,
+			}
+
+			Expect(rules.CipherAllowed("TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256")).To(BeTrue())
+			Expect(rules.CipherAllowed("TLS_AES_128_GCM_SHA256")).To(BeTrue())
+			Expect(rules.CipherAllowed("TLS_DHE_RSA_WITH_AES_128_GCM_SHA256")).To(BeFalse())
+			Expect(rules.CipherAllowed("TLS_RSA_WITH_AES_128_GCM_SHA256")).To(BeFalse())
+			Expect(rules.CipherAllowed("TLS_ECDH_RSA_WITH_AES_128_CBC_SHA")).To(BeFalse())
+		})
+	})
+
+	Describe("Violations", func() {
+		var rules tlspolicy.Rules
+
+		BeforeEach(func() {
+			requireServerCipherOrder := true
+			minimumDHBits := 2048
+
+			rules = tlspolicy.Rules{
+				RequireServerCipherOrder: &requireServerCipherOrder,
+				MinimumDHBits:            &minimumDHBits,
+			}
+		})
+
+		It("requires the server to pick the cipher suite", func() {
+			serverOrder, clientOrder := true, false
+
+			Expect(rules.Violations(tlspolicy.Endpoint{ServerCipherPreference: &serverOrder})).To(BeEmpty())
+			Expect(rules.Violations(tlspolicy.Endpoint{ServerCipherPreference: &clientOrder})).To(ConsistOf("server cipher order is not enforced"))
+			Expect(rules.Violations(tlspolicy.Endpoint{})).To(ConsistOf("server cipher order could not be checked"))
+		})
+
+		It("checks the size of the DH parameters", func() {
+			serverOrder := true
+
+			Expect(rules.Violations(tlspolicy.Endpoint{ServerCipherPreference: &serverOrder, DHBits: 1024})).To(ConsistOf("DH parameters are 1024 bits (minimum 2048)"))
+			Expect(rules.Violations(tlspolicy.Endpoint{ServerCipherPreference: &serverOrder, DHBits: 2048})).To(BeEmpty())
+		})
+	})
+})
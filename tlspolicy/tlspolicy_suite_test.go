@@ -0,0 +1,13 @@
+package tlspolicy_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTlspolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TLS Policy Suite")
+}
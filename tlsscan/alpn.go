@@ -0,0 +1,71 @@
+package tlsscan
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+// alpnProtocols are the application protocols which are offered to the
+// server, one at a time.
+var alpnProtocols = []string{"h2", "http/1.1"}
+
+// scanALPN returns the application protocols which the server agrees to. This
+// needs a handshake which crypto/tls can complete so servers which only offer
+// cipher suites that it does not implement are not found to speak any.
+func scanALPN(logger scanlog.Logger, probe prober) []string {
+	protocols := []string{}
+
+	for _, protocol := range alpnProtocols {
+		var negotiated string
+		err := probe.do(func(dialer *net.Dialer) error {
+			var err error
+			negotiated, err = negotiateALPN(dialer, probe.host, probe.port, probe.startTLS, protocol)
+			return err
+		})
+		if err != nil {
+			logger.Debugf("Server refused ALPN protocol %s: %s", protocol, err)
+			continue
+		}
+
+		if negotiated == protocol {
+			protocols = append(protocols, protocol)
+		}
+	}
+
+	return protocols
+}
+
+func negotiateALPN(dialer *net.Dialer, host, port string, startTLS *StartTLS, protocol string) (string, error) {
+	negotiated := ""
+
+	config := &tls.Config{
+		// The handshake is abandoned as soon as the server has answered.
+		InsecureSkipVerify: true,
+		ServerName:         host,
+		NextProtos:         []string{protocol},
+		VerifyConnection: func(state tls.ConnectionState) error {
+			negotiated = state.NegotiatedProtocol
+			return ErrExpectedAbort
+		},
+	}
+
+	conn, err := dial(dialer, "tcp", net.JoinHostPort(host, port), startTLS)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if dialer.Timeout != 0 {
+		conn.SetDeadline(time.Now().Add(dialer.Timeout))
+	}
+
+	err = tls.Client(conn, config).Handshake()
+	if err != nil && err != ErrExpectedAbort {
+		return "", err
+	}
+
+	return negotiated, nil
+}
@@ -14,6 +14,7 @@ const (
 	VersionTLS10 = 0x0301
 	VersionTLS11 = 0x0302
 	VersionTLS12 = 0x0303
+	VersionTLS13 = 0x0304
 )
 
 type ProtocolVersion struct {
@@ -26,6 +27,13 @@ var ProtocolVersions = []ProtocolVersion{
 	{ID: VersionTLS10, Name: "VersionTLS10"},
 	{ID: VersionTLS11, Name: "VersionTLS11"},
 	{ID: VersionTLS12, Name: "VersionTLS12"},
+	{ID: VersionTLS13, Name: "VersionTLS13"},
+}
+
+// TLS 1.3 cipher suites (0x13XX) can only be used with TLS 1.3, and TLS 1.3
+// can only be used with them.
+func (v ProtocolVersion) Supports(cs CipherSuite) bool {
+	return (v.ID == VersionTLS13) == isTLS13CipherSuite(cs.ID)
 }
 
 type CipherSuite struct {
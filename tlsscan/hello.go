@@ -0,0 +1,534 @@
+package tlsscan
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// Cipher suites are probed with hand-built ClientHellos rather than with
+// crypto/tls so that suites which Go does not implement (RC4, 3DES, EXPORT,
+// NULL, ...) and old protocol versions can still be found. The handshake is
+// never completed.
+
+const (
+	recordTypeAlert     = 0x15
+	recordTypeHandshake = 0x16
+
+	handshakeTypeClientHello        = 0x01
+	handshakeTypeServerHello        = 0x02
+	handshakeTypeCertificate        = 0x0b
+	handshakeTypeServerKeyExchange  = 0x0c
+	handshakeTypeCertificateRequest = 0x0d
+	handshakeTypeServerHelloDone    = 0x0e
+
+	extensionServerName          = 0x0000
+	extensionSupportedGroups     = 0x000a
+	extensionECPointFormats      = 0x000b
+	extensionSignatureAlgorithms = 0x000d
+	extensionSupportedVersions   = 0x002b
+	extensionKeyShare            = 0x0033
+	extensionRenegotiationInfo   = 0xff01
+
+	groupX25519 = 0x001d
+
+	ecCurveTypeNamedCurve = 3
+
+	maxRecordLength           = 1<<14 + 2048
+	maxHandshakeMessageLength = 1 << 20
+)
+
+var supportedGroups = []uint16{
+	groupX25519,
+	0x0017, // secp256r1
+	0x0018, // secp384r1
+	0x0019, // secp521r1
+}
+
+var signatureAlgorithms = []uint16{
+	0x0403, // ecdsa_secp256r1_sha256
+	0x0503, // ecdsa_secp384r1_sha384
+	0x0603, // ecdsa_secp521r1_sha512
+	0x0804, // rsa_pss_rsae_sha256
+	0x0805, // rsa_pss_rsae_sha384
+	0x0806, // rsa_pss_rsae_sha512
+	0x0807, // ed25519
+	0x0401, // rsa_pkcs1_sha256
+	0x0501, // rsa_pkcs1_sha384
+	0x0601, // rsa_pkcs1_sha512
+}
+
+var errAlert = errors.New("tls: server sent an alert")
+
+func isTLS13CipherSuite(id uint16) bool {
+	return id>>8 == 0x13
+}
+
+// Signaling cipher suite values are not real cipher suites. Offering
+// TLS_FALLBACK_SCSV makes servers refuse anything but their newest protocol
+// version.
+func isSignalingCipherSuite(name string) bool {
+	return strings.HasSuffix(name, "_SCSV")
+}
+
+type clientHello struct {
+	version      uint16
+	cipherSuites []uint16
+
+	// groups defaults to supportedGroups.
+	groups []uint16
+}
+
+type serverHello struct {
+	version     uint16
+	cipherSuite uint16
+
+	// Only set when the ServerKeyExchange was read: the size of the prime
+	// for DHE and the named curve for ECDHE.
+	dhBits int
+	curve  uint16
+}
+
+// negotiate sends the ClientHello and reads the server's reply. ok is false
+// when the server refused the ClientHello or would only use a different
+// protocol version. A HelloRetryRequest counts: the server has already chosen
+// the cipher suite and group and only wants a different key share.
+//
+// When the cipher suites which were offered all use DHE or ECDHE the
+// handshake continues until the server's key exchange parameters have been
+// read.
+func negotiate(dialer *net.Dialer, host, port string, startTLS *StartTLS, hello clientHello, kex keyExchange) (serverHello, bool, error) {
+	return exchangeHellos(dialer, host, port, startTLS, hello, func(server *serverHello, reader *handshakeReader) error {
+		if kex == keyExchangeNone || server.version == VersionTLS13 {
+			return nil
+		}
+
+		return server.readKeyExchange(reader, kex)
+	})
+}
+
+// negotiateCertificates reads the certificates from the server's first flight
+// and whether it asks for a client certificate. This works for servers which
+// crypto/tls will not talk to, such as ones which only speak SSL 3.0 or only
+// use RC4. TLS 1.3 encrypts the certificate so it cannot be used.
+func negotiateCertificates(dialer *net.Dialer, host, port string, startTLS *StartTLS, hello clientHello) ([]x509.Certificate, bool, bool, error) {
+	var certs []x509.Certificate
+	var mutual bool
+
+	_, ok, err := exchangeHellos(dialer, host, port, startTLS, hello, func(server *serverHello, reader *handshakeReader) error {
+		var err error
+		certs, mutual, err = readCertificateFlight(reader)
+		return err
+	})
+
+	return certs, mutual, ok, err
+}
+
+// exchangeHellos sends the ClientHello and reads the ServerHello. When the
+// server accepted the ClientHello, more of the handshake can be read with
+// next before the connection is closed.
+func exchangeHellos(dialer *net.Dialer, host, port string, startTLS *StartTLS, hello clientHello, next func(*serverHello, *handshakeReader) error) (serverHello, bool, error) {
+	message, err := hello.marshal(host)
+	if err != nil {
+		return serverHello{}, false, err
+	}
+
+	conn, err := dial(dialer, "tcp", net.JoinHostPort(host, port), startTLS)
+	if err != nil {
+		return serverHello{}, false, err
+	}
+	defer conn.Close()
+
+	if dialer.Timeout != 0 {
+		conn.SetDeadline(time.Now().Add(dialer.Timeout))
+	}
+
+	_, err = conn.Write(message)
+	if err != nil {
+		return serverHello{}, false, err
+	}
+
+	reader := &handshakeReader{r: conn}
+
+	msgType, body, err := reader.readMessage()
+	if err == errAlert {
+		return serverHello{}, false, nil
+	}
+	if err != nil {
+		return serverHello{}, false, err
+	}
+
+	if msgType != handshakeTypeServerHello {
+		return serverHello{}, false, errors.New("tls: expected a ServerHello")
+	}
+
+	server, err := parseServerHello(body)
+	if err != nil {
+		return serverHello{}, false, err
+	}
+
+	if server.version != hello.version || server.cipherSuite == 0 {
+		return serverHello{}, false, nil
+	}
+
+	err = next(&server, reader)
+	if err != nil {
+		return serverHello{}, false, err
+	}
+
+	return server, true, nil
+}
+
+func (h clientHello) marshal(host string) ([]byte, error) {
+	random := make([]byte, 32)
+	sessionID := make([]byte, 32)
+
+	var privateKey, publicKey [32]byte
+
+	for _, b := range [][]byte{random, sessionID, privateKey[:]} {
+		_, err := io.ReadFull(rand.Reader, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groups := h.groups
+	if groups == nil {
+		groups = supportedGroups
+	}
+
+	extensions := &bytes.Buffer{}
+
+	// SSL 3.0 has no extensions and some servers which only speak it reject
+	// them.
+	if h.version >= VersionTLS10 {
+		if net.ParseIP(host) == nil {
+			serverName := &bytes.Buffer{}
+			serverName.WriteByte(0) // host_name
+			writeVector16(serverName, []byte(host))
+			writeExtension(extensions, extensionServerName, vector16(serverName.Bytes()))
+		}
+
+		writeExtension(extensions, extensionSupportedGroups, vector16(uint16s(groups)))
+		writeExtension(extensions, extensionECPointFormats, []byte{1, 0}) // uncompressed
+		writeExtension(extensions, extensionRenegotiationInfo, []byte{0})
+	}
+
+	if h.version >= VersionTLS12 {
+		writeExtension(extensions, extensionSignatureAlgorithms, vector16(uint16s(signatureAlgorithms)))
+	}
+
+	legacyVersion := h.version
+
+	if h.version == VersionTLS13 {
+		legacyVersion = VersionTLS12
+
+		writeExtension(extensions, extensionSupportedVersions, []byte{2, VersionTLS13 >> 8, VersionTLS13 & 0xff})
+
+		// Only an x25519 key share is sent. A server which wants another
+		// group asks for it with a HelloRetryRequest.
+		keyShare := &bytes.Buffer{}
+		if containsUint16(groups, groupX25519) {
+			curve25519.ScalarBaseMult(&publicKey, &privateKey)
+
+			binary.Write(keyShare, binary.BigEndian, uint16(groupX25519))
+			writeVector16(keyShare, publicKey[:])
+		}
+		writeExtension(extensions, extensionKeyShare, vector16(keyShare.Bytes()))
+	}
+
+	body := &bytes.Buffer{}
+	binary.Write(body, binary.BigEndian, legacyVersion)
+	body.Write(random)
+	body.WriteByte(byte(len(sessionID)))
+	body.Write(sessionID)
+	writeVector16(body, uint16s(h.cipherSuites))
+	body.Write([]byte{1, 0}) // null compression
+	if extensions.Len() > 0 {
+		writeVector16(body, extensions.Bytes())
+	}
+
+	handshake := &bytes.Buffer{}
+	handshake.WriteByte(handshakeTypeClientHello)
+	writeUint24(handshake, body.Len())
+	handshake.Write(body.Bytes())
+
+	recordVersion := uint16(VersionTLS10)
+	if h.version == VersionSSL30 {
+		recordVersion = VersionSSL30
+	}
+
+	record := &bytes.Buffer{}
+	record.WriteByte(recordTypeHandshake)
+	binary.Write(record, binary.BigEndian, recordVersion)
+	writeVector16(record, handshake.Bytes())
+
+	return record.Bytes(), nil
+}
+
+// parseServerHello returns the negotiated protocol version and cipher suite.
+func parseServerHello(hello []byte) (serverHello, error) {
+	// legacy_version(2) random(32) session_id(1+n) cipher_suite(2) compression(1)
+	if len(hello) < 35 {
+		return serverHello{}, errors.New("tls: ServerHello is too short")
+	}
+
+	version := binary.BigEndian.Uint16(hello[0:2])
+	hello = hello[34:]
+
+	sessionIDLength := int(hello[0])
+	if len(hello) < 1+sessionIDLength+3 {
+		return serverHello{}, errors.New("tls: ServerHello is too short")
+	}
+
+	hello = hello[1+sessionIDLength:]
+	cipherSuite := binary.BigEndian.Uint16(hello[0:2])
+	hello = hello[3:]
+
+	if len(hello) < 2 {
+		return serverHello{version: version, cipherSuite: cipherSuite}, nil
+	}
+
+	extensions := hello[2:]
+	for len(extensions) >= 4 {
+		extensionType := binary.BigEndian.Uint16(extensions[0:2])
+		extensionLength := int(binary.BigEndian.Uint16(extensions[2:4]))
+		if len(extensions) < 4+extensionLength {
+			return serverHello{}, errors.New("tls: ServerHello extension is too short")
+		}
+
+		if extensionType == extensionSupportedVersions && extensionLength == 2 {
+			version = binary.BigEndian.Uint16(extensions[4:6])
+		}
+
+		extensions = extensions[4+extensionLength:]
+	}
+
+	return serverHello{version: version, cipherSuite: cipherSuite}, nil
+}
+
+// readKeyExchange reads the rest of the server's first flight looking for its
+// ServerKeyExchange. Cipher suites without ephemeral keys do not have one.
+func (s *serverHello) readKeyExchange(reader *handshakeReader, kex keyExchange) error {
+	for {
+		msgType, body, err := reader.readMessage()
+		if err != nil {
+			return err
+		}
+
+		switch msgType {
+		case handshakeTypeServerHelloDone:
+			return nil
+		case handshakeTypeServerKeyExchange:
+			return s.parseServerKeyExchange(body, kex)
+		}
+	}
+}
+
+// readCertificateFlight reads the rest of the server's first flight looking
+// for its Certificate and CertificateRequest.
+func readCertificateFlight(reader *handshakeReader) ([]x509.Certificate, bool, error) {
+	var certs []x509.Certificate
+	mutual := false
+
+	for {
+		msgType, body, err := reader.readMessage()
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch msgType {
+		case handshakeTypeCertificate:
+			certs, err = parseCertificates(body)
+			if err != nil {
+				return nil, false, err
+			}
+		case handshakeTypeCertificateRequest:
+			mutual = true
+		case handshakeTypeServerHelloDone:
+			if len(certs) == 0 {
+				return nil, false, errors.New("tls: server did not present a certificate")
+			}
+
+			return certs, mutual, nil
+		}
+	}
+}
+
+// parseCertificates parses a Certificate message from TLS 1.2 or earlier: a
+// list of DER certificates, each with a 24-bit length.
+func parseCertificates(body []byte) ([]x509.Certificate, error) {
+	if len(body) < 3 || len(body) != 3+readUint24(body) {
+		return nil, errors.New("tls: malformed Certificate message")
+	}
+
+	certs := []x509.Certificate{}
+
+	list := body[3:]
+	for len(list) > 0 {
+		if len(list) < 3 {
+			return nil, errors.New("tls: malformed Certificate message")
+		}
+
+		length := readUint24(list)
+		if len(list) < 3+length {
+			return nil, errors.New("tls: malformed Certificate message")
+		}
+
+		cert, err := x509.ParseCertificate(list[3 : 3+length])
+		if err != nil {
+			return nil, errors.New("tls: failed to parse certificate from server: " + err.Error())
+		}
+
+		certs = append(certs, *cert)
+		list = list[3+length:]
+	}
+
+	return certs, nil
+}
+
+func (s *serverHello) parseServerKeyExchange(body []byte, kex keyExchange) error {
+	switch kex {
+	case keyExchangeDHE:
+		// dh_p<1..2^16-1> comes first
+		if len(body) < 2 {
+			return errors.New("tls: ServerKeyExchange is too short")
+		}
+
+		length := int(binary.BigEndian.Uint16(body[0:2]))
+		if len(body) < 2+length {
+			return errors.New("tls: ServerKeyExchange is too short")
+		}
+
+		s.dhBits = new(big.Int).SetBytes(body[2 : 2+length]).BitLen()
+	case keyExchangeECDHE:
+		if len(body) < 3 {
+			return errors.New("tls: ServerKeyExchange is too short")
+		}
+
+		if body[0] == ecCurveTypeNamedCurve {
+			s.curve = binary.BigEndian.Uint16(body[1:3])
+		}
+	}
+
+	return nil
+}
+
+// handshakeReader reads handshake messages, which may be split across or
+// share TLS records.
+type handshakeReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (h *handshakeReader) readMessage() (byte, []byte, error) {
+	for !h.hasMessage() {
+		recordType, payload, err := readRecord(h.r)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch recordType {
+		case recordTypeAlert:
+			return 0, nil, errAlert
+		case recordTypeHandshake:
+			h.buf = append(h.buf, payload...)
+		default:
+			return 0, nil, fmt.Errorf("tls: unexpected record type %d", recordType)
+		}
+
+		if len(h.buf) >= 4 && messageLength(h.buf) > maxHandshakeMessageLength {
+			return 0, nil, errors.New("tls: handshake message is too long")
+		}
+	}
+
+	length := 4 + messageLength(h.buf)
+	msgType, body := h.buf[0], h.buf[4:length]
+	h.buf = h.buf[length:]
+
+	return msgType, body, nil
+}
+
+func (h *handshakeReader) hasMessage() bool {
+	return len(h.buf) >= 4 && len(h.buf) >= 4+messageLength(h.buf)
+}
+
+func messageLength(header []byte) int {
+	return readUint24(header[1:])
+}
+
+func readUint24(b []byte) int {
+	return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+}
+
+func readRecord(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length := int(binary.BigEndian.Uint16(header[3:5]))
+	if length > maxRecordLength {
+		return 0, nil, fmt.Errorf("tls: record of %d bytes is too long", length)
+	}
+
+	payload := make([]byte, length)
+
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return header[0], payload, nil
+}
+
+func writeExtension(w *bytes.Buffer, extensionType uint16, data []byte) {
+	binary.Write(w, binary.BigEndian, extensionType)
+	writeVector16(w, data)
+}
+
+func writeVector16(w *bytes.Buffer, data []byte) {
+	binary.Write(w, binary.BigEndian, uint16(len(data)))
+	w.Write(data)
+}
+
+func vector16(data []byte) []byte {
+	w := &bytes.Buffer{}
+	writeVector16(w, data)
+	return w.Bytes()
+}
+
+func writeUint24(w *bytes.Buffer, n int) {
+	w.Write([]byte{byte(n >> 16), byte(n >> 8), byte(n)})
+}
+
+func uint16s(values []uint16) []byte {
+	w := &bytes.Buffer{}
+	for _, v := range values {
+		binary.Write(w, binary.BigEndian, v)
+	}
+
+	return w.Bytes()
+}
+
+func containsUint16(values []uint16, value uint16) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
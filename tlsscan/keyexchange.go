@@ -0,0 +1,201 @@
+package tlsscan
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+type keyExchange int
+
+const (
+	keyExchangeNone keyExchange = iota
+	keyExchangeDHE
+	keyExchangeECDHE
+)
+
+// keyExchangeOf returns the ephemeral key exchange used by a TLS 1.2 or older
+// cipher suite. Static RSA and DH, and the PSK suites, are keyExchangeNone.
+func keyExchangeOf(name string) keyExchange {
+	switch {
+	case strings.Contains(name, "_PSK_"):
+		return keyExchangeNone
+	case strings.Contains(name, "_ECDHE_"), strings.Contains(name, "_ECDH_anon_"):
+		return keyExchangeECDHE
+	case strings.Contains(name, "_DHE_"), strings.Contains(name, "_DH_anon_"):
+		return keyExchangeDHE
+	default:
+		return keyExchangeNone
+	}
+}
+
+type namedGroup struct {
+	ID   uint16
+	Name string
+
+	// Finite field groups can only be negotiated this way with TLS 1.3.
+	tls13Only bool
+}
+
+var namedGroups = []namedGroup{
+	{ID: 0x0013, Name: "secp192r1"},
+	{ID: 0x0015, Name: "secp224r1"},
+	{ID: 0x0017, Name: "secp256r1"},
+	{ID: 0x0018, Name: "secp384r1"},
+	{ID: 0x0019, Name: "secp521r1"},
+	{ID: 0x001d, Name: "x25519"},
+	{ID: 0x001e, Name: "x448"},
+	{ID: 0x0100, Name: "ffdhe2048", tls13Only: true},
+	{ID: 0x0101, Name: "ffdhe3072", tls13Only: true},
+	{ID: 0x0102, Name: "ffdhe4096", tls13Only: true},
+	{ID: 0x0103, Name: "ffdhe6144", tls13Only: true},
+	{ID: 0x0104, Name: "ffdhe8192", tls13Only: true},
+	{ID: 0x11ec, Name: "X25519MLKEM768", tls13Only: true},
+}
+
+// scanGroups finds the key exchange groups (curves) which the server accepts
+// by offering them one at a time. TLS 1.3 is used if the server speaks it;
+// older versions are probed with the ECDHE cipher suites the server accepted.
+// The groups are probed at the same time, as far as the prober allows.
+func scanGroups(logger scanlog.Logger, probe prober, accepted map[uint16][]CipherSuite) []string {
+	tls12Version, ecdheSuites := newestVersionWith(accepted, keyExchangeECDHE)
+
+	found := make([]bool, len(namedGroups))
+	wg := &sync.WaitGroup{}
+
+	for i, group := range namedGroups {
+		wg.Add(1)
+
+		go func(i int, group namedGroup) {
+			defer wg.Done()
+
+			if suites := accepted[VersionTLS13]; len(suites) > 0 {
+				found[i] = acceptsGroup(logger, probe, VersionTLS13, suites, group)
+			}
+
+			if !found[i] && !group.tls13Only && len(ecdheSuites) > 0 {
+				found[i] = acceptsGroup(logger, probe, tls12Version, ecdheSuites, group)
+			}
+		}(i, group)
+	}
+
+	wg.Wait()
+
+	groups := []string{}
+	for i, group := range namedGroups {
+		if found[i] {
+			groups = append(groups, group.Name)
+		}
+	}
+
+	return groups
+}
+
+func acceptsGroup(logger scanlog.Logger, probe prober, version uint16, suites []CipherSuite, group namedGroup) bool {
+	hello := clientHello{
+		version:      version,
+		cipherSuites: cipherSuiteIDs(suites),
+		groups:       []uint16{group.ID},
+	}
+
+	_, ok, err := probe.negotiate(hello, keyExchangeNone)
+	if err != nil {
+		logger.Debugf("Group %s could not be probed: %s", group.Name, err)
+		return false
+	}
+
+	return ok
+}
+
+// scanDHBits returns the size of the server's DHE prime, or 0 if it does not
+// accept any DHE cipher suites.
+func scanDHBits(logger scanlog.Logger, probe prober, accepted map[uint16][]CipherSuite) int {
+	version, dheSuites := newestVersionWith(accepted, keyExchangeDHE)
+	if len(dheSuites) == 0 {
+		return 0
+	}
+
+	hello := clientHello{
+		version:      version,
+		cipherSuites: cipherSuiteIDs(dheSuites),
+	}
+
+	server, ok, err := probe.negotiate(hello, keyExchangeDHE)
+	if err != nil || !ok {
+		logger.Debugf("DH parameters could not be read: ok=%t err=%v", ok, err)
+		return 0
+	}
+
+	return server.dhBits
+}
+
+// prefersServerOrder offers the cipher suites which the server accepted again
+// with the one it picked first moved to the end. A server which follows the
+// client's preference picks a different suite. Versions with fewer than two
+// cipher suites leave the server nothing to choose and are skipped. The
+// result is nil when no version which had a choice could be checked.
+func prefersServerOrder(logger scanlog.Logger, probe prober, accepted map[uint16][]CipherSuite) *bool {
+	choice, checked := false, false
+
+	for version, suites := range accepted {
+		if len(suites) < 2 {
+			continue
+		}
+		choice = true
+
+		rotated := append(cipherSuiteIDs(suites[1:]), suites[0].ID)
+
+		id, ok, err := probe.negotiateCipherSuite(version, rotated)
+		if err != nil || !ok {
+			logger.Debugf("Cipher suite order could not be checked: ok=%t err=%v", ok, err)
+			continue
+		}
+
+		if id != suites[0].ID {
+			preference := false
+			return &preference
+		}
+		checked = true
+	}
+
+	if choice && !checked {
+		return nil
+	}
+
+	preference := true
+	return &preference
+}
+
+// newestVersionWith returns the newest protocol version before TLS 1.3 with
+// accepted cipher suites using the key exchange, along with those suites.
+func newestVersionWith(accepted map[uint16][]CipherSuite, kex keyExchange) (uint16, []CipherSuite) {
+	for i := len(ProtocolVersions) - 1; i >= 0; i-- {
+		version := ProtocolVersions[i].ID
+		if version == VersionTLS13 {
+			continue
+		}
+
+		suites := []CipherSuite{}
+		for _, suite := range accepted[version] {
+			if keyExchangeOf(suite.Name) == kex {
+				suites = append(suites, suite)
+			}
+		}
+
+		if len(suites) > 0 {
+			return version, suites
+		}
+	}
+
+	return 0, nil
+}
+
+func cipherSuiteIDs(suites []CipherSuite) []uint16 {
+	ids := make([]uint16, len(suites))
+	for i, suite := range suites {
+		ids[i] = suite.ID
+	}
+
+	return ids
+}
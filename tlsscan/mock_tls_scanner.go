@@ -35,21 +35,21 @@ func (m *MockTlsScanner) EXPECT() *MockTlsScannerMockRecorder {
 }
 
 // Scan mocks base method
-func (m *MockTlsScanner) Scan(logger scanlog.Logger, host, port string) (scantron.CipherInformation, error) {
-	ret := m.ctrl.Call(m, "Scan", logger, host, port)
-	ret0, _ := ret[0].(scantron.CipherInformation)
+func (m *MockTlsScanner) Scan(logger scanlog.Logger, host, port, process string) (ScanResult, error) {
+	ret := m.ctrl.Call(m, "Scan", logger, host, port, process)
+	ret0, _ := ret[0].(ScanResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Scan indicates an expected call of Scan
-func (mr *MockTlsScannerMockRecorder) Scan(logger, host, port interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockTlsScanner)(nil).Scan), logger, host, port)
+func (mr *MockTlsScannerMockRecorder) Scan(logger, host, port, process interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockTlsScanner)(nil).Scan), logger, host, port, process)
 }
 
 // FetchTLSInformation mocks base method
-func (m *MockTlsScanner) FetchTLSInformation(host, port string) (*scantron.Certificate, bool, error) {
-	ret := m.ctrl.Call(m, "FetchTLSInformation", host, port)
+func (m *MockTlsScanner) FetchTLSInformation(host, port, process string) (*scantron.Certificate, bool, error) {
+	ret := m.ctrl.Call(m, "FetchTLSInformation", host, port, process)
 	ret0, _ := ret[0].(*scantron.Certificate)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -57,6 +57,6 @@ func (m *MockTlsScanner) FetchTLSInformation(host, port string) (*scantron.Certi
 }
 
 // FetchTLSInformation indicates an expected call of FetchTLSInformation
-func (mr *MockTlsScannerMockRecorder) FetchTLSInformation(host, port interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTLSInformation", reflect.TypeOf((*MockTlsScanner)(nil).FetchTLSInformation), host, port)
+func (mr *MockTlsScannerMockRecorder) FetchTLSInformation(host, port, process interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTLSInformation", reflect.TypeOf((*MockTlsScanner)(nil).FetchTLSInformation), host, port, process)
 }
@@ -0,0 +1,72 @@
+package tlsscan
+
+import (
+	"net"
+	"time"
+)
+
+// prober makes the handshakes for one port. Copies made by withTimeout share
+// the limit on handshakes in flight.
+type prober struct {
+	host     string
+	port     string
+	startTLS *StartTLS
+
+	timeout time.Duration
+	retries int
+	slots   chan struct{}
+}
+
+func (p prober) withTimeout(timeout time.Duration) prober {
+	p.timeout = timeout
+	return p
+}
+
+func (p prober) withStartTLS(startTLS *StartTLS) prober {
+	p.startTLS = startTLS
+	return p
+}
+
+// do runs the probe once a slot is free, and again while it times out until
+// the retries are used up.
+func (p prober) do(probe func(dialer *net.Dialer) error) error {
+	var err error
+
+	for attempt := 0; attempt <= p.retries; attempt++ {
+		p.slots <- struct{}{}
+		err = probe(&net.Dialer{Timeout: p.timeout})
+		<-p.slots
+
+		if !isTimeout(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (p prober) negotiate(hello clientHello, kex keyExchange) (serverHello, bool, error) {
+	var server serverHello
+	var ok bool
+
+	err := p.do(func(dialer *net.Dialer) error {
+		var err error
+		server, ok, err = negotiate(dialer, p.host, p.port, p.startTLS, hello, kex)
+		return err
+	})
+
+	return server, ok, err
+}
+
+// negotiateCipherSuite offers the cipher suites to the server and returns the
+// one it picked. ok is false when the server refused all of them or would
+// only use a different protocol version.
+func (p prober) negotiateCipherSuite(version uint16, cipherSuites []uint16) (uint16, bool, error) {
+	server, ok, err := p.negotiate(clientHello{version: version, cipherSuites: cipherSuites}, keyExchangeNone)
+	return server.cipherSuite, ok, err
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
@@ -0,0 +1,14 @@
+// +build windows
+
+package tlsscan
+
+import (
+	"errors"
+	"syscall"
+)
+
+// connectionReset is whether the server reset or stopped reading from the
+// connection.
+func connectionReset(err error) bool {
+	return errors.Is(err, syscall.WSAECONNRESET) || errors.Is(err, syscall.WSAECONNABORTED)
+}
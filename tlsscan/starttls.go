@@ -0,0 +1,400 @@
+package tlsscan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// A StartTLS negotiator asks a server which speaks a plain text protocol to
+// switch to TLS on the same connection. Once it returns the next bytes on the
+// connection are the TLS handshake.
+type StartTLS struct {
+	Protocol  string
+	negotiate func(conn net.Conn) error
+}
+
+var (
+	startTLSSMTP     = &StartTLS{Protocol: "smtp", negotiate: negotiateSMTP}
+	startTLSIMAP     = &StartTLS{Protocol: "imap", negotiate: negotiateIMAP}
+	startTLSLDAP     = &StartTLS{Protocol: "ldap", negotiate: negotiateLDAP}
+	startTLSPostgres = &StartTLS{Protocol: "postgres", negotiate: negotiatePostgres}
+	startTLSMySQL    = &StartTLS{Protocol: "mysql", negotiate: negotiateMySQL}
+)
+
+var startTLSByProcess = map[string]*StartTLS{
+	"master":     startTLSSMTP, // postfix
+	"smtpd":      startTLSSMTP,
+	"exim":       startTLSSMTP,
+	"exim4":      startTLSSMTP,
+	"sendmail":   startTLSSMTP,
+	"dovecot":    startTLSIMAP,
+	"imap-login": startTLSIMAP,
+	"slapd":      startTLSLDAP,
+	"postgres":   startTLSPostgres,
+	"postmaster": startTLSPostgres,
+	"mysqld":     startTLSMySQL,
+	"mariadbd":   startTLSMySQL,
+}
+
+var startTLSByPort = map[string]*StartTLS{
+	"25":   startTLSSMTP,
+	"587":  startTLSSMTP,
+	"143":  startTLSIMAP,
+	"389":  startTLSLDAP,
+	"5432": startTLSPostgres,
+	"3306": startTLSMySQL,
+}
+
+// StartTLSFor picks the negotiator for a process listening on a port. The
+// process name wins over the port so that services on unusual ports are still
+// upgraded. It returns nil when neither is known.
+//
+// Redis has no in-band upgrade: a Redis TLS port speaks TLS immediately and
+// so is found without a negotiator.
+func StartTLSFor(process, port string) *StartTLS {
+	if startTLS, ok := startTLSByProcess[process]; ok {
+		return startTLS
+	}
+
+	return startTLSByPort[port]
+}
+
+// dial connects to the address and, if startTLS is not nil, asks the server to
+// start TLS so that the connection is ready for the TLS handshake.
+func dial(dialer *net.Dialer, network, addr string, startTLS *StartTLS) (net.Conn, error) {
+	conn, err := dialer.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if startTLS == nil {
+		return conn, nil
+	}
+
+	if dialer.Timeout != 0 {
+		conn.SetDeadline(time.Now().Add(dialer.Timeout))
+	}
+
+	err = startTLS.negotiate(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s starttls: %s", startTLS.Protocol, err)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
+
+func negotiateSMTP(conn net.Conn) error {
+	text := textproto.NewConn(conn)
+
+	_, _, err := text.ReadResponse(220)
+	if err != nil {
+		return err
+	}
+
+	err = text.PrintfLine("EHLO scantron")
+	if err != nil {
+		return err
+	}
+
+	_, msg, err := text.ReadResponse(250)
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(strings.ToUpper(msg), "STARTTLS") {
+		return errors.New("server does not offer STARTTLS")
+	}
+
+	err = text.PrintfLine("STARTTLS")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = text.ReadResponse(220)
+	return err
+}
+
+func negotiateIMAP(conn net.Conn) error {
+	text := textproto.NewConn(conn)
+
+	greeting, err := text.ReadLine()
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected greeting: %q", greeting)
+	}
+
+	err = text.PrintfLine("a1 STARTTLS")
+	if err != nil {
+		return err
+	}
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		// Untagged responses may come before the reply to our command.
+		if !strings.HasPrefix(line, "a1 ") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "a1 OK") {
+			return fmt.Errorf("server refused STARTTLS: %q", line)
+		}
+
+		return nil
+	}
+}
+
+// The LDAP StartTLS extended operation.
+const ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+
+const (
+	berTagInteger           = 0x02
+	berTagEnumerated        = 0x0a
+	berTagSequence          = 0x30
+	ldapTagExtendedRequest  = 0x77
+	ldapTagExtendedResponse = 0x78
+	ldapTagRequestName      = 0x80
+)
+
+func negotiateLDAP(conn net.Conn) error {
+	request := berTLV(berTagSequence, append(
+		berTLV(berTagInteger, []byte{1}),
+		berTLV(ldapTagExtendedRequest, berTLV(ldapTagRequestName, []byte(ldapStartTLSOID)))...,
+	))
+
+	_, err := conn.Write(request)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(conn)
+
+	tag, message, err := readBER(reader)
+	if err != nil {
+		return err
+	}
+
+	if tag != berTagSequence {
+		return fmt.Errorf("unexpected response tag: %#x", tag)
+	}
+
+	body := bytes.NewReader(message)
+
+	_, _, err = readBER(body) // message ID
+	if err != nil {
+		return err
+	}
+
+	tag, response, err := readBER(body)
+	if err != nil {
+		return err
+	}
+
+	if tag != ldapTagExtendedResponse {
+		return fmt.Errorf("unexpected response tag: %#x", tag)
+	}
+
+	tag, resultCode, err := readBER(bytes.NewReader(response))
+	if err != nil {
+		return err
+	}
+
+	if tag != berTagEnumerated || len(resultCode) != 1 {
+		return errors.New("malformed extended response")
+	}
+
+	if resultCode[0] != 0 {
+		return fmt.Errorf("server refused StartTLS: result code %d", resultCode[0])
+	}
+
+	return nil
+}
+
+func berTLV(tag byte, value []byte) []byte {
+	tlv := []byte{tag}
+
+	if len(value) < 0x80 {
+		tlv = append(tlv, byte(len(value)))
+	} else {
+		tlv = append(tlv, 0x82, byte(len(value)>>8), byte(len(value)))
+	}
+
+	return append(tlv, value...)
+}
+
+func readBER(r io.ByteReader) (byte, []byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	size := int(length)
+	if length&0x80 != 0 {
+		octets := int(length & 0x7f)
+		if octets == 0 || octets > 3 {
+			return 0, nil, errors.New("unsupported BER length")
+		}
+
+		size = 0
+		for i := 0; i < octets; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+
+			size = size<<8 | int(b)
+		}
+	}
+
+	value := make([]byte, size)
+	for i := range value {
+		value[i], err = r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return tag, value, nil
+}
+
+// The code which a PostgreSQL client sends instead of a protocol version to
+// ask for TLS.
+const postgresSSLRequestCode = 80877103
+
+func negotiatePostgres(conn net.Conn) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequestCode)
+
+	_, err := conn.Write(request)
+	if err != nil {
+		return err
+	}
+
+	response := make([]byte, 1)
+	_, err = io.ReadFull(conn, response)
+	if err != nil {
+		return err
+	}
+
+	switch response[0] {
+	case 'S':
+		return nil
+	case 'N':
+		return errors.New("server does not support SSL")
+	default:
+		return fmt.Errorf("unexpected response: %q", response[0])
+	}
+}
+
+const (
+	mysqlClientProtocol41 = 0x00000200
+	mysqlClientSSL        = 0x00000800
+	mysqlCharsetUTF8      = 33
+)
+
+func negotiateMySQL(conn net.Conn) error {
+	greeting, err := readMySQLPacket(conn)
+	if err != nil {
+		return err
+	}
+
+	capabilities, err := mysqlServerCapabilities(greeting)
+	if err != nil {
+		return err
+	}
+
+	if capabilities&mysqlClientSSL == 0 {
+		return errors.New("server does not support SSL")
+	}
+
+	// An SSLRequest is the start of a HandshakeResponse41: the client
+	// capabilities, the maximum packet size, the character set, and 23 bytes
+	// of padding.
+	request := make([]byte, 32)
+	binary.LittleEndian.PutUint32(request[0:4], mysqlClientProtocol41|mysqlClientSSL)
+	binary.LittleEndian.PutUint32(request[4:8], 1<<24-1)
+	request[8] = mysqlCharsetUTF8
+
+	return writeMySQLPacket(conn, 1, request)
+}
+
+func mysqlServerCapabilities(greeting []byte) (uint32, error) {
+	if len(greeting) == 0 {
+		return 0, errors.New("empty greeting")
+	}
+
+	if greeting[0] == 0xff {
+		return 0, errors.New("server sent an error instead of a greeting")
+	}
+
+	// protocol version, server version, connection ID, first part of the
+	// auth plugin data, and a filler byte
+	versionEnd := bytes.IndexByte(greeting[1:], 0)
+	if versionEnd == -1 {
+		return 0, errors.New("malformed greeting")
+	}
+
+	offset := 1 + versionEnd + 1 + 4 + 8 + 1
+	if len(greeting) < offset+2 {
+		return 0, errors.New("malformed greeting")
+	}
+
+	capabilities := uint32(binary.LittleEndian.Uint16(greeting[offset:]))
+
+	// character set and status flags come before the upper capability flags
+	upper := offset + 2 + 1 + 2
+	if len(greeting) >= upper+2 {
+		capabilities |= uint32(binary.LittleEndian.Uint16(greeting[upper:])) << 16
+	}
+
+	return capabilities, nil
+}
+
+func readMySQLPacket(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func writeMySQLPacket(w io.Writer, sequence byte, payload []byte) error {
+	length := len(payload)
+	packet := append([]byte{byte(length), byte(length >> 8), byte(length >> 16), sequence}, payload...)
+
+	_, err := w.Write(packet)
+	return err
+}
@@ -0,0 +1,271 @@
+package tlsscan_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"log"
+	"net"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/paraphernalia/test/certtest"
+	"github.com/pivotal-cf/scantron/scanlog"
+	"github.com/pivotal-cf/scantron/tlsscan"
+)
+
+var _ = Describe("STARTTLS", func() {
+	var (
+		listener  net.Listener
+		tlsConfig *tls.Config
+		logger    scanlog.Logger
+		subject   *tlsscan.TlsScannerImpl
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(GinkgoWriter)
+
+		logger = scanlog.NewNopLogger()
+		subject = &tlsscan.TlsScannerImpl{}
+
+		ca, err := certtest.BuildCA("scantron")
+		Expect(err).NotTo(HaveOccurred())
+
+		cert, err := ca.BuildSignedCertificate("server")
+		Expect(err).NotTo(HaveOccurred())
+
+		tlsCert, err := cert.TLSCertificate()
+		Expect(err).NotTo(HaveOccurred())
+
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+	})
+
+	AfterEach(func() {
+		if listener != nil {
+			listener.Close()
+		}
+	})
+
+	// serve accepts connections and starts a TLS handshake on each one once
+	// the fake server's negotiation has succeeded.
+	serve := func(negotiate func(conn net.Conn) bool) {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+
+				go func() {
+					defer conn.Close()
+
+					if negotiate(conn) {
+						tls.Server(conn, tlsConfig).Handshake()
+					}
+				}()
+			}
+		}()
+	}
+
+	address := func() (string, string) {
+		host, port, err := net.SplitHostPort(listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		return host, port
+	}
+
+	itUpgradesTheConnection := func(process string) {
+		It("fetches the certificate", func() {
+			host, port := address()
+
+			cert, _, err := subject.FetchTLSInformation(host, port, process)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cert.Subject.CommonName).To(Equal("server"))
+		})
+
+		It("enumerates the cipher suites", func() {
+			host, port := address()
+
+			results, err := subject.Scan(logger, host, port, process)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results.CipherInformation.HasTLS()).To(BeTrue())
+			Expect(results.CipherInformation["VersionTLS12"]).To(ContainElement("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"))
+			Expect(results.CipherInformation["VersionTLS13"]).To(ContainElement("TLS_AES_128_GCM_SHA256"))
+		})
+	}
+
+	Context("with an SMTP server", func() {
+		BeforeEach(func() {
+			serve(func(conn net.Conn) bool {
+				reader := bufio.NewReader(conn)
+
+				io.WriteString(conn, "220 mail.example.com ESMTP\r\n")
+
+				line, err := reader.ReadString('\n')
+				if err != nil || !strings.HasPrefix(line, "EHLO ") {
+					return false
+				}
+
+				io.WriteString(conn, "250-mail.example.com\r\n250-PIPELINING\r\n250 STARTTLS\r\n")
+
+				line, err = reader.ReadString('\n')
+				if err != nil || line != "STARTTLS\r\n" {
+					return false
+				}
+
+				io.WriteString(conn, "220 Ready to start TLS\r\n")
+				return true
+			})
+		})
+
+		itUpgradesTheConnection("smtpd")
+	})
+
+	Context("with an IMAP server", func() {
+		BeforeEach(func() {
+			serve(func(conn net.Conn) bool {
+				reader := bufio.NewReader(conn)
+
+				io.WriteString(conn, "* OK IMAP4rev1 Service Ready\r\n")
+
+				line, err := reader.ReadString('\n')
+				if err != nil || line != "a1 STARTTLS\r\n" {
+					return false
+				}
+
+				io.WriteString(conn, "* BYE not really\r\na1 OK Begin TLS negotiation now\r\n")
+				return true
+			})
+		})
+
+		itUpgradesTheConnection("dovecot")
+	})
+
+	Context("with an LDAP server", func() {
+		BeforeEach(func() {
+			serve(func(conn net.Conn) bool {
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil || header[0] != 0x30 {
+					return false
+				}
+
+				request := make([]byte, header[1])
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return false
+				}
+
+				if !bytes.Contains(request, []byte("1.3.6.1.4.1.1466.20037")) {
+					return false
+				}
+
+				// ExtendedResponse: success, with an empty matched DN and
+				// diagnostic message
+				conn.Write([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x04, 0x00})
+				return true
+			})
+		})
+
+		itUpgradesTheConnection("slapd")
+	})
+
+	Context("with a PostgreSQL server", func() {
+		var acceptSSL bool
+
+		BeforeEach(func() {
+			acceptSSL = true
+
+			serve(func(conn net.Conn) bool {
+				request := make([]byte, 8)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return false
+				}
+
+				if binary.BigEndian.Uint32(request[4:]) != 80877103 {
+					return false
+				}
+
+				if !acceptSSL {
+					conn.Write([]byte("N"))
+					return false
+				}
+
+				conn.Write([]byte("S"))
+				return true
+			})
+		})
+
+		itUpgradesTheConnection("postgres")
+
+		Context("when the server refuses SSL", func() {
+			BeforeEach(func() {
+				acceptSSL = false
+			})
+
+			It("does not find TLS", func() {
+				host, port := address()
+
+				results, err := subject.Scan(logger, host, port, "postgres")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(results.CipherInformation.HasTLS()).To(BeFalse())
+
+				_, _, err = subject.FetchTLSInformation(host, port, "postgres")
+				Expect(err).To(MatchError(ContainSubstring("postgres starttls: server does not support SSL")))
+			})
+		})
+	})
+
+	Context("with a MySQL server", func() {
+		BeforeEach(func() {
+			serve(func(conn net.Conn) bool {
+				greeting := []byte{0x0a}
+				greeting = append(greeting, "8.0.0\x00"...)
+				greeting = append(greeting, 1, 0, 0, 0)               // connection ID
+				greeting = append(greeting, "abcdefgh"...)            // auth plugin data
+				greeting = append(greeting, 0)                        // filler
+				greeting = append(greeting, 0xff, 0xff)               // capabilities, including SSL
+				greeting = append(greeting, 33, 2, 0, 0xff, 0xff, 21) // charset, status, upper capabilities
+				greeting = append(greeting, make([]byte, 10)...)
+
+				packet := []byte{byte(len(greeting)), 0, 0, 0}
+				conn.Write(append(packet, greeting...))
+
+				header := make([]byte, 4)
+				if _, err := io.ReadFull(conn, header); err != nil || header[3] != 1 {
+					return false
+				}
+
+				request := make([]byte, header[0])
+				if _, err := io.ReadFull(conn, request); err != nil || len(request) != 32 {
+					return false
+				}
+
+				return binary.LittleEndian.Uint32(request)&0x0800 != 0
+			})
+		})
+
+		itUpgradesTheConnection("mysqld")
+	})
+
+	Describe("StartTLSFor", func() {
+		It("picks a negotiator by process name", func() {
+			Expect(tlsscan.StartTLSFor("postgres", "15432").Protocol).To(Equal("postgres"))
+		})
+
+		It("picks a negotiator by well-known port", func() {
+			Expect(tlsscan.StartTLSFor("unknown", "587").Protocol).To(Equal("smtp"))
+			Expect(tlsscan.StartTLSFor("unknown", "3306").Protocol).To(Equal("mysql"))
+		})
+
+		It("returns nil for services which speak TLS straight away", func() {
+			Expect(tlsscan.StartTLSFor("nginx", "443")).To(BeNil())
+			Expect(tlsscan.StartTLSFor("redis-server", "6379")).To(BeNil())
+		})
+	})
+})
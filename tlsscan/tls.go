@@ -1,20 +1,135 @@
 package tlsscan
 
 import (
+	"bytes"
+	"crypto/dsa"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/pivotal-cf/scantron"
 )
 
 var ErrExpectedAbort = errors.New("tls: aborting handshake")
 
-func (s *TlsScannerImpl) FetchTLSInformation(host, port string) (*scantron.Certificate, bool, error) {
+func (s *TlsScannerImpl) FetchTLSInformation(host, port, process string) (*scantron.Certificate, bool, error) {
+	probe := s.prober(host, port, nil)
+
+	certs, mutual, err := fetchCertificates(probe)
+	if err != nil {
+		if startTLS := StartTLSFor(process, port); startTLS != nil {
+			certs, mutual, err = fetchCertificates(probe.withStartTLS(startTLS))
+		}
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The rest of the certificate information is about the server's own
+	// certificate, which is always first.
+	cert := certs[0]
+
+	keyType, bits, ok := publicKeyInfo(cert)
+	if !ok {
+		return nil, false, fmt.Errorf("tls: unsupported public key type: %s", keyType)
+	}
+
+	certificate := &scantron.Certificate{
+		Bits:       bits,
+		KeyType:    keyType,
+		Expiration: cert.NotAfter,
+		Subject: scantron.CertificateSubject{
+			Country:  singleton(cert.Subject.Country),
+			Province: singleton(cert.Subject.Province),
+			Locality: singleton(cert.Subject.Locality),
+
+			Organization: singleton(cert.Subject.Organization),
+			CommonName:   cert.Subject.CommonName,
+		},
+	}
+
+	for _, c := range certs {
+		certificate.Chain = append(certificate.Chain, chainCertificate(c))
+	}
+
+	return certificate, mutual, nil
+}
+
+// fetchCertificates reads the certificates with crypto/tls, falling back to a
+// hand-built handshake for servers which only accept protocol versions or
+// cipher suites which crypto/tls refuses.
+func fetchCertificates(probe prober) ([]x509.Certificate, bool, error) {
+	var certs []x509.Certificate
+	var mutual bool
+
+	err := probe.do(func(dialer *net.Dialer) error {
+		var err error
+		certs, mutual, err = readCertificates(dialer, probe.host, probe.port, probe.startTLS)
+		return err
+	})
+	if err == nil {
+		return certs, mutual, nil
+	}
+
+	legacyCerts, legacyMutual, legacyErr := fetchLegacyCertificates(probe)
+	if legacyErr != nil {
+		return nil, false, err
+	}
+
+	return legacyCerts, legacyMutual, nil
+}
+
+// fetchLegacyCertificates offers every cipher suite at each protocol version
+// before TLS 1.3, newest first, until the server accepts one.
+func fetchLegacyCertificates(probe prober) ([]x509.Certificate, bool, error) {
+	cipherSuites, err := BuildCipherSuites()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for i := len(ProtocolVersions) - 1; i >= 0; i-- {
+		version := ProtocolVersions[i]
+		if version.ID == VersionTLS13 {
+			continue
+		}
+
+		hello := clientHello{version: version.ID}
+		for _, cipherSuite := range cipherSuites {
+			if version.Supports(cipherSuite) && !isSignalingCipherSuite(cipherSuite.Name) {
+				hello.cipherSuites = append(hello.cipherSuites, cipherSuite.ID)
+			}
+		}
+
+		var certs []x509.Certificate
+		var mutual, ok bool
+
+		err = probe.do(func(dialer *net.Dialer) error {
+			var err error
+			certs, mutual, ok, err = negotiateCertificates(dialer, probe.host, probe.port, probe.startTLS, hello)
+			return err
+		})
+		if err != nil {
+			return nil, false, err
+		}
+
+		if ok {
+			return certs, mutual, nil
+		}
+	}
+
+	return nil, false, errors.New("tls: server accepted no protocol version")
+}
+
+func readCertificates(dialer *net.Dialer, host, port string, startTLS *StartTLS) ([]x509.Certificate, bool, error) {
 	certs := []x509.Certificate{}
 	mutual := false
 
@@ -22,6 +137,7 @@ func (s *TlsScannerImpl) FetchTLSInformation(host, port string) (*scantron.Certi
 		// We never send secret information over this TLS connection. We're just
 		// probing it.
 		InsecureSkipVerify: true,
+		ServerName:         host,
 		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
 			for _, rawCert := range rawCerts {
 				cert, err := x509.ParseCertificate(rawCert)
@@ -40,44 +156,81 @@ func (s *TlsScannerImpl) FetchTLSInformation(host, port string) (*scantron.Certi
 		},
 	}
 
-	hostport := net.JoinHostPort(host, port)
-	conn, err := tls.Dial("tcp", hostport, config)
+	rawConn, err := dial(dialer, "tcp", net.JoinHostPort(host, port), startTLS)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rawConn.Close()
+
+	rawConn.SetDeadline(time.Now().Add(dialer.Timeout))
+
+	err = tls.Client(rawConn, config).Handshake()
 	if err != nil && err != ErrExpectedAbort {
 		return nil, false, err
 	}
 
-	if conn != nil {
-		_ = conn.Close()
+	if len(certs) == 0 {
+		return nil, false, errors.New("tls: server did not present a certificate")
 	}
 
-	// XXX: We only get the first certificate given to us.
-	cert := certs[0]
-	var bits int
+	return certs, mutual, nil
+}
+
+func chainCertificate(cert x509.Certificate) scantron.ChainCertificate {
+	keyType, keyBits, _ := publicKeyInfo(cert)
+	fingerprint := sha256.Sum256(cert.Raw)
 
+	sans := []string{}
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	return scantron.ChainCertificate{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SerialNumber:       cert.SerialNumber.Text(16),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		SANs:               sans,
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		KeyType:            keyType,
+		KeyBits:            keyBits,
+		SHA256Fingerprint:  hex.EncodeToString(fingerprint[:]),
+		SelfSigned:         selfSigned(cert),
+	}
+}
+
+// publicKeyInfo returns the type and size of a certificate's key. The size
+// of a key which we do not understand is unknown so ok is false.
+func publicKeyInfo(cert x509.Certificate) (keyType string, bits int, ok bool) {
 	switch key := cert.PublicKey.(type) {
 	case *rsa.PublicKey:
-		bits = key.N.BitLen()
+		return "RSA", key.N.BitLen(), true
 	case *ecdsa.PublicKey:
-		bits = key.Params().BitSize
+		return "ECDSA", key.Params().BitSize, true
+	case ed25519.PublicKey:
+		return "Ed25519", len(key) * 8, true
+	case *dsa.PublicKey:
+		return "DSA", key.P.BitLen(), true
 	default:
-		msg := fmt.Sprintf("did not know how to convert type: %T", key)
-		panic(msg)
+		return cert.PublicKeyAlgorithm.String(), 0, false
 	}
+}
 
-	certificate := &scantron.Certificate{
-		Bits:       bits,
-		Expiration: cert.NotAfter,
-		Subject: scantron.CertificateSubject{
-			Country:  singleton(cert.Subject.Country),
-			Province: singleton(cert.Subject.Province),
-			Locality: singleton(cert.Subject.Locality),
-
-			Organization: singleton(cert.Subject.Organization),
-			CommonName:   cert.Subject.CommonName,
-		},
+// A certificate is self-signed when it names itself as its issuer. Checking
+// the signature itself would miss self-signed certificates using algorithms
+// which crypto/x509 no longer verifies, like SHA-1.
+func selfSigned(cert x509.Certificate) bool {
+	if !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+		return false
 	}
 
-	return certificate, mutual, nil
+	return len(cert.AuthorityKeyId) == 0 || bytes.Equal(cert.AuthorityKeyId, cert.SubjectKeyId)
 }
 
 func singleton(array []string) string {
@@ -1,183 +1,244 @@
 package tlsscan
 
 import (
-	"context"
-	"crypto/x509"
-	"fmt"
+	"io"
 	"net"
 	"sync"
-
-	"crypto/tls"
-	"strings"
-
 	"time"
 
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/scanlog"
-	"golang.org/x/sync/semaphore"
 )
 
 const (
-	maxInFlight = 20
+	// The first ClientHello for each protocol version only waits a short
+	// time so that ports which do not speak TLS are skipped quickly.
+	defaultProtocolTimeout = 1 * time.Second
+	defaultCipherTimeout   = 10 * time.Second
+	defaultConcurrency     = 20
 )
 
-type result struct {
-	version string
-	suite   string
+type ScanResult struct {
+	CipherInformation      scantron.CipherInformation
+	ServerCipherPreference *bool
+	KeyExchange            scantron.KeyExchange
+	ALPNProtocols          []string
+
+	// ProbeErrors are the protocol versions whose cipher suites may not all
+	// have been found, including versions which could not be checked at all.
+	ProbeErrors []scantron.ProbeError
 }
 
-func release(logger scanlog.Logger, sem *semaphore.Weighted, wg *sync.WaitGroup) {
-	logger.Debugf("Releasing locks")
-	sem.Release(1)
-	wg.Done()
+// TlsScannerImpl uses the defaults for any options which are not set.
+type TlsScannerImpl struct {
+	Options scantron.TLSScanOptions
 }
 
-type TlsScannerImpl struct{}
+func (s *TlsScannerImpl) options() scantron.TLSScanOptions {
+	options := s.Options
 
-func (s *TlsScannerImpl) Scan(logger scanlog.Logger, host string, port string) (scantron.CipherInformation, error) {
-	results := scantron.CipherInformation{}
-	for _, version := range ProtocolVersions {
-		results[version.Name] = []string{}
+	if options.ProtocolTimeout <= 0 {
+		options.ProtocolTimeout = defaultProtocolTimeout
+	}
+	if options.CipherTimeout <= 0 {
+		options.CipherTimeout = defaultCipherTimeout
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultConcurrency
+	}
+	if options.Retries < 0 {
+		options.Retries = 0
 	}
 
-	supportedProtocols := getSupportedProtocols(logger, host, port)
-	if len(supportedProtocols) == 0 {
-		logger.Debugf("Skipping cipher scan for %s:%s (no supported protocols)", host, port)
-		return results, nil
+	return options
+}
+
+// prober returns a prober for the port which waits for the cipher timeout.
+func (s *TlsScannerImpl) prober(host, port string, startTLS *StartTLS) prober {
+	options := s.options()
+
+	return prober{
+		host:     host,
+		port:     port,
+		startTLS: startTLS,
+		timeout:  options.CipherTimeout,
+		retries:  options.Retries,
+		slots:    make(chan struct{}, options.Concurrency),
 	}
+}
 
-	logger.Debugf("Starting cipher scan for %s:%s", host, port)
+func (s *TlsScannerImpl) Scan(logger scanlog.Logger, host string, port string, process string) (ScanResult, error) {
+	result := ScanResult{
+		CipherInformation: scantron.CipherInformation{},
+	}
+	for _, version := range ProtocolVersions {
+		result.CipherInformation[version.Name] = []string{}
+	}
 
-	sem := semaphore.NewWeighted(maxInFlight)
 	cipherSuites, err := BuildCipherSuites()
 	if err != nil {
-		return results, err
+		return result, err
 	}
-	numCiphersuites := len(supportedProtocols) * len(cipherSuites)
-	resultChan := make(chan result, maxInFlight)
 
-	wg := &sync.WaitGroup{}
-	wg.Add(numCiphersuites)
-
-	go func(logger scanlog.Logger) {
-		for _, version := range supportedProtocols {
-			logger.Debugf("Starting TLS version %s", version.Name)
-			for _, cipherSuite := range cipherSuites {
-				logger.Debugf("Starting ciphersuite %s", cipherSuite.Name)
-				scanLogger := logger.With(
-					"host", host,
-					"port", port,
-					"version", version.Name,
-					"suite", cipherSuite.Name,
-				)
-
-				if err := sem.Acquire(context.Background(), 1); err != nil {
-					scanLogger.Errorf("Failed to acquire lock: %q", err)
-				}
-				scanLogger.Debugf("Acquired lock")
-
-				go testCipher(scanLogger, version, cipherSuite, sem, wg, host, port, resultChan)
-			}
+	logger.Debugf("Starting cipher scan for %s:%s", host, port)
+
+	probe := s.prober(host, port, nil)
+	protocolTimeout := s.options().ProtocolTimeout
+	accepted, probeErrors := scanProtocolVersions(logger, probe, protocolTimeout, cipherSuites)
+
+	if len(accepted) == 0 {
+		if startTLS := StartTLSFor(process, port); startTLS != nil {
+			logger.Debugf("Trying %s STARTTLS for %s:%s", startTLS.Protocol, host, port)
+			probe = probe.withStartTLS(startTLS)
+			accepted, probeErrors = scanProtocolVersions(logger, probe, protocolTimeout, cipherSuites)
 		}
-	}(logger)
+	}
 
-	go func(logger scanlog.Logger) {
-		wg.Wait()
-		logger.Debugf("Wait group done")
-		close(resultChan)
-	}(logger)
+	for _, version := range ProtocolVersions {
+		for _, suite := range accepted[version.ID] {
+			result.CipherInformation[version.Name] = append(result.CipherInformation[version.Name], suite.Name)
+		}
+
+		if err, ok := probeErrors[version.ID]; ok {
+			result.ProbeErrors = append(result.ProbeErrors, scantron.ProbeError{
+				Protocol: version.Name,
+				Error:    err.Error(),
+			})
+		}
+	}
 
-	logger.Debugf("About to start reading from channel")
-	for res := range resultChan {
-		logger.Debugf("Read %s %s from channel", res.version, res.suite)
-		results[res.version] = append(results[res.version], res.suite)
+	if len(accepted) == 0 {
+		logger.Debugf("Finished cipher scan for %s:%s (no supported protocols)", host, port)
+		return result, nil
 	}
 
+	result.ServerCipherPreference = prefersServerOrder(logger, probe, accepted)
+	result.KeyExchange.Groups = scanGroups(logger, probe, accepted)
+	result.KeyExchange.DHBits = scanDHBits(logger, probe, accepted)
+	result.ALPNProtocols = scanALPN(logger, probe)
+
 	logger.Debugf("Finished cipher scan for %s:%s", host, port)
-	return results, nil
+	return result, nil
 }
 
-func testCipher(
-	logger scanlog.Logger,
-	version ProtocolVersion,
-	cipherSuite CipherSuite,
-	sem *semaphore.Weighted,
-	wg *sync.WaitGroup,
-	host string,
-	port string,
-	resultChan chan result) {
-	defer release(logger, sem, wg)
-	found, err := tryHandshakeWithCipher(logger, host, port, version, cipherSuite)
-	if err != nil {
-		logger.Debugf("Remote server did not respond affirmatively to request: %s", err)
-		return
+// scanProtocolVersions finds the cipher suites for every protocol version at
+// the same time. Versions the server does not speak are left out. Versions
+// whose enumeration was cut short by an error, or which could not be checked
+// at all, are also returned with the error.
+func scanProtocolVersions(logger scanlog.Logger, probe prober, protocolTimeout time.Duration, cipherSuites []CipherSuite) (map[uint16][]CipherSuite, map[uint16]error) {
+	accepted := map[uint16][]CipherSuite{}
+	probeErrors := map[uint16]error{}
+	mutex := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+
+	for _, version := range ProtocolVersions {
+		wg.Add(1)
+
+		go func(version ProtocolVersion) {
+			defer wg.Done()
+
+			versionLogger := logger.With(
+				"host", probe.host,
+				"port", probe.port,
+				"version", version.Name,
+			)
+
+			suites, err := enumerateCipherSuites(versionLogger, probe, protocolTimeout, version, cipherSuites)
+
+			mutex.Lock()
+			if len(suites) > 0 {
+				accepted[version.ID] = suites
+			}
+			if err != nil {
+				probeErrors[version.ID] = err
+			}
+			mutex.Unlock()
+		}(version)
 	}
 
-	if found {
-		logger.Debugf("Sending result")
-		resultChan <- result{
-			version: version.Name,
-			suite:   cipherSuite.Name,
+	wg.Wait()
+
+	return accepted, probeErrors
+}
+
+// enumerateCipherSuites offers the server every cipher suite for the protocol
+// version, removes the one it picks, and offers the rest again until it
+// refuses them all. The suites are returned in the order they were picked.
+//
+// Many servers refuse a protocol version by closing the connection, so that
+// or an alert in reply to the first ClientHello means the version is not
+// spoken. A timeout or other network error means the version could not be
+// checked and is returned. After the first suite is accepted any error means
+// some suites may have been missed and is returned along with the suites
+// which were found. The first ClientHello waits for protocolTimeout rather
+// than the prober's timeout.
+func enumerateCipherSuites(logger scanlog.Logger, probe prober, protocolTimeout time.Duration, version ProtocolVersion, cipherSuites []CipherSuite) ([]CipherSuite, error) {
+	offered := map[uint16]CipherSuite{}
+	ids := []uint16{}
+
+	for _, cipherSuite := range cipherSuites {
+		if !version.Supports(cipherSuite) || isSignalingCipherSuite(cipherSuite.Name) {
+			continue
 		}
-		logger.Debugf("Result sent")
+
+		offered[cipherSuite.ID] = cipherSuite
+		ids = append(ids, cipherSuite.ID)
 	}
-	logger.Debugf("Finished ciphersuite %s", cipherSuite.Name)
-}
 
-func getSupportedProtocols(logger scanlog.Logger, host string, port string) []ProtocolVersion {
-	supportedVersions := []ProtocolVersion{}
-	for _, version := range ProtocolVersions {
-		providesCert := false
-		wantsCert := false
-		config := tls.Config{
-			MinVersion:         version.ID,
-			MaxVersion:         version.ID,
-			InsecureSkipVerify: true,
-			VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-				providesCert = true
-				return nil
-			},
-			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
-				wantsCert = true
-				return nil, ErrExpectedAbort
-			},
+	accepted := []CipherSuite{}
+	current := probe.withTimeout(protocolTimeout)
+
+	for len(ids) > 0 {
+		id, ok, err := current.negotiateCipherSuite(version.ID, ids)
+		if err != nil {
+			logger.Debugf("Remote server did not respond affirmatively to request: %s", err)
+
+			if len(accepted) > 0 || !refused(err) {
+				return accepted, err
+			}
+
+			break
 		}
-		err := AttemptHandshake(logger, &net.Dialer{Timeout: 1 * time.Second}, "tcp", fmt.Sprintf("%s:%s", host, port), &config)
 
-		if providesCert {
-			logger.Debugf("%s:%s accepts TLS (%s mutual=%t)", host, port, version.Name, wantsCert)
-			supportedVersions = append(supportedVersions, version)
-		} else {
-			logger.Debugf("%s:%s refuses TLS (%s %s)", host, port, version.Name, err)
+		if !ok {
+			logger.Debugf("Server refused the remaining %d cipher suites", len(ids))
+			break
 		}
+
+		cipherSuite, offeredSuite := offered[id]
+		if !offeredSuite {
+			logger.Debugf("Server picked cipher suite %#04x which was not offered", id)
+			break
+		}
+
+		logger.Debugf("Server accepted %s", cipherSuite.Name)
+		accepted = append(accepted, cipherSuite)
+		ids = without(ids, id)
+		current = probe
 	}
-	return supportedVersions
+
+	return accepted, nil
 }
 
-func tryHandshakeWithCipher(logger scanlog.Logger, host string, port string, version ProtocolVersion, cipherSuite CipherSuite) (bool, error) {
-	config := tls.Config{
-		MinVersion:            version.ID,
-		MaxVersion:            version.ID,
-		CipherSuites:          []uint16{cipherSuite.ID},
-		InsecureSkipVerify:    true,
-		VerifyPeerCertificate: nil,
+// refused is whether an error in reply to the first ClientHello only means
+// that the server does not speak the protocol version: it closed or reset
+// the connection or did not answer with TLS at all.
+func refused(err error) bool {
+	if err == io.EOF || connectionReset(err) {
+		return true
 	}
 
-	address := fmt.Sprintf("%s:%s", host, port)
-	logger.Debugf("Dialing %s %s %s", address, version.Name, cipherSuite.Name)
-	err := AttemptHandshake(logger, &net.Dialer{Timeout: 10 * time.Second}, "tcp", address, &config)
+	_, isNetworkError := err.(net.Error)
+	return !isNetworkError
+}
 
-	if err != nil {
-		if strings.Contains(err.Error(), "remote error") {
-			logger.Debugf("Dialed: no tls for %s %s %s (%s)", address, version.Name, cipherSuite.Name, err)
-			return false, nil
+func without(ids []uint16, id uint16) []uint16 {
+	remaining := make([]uint16, 0, len(ids))
+	for _, other := range ids {
+		if other != id {
+			remaining = append(remaining, other)
 		}
-
-		// TODO are these meant to be recorded in tls_scan_errors?
-		logger.Debugf("Dialed: error for %s %s %s: %s", address, version.Name, cipherSuite.Name, err)
-		return false, err
 	}
 
-	logger.Debugf("Dialed: tls available for %s %s %s", address, version.Name, cipherSuite.Name)
-	return true, nil
+	return remaining
 }
@@ -2,17 +2,22 @@ package tlsscan_test
 
 import (
 	"crypto/tls"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/pivotal-cf/paraphernalia/test/certtest"
+	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/scanlog"
 	"github.com/pivotal-cf/scantron/tlsscan"
 )
@@ -59,14 +64,521 @@ var _ = Describe("TLS Scan", func() {
 		It("performs a scan", func() {
 			host, port := hostport(server.URL)
 
-			result, err := subject.Scan(logger, host, port)
+			result, err := subject.Scan(logger, host, port, "")
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.HasTLS()).To(BeTrue())
+			Expect(result.CipherInformation.HasTLS()).To(BeTrue())
 
-			Expect(result).To(HaveKeyWithValue("VersionTLS10", []string{"TLS_RSA_WITH_AES_128_CBC_SHA"}))
-			Expect(result).To(HaveKeyWithValue("VersionTLS11", []string{"TLS_RSA_WITH_AES_128_CBC_SHA"}))
-			Expect(result).To(HaveKeyWithValue("VersionTLS12", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS10", []string{"TLS_RSA_WITH_AES_128_CBC_SHA"}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS11", []string{"TLS_RSA_WITH_AES_128_CBC_SHA"}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS12", []string{}))
+		})
+	})
+
+	Context("scanning a server that only supports TLS 1.3", func() {
+		BeforeEach(func() {
+			server.TLS = &tls.Config{
+				MinVersion: tls.VersionTLS13,
+			}
+			server.StartTLS()
+		})
+
+		It("finds the TLS 1.3 cipher suites", func() {
+			host, port := hostport(server.URL)
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.CipherInformation.HasTLS()).To(BeTrue())
+
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS10", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS11", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS12", []string{}))
+			Expect(result.CipherInformation["VersionTLS13"]).To(ConsistOf(
+				"TLS_AES_128_GCM_SHA256",
+				"TLS_AES_256_GCM_SHA384",
+				"TLS_CHACHA20_POLY1305_SHA256",
+			))
+		})
+	})
+
+	Context("scanning a server with cipher suites which Go does not implement", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			// The server picks the first of these which the client offers.
+			listener = listenTLS12(func(offered []uint16) []byte {
+				for _, suite := range []uint16{0x0004, 0x0003, 0x000A, 0x0001} {
+					if containsSuite(offered, suite) {
+						return serverHello(suite)
+					}
+				}
+
+				return nil
+			})
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("finds them in the order the server picks them", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS11", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS12", []string{
+				"TLS_RSA_WITH_RC4_128_MD5",
+				"TLS_RSA_EXPORT_WITH_RC4_40_MD5",
+				"TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+				"TLS_RSA_WITH_NULL_MD5",
+			}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS13", []string{}))
+			Expect(*result.ServerCipherPreference).To(BeTrue())
+		})
+	})
+
+	Context("scanning a server which resets the connection part way through", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			listener = listenTLS12(func(offered []uint16) []byte {
+				if containsSuite(offered, 0x002F) {
+					return serverHello(0x002F)
+				}
+
+				// Closing the connection without an alert is not a refusal
+				// once the server has accepted a cipher suite.
+				return []byte{}
+			})
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("records that the protocol version may be missing cipher suites", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.CipherInformation["VersionTLS12"]).To(Equal([]string{"TLS_RSA_WITH_AES_128_CBC_SHA"}))
+			Expect(result.ProbeErrors).To(HaveLen(1))
+			Expect(result.ProbeErrors[0].Protocol).To(Equal("VersionTLS12"))
+			Expect(result.ProbeErrors[0].Error).NotTo(BeEmpty())
+		})
+	})
+
+	Context("scanning a server which resets the connection for versions it does not speak", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+
+					go func() {
+						defer conn.Close()
+
+						version, offered, err := readClientHello(conn)
+						if err != nil {
+							return
+						}
+
+						if version != tlsscan.VersionTLS12 {
+							conn.(*net.TCPConn).SetLinger(0)
+							return
+						}
+
+						if containsSuite(offered, 0x002F) {
+							conn.Write(serverHello(0x002F))
+							return
+						}
+
+						conn.Write([]byte{0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 0x28}) // handshake_failure
+					}()
+				}
+			}()
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("does not record the reset versions as incomplete", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.CipherInformation["VersionTLS12"]).To(Equal([]string{"TLS_RSA_WITH_AES_128_CBC_SHA"}))
+			Expect(result.CipherInformation["VersionTLS11"]).To(BeEmpty())
+			Expect(result.ProbeErrors).To(BeEmpty())
+		})
+	})
+
+	Context("scanning a server which is slow to answer the first handshake", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var answered int32
+
+			// The TLS 1.3 probe also says it is TLS 1.2, so only the first
+			// hello which offers the TLS 1.2 suite is slowed down.
+			listener = listenTLS12(func(offered []uint16) []byte {
+				if !containsSuite(offered, 0x002F) {
+					return nil
+				}
+
+				if atomic.AddInt32(&answered, 1) == 1 {
+					time.Sleep(500 * time.Millisecond)
+				}
+
+				return serverHello(0x002F)
+			})
+
+			subject.Options = scantron.TLSScanOptions{
+				ProtocolTimeout: 100 * time.Millisecond,
+			}
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("records that the protocol version could not be checked", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.CipherInformation["VersionTLS12"]).To(BeEmpty())
+			Expect(result.ProbeErrors).To(HaveLen(1))
+			Expect(result.ProbeErrors[0].Protocol).To(Equal("VersionTLS12"))
+			Expect(result.ProbeErrors[0].Error).To(ContainSubstring("timeout"))
+		})
+
+		Context("when timeouts are retried", func() {
+			BeforeEach(func() {
+				subject.Options.Retries = 1
+			})
+
+			It("finds the cipher suites", func() {
+				host, port, err := net.SplitHostPort(listener.Addr().String())
+				Expect(err).NotTo(HaveOccurred())
+
+				result, err := subject.Scan(logger, host, port, "")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.CipherInformation["VersionTLS12"]).To(Equal([]string{"TLS_RSA_WITH_AES_128_CBC_SHA"}))
+			})
+		})
+	})
+
+	Context("when the number of handshakes in flight is limited", func() {
+		var (
+			listener net.Listener
+			inFlight int32
+			most     int32
+		)
+
+		BeforeEach(func() {
+			inFlight = 0
+			most = 0
+
+			listener = listenTLS12(func(offered []uint16) []byte {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+
+				for {
+					seen := atomic.LoadInt32(&most)
+					if current <= seen || atomic.CompareAndSwapInt32(&most, seen, current) {
+						break
+					}
+				}
+
+				time.Sleep(10 * time.Millisecond)
+
+				if containsSuite(offered, 0xC02F) {
+					return serverHello(0xC02F)
+				}
+
+				return nil
+			})
+
+			subject.Options = scantron.TLSScanOptions{
+				Concurrency: 1,
+			}
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("makes one handshake at a time", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.KeyExchange.Groups).NotTo(BeEmpty())
+			Expect(atomic.LoadInt32(&most)).To(Equal(int32(1)))
+		})
+	})
+
+	Context("scanning a server which refuses the cipher suites it does not support", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			listener = listenTLS12(func(offered []uint16) []byte {
+				if containsSuite(offered, 0x002F) {
+					return serverHello(0x002F)
+				}
+
+				return nil
+			})
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("does not record any errors", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.ProbeErrors).To(BeEmpty())
+		})
+	})
+
+	Context("scanning a server which follows the client's cipher order", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			supported := []uint16{0x002F, 0x0035}
+
+			listener = listenTLS12(func(offered []uint16) []byte {
+				for _, suite := range offered {
+					if containsSuite(supported, suite) {
+						return serverHello(suite)
+					}
+				}
+
+				return nil
+			})
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("finds that the server does not enforce its own order", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.CipherInformation["VersionTLS12"]).To(ConsistOf(
+				"TLS_RSA_WITH_AES_128_CBC_SHA",
+				"TLS_RSA_WITH_AES_256_CBC_SHA",
+			))
+			Expect(*result.ServerCipherPreference).To(BeFalse())
+		})
+	})
+
+	Context("scanning a server with DHE cipher suites", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			const dheSuite = 0x009E // TLS_DHE_RSA_WITH_AES_128_GCM_SHA256
+
+			prime := make([]byte, 128) // 1024 bits
+			prime[0] = 0xff
+
+			listener = listenTLS12(func(offered []uint16) []byte {
+				if !containsSuite(offered, dheSuite) {
+					return nil
+				}
+
+				// dh_p, dh_g and dh_Ys; the signature is never read
+				params := []byte{0x00, byte(len(prime))}
+				params = append(params, prime...)
+				params = append(params, 0x00, 0x01, 0x02, 0x00, 0x01, 0x05)
+
+				response := serverHello(dheSuite)
+				response = append(response, handshakeRecord(0x0c, params)...)
+				return append(response, handshakeRecord(0x0e, nil)...)
+			})
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("finds the size of the DH parameters", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.CipherInformation["VersionTLS12"]).To(Equal([]string{"TLS_DHE_RSA_WITH_AES_128_GCM_SHA256"}))
+			Expect(result.KeyExchange.DHBits).To(Equal(1024))
+			Expect(result.KeyExchange.Groups).To(BeEmpty())
+		})
+	})
+
+	Context("scanning a server with a limited set of curves", func() {
+		BeforeEach(func() {
+			server.TLS = &tls.Config{
+				MaxVersion: tls.VersionTLS12,
+				CipherSuites: []uint16{
+					tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+					tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+				},
+				CurvePreferences: []tls.CurveID{
+					tls.CurveP384,
+					tls.X25519,
+				},
+			}
+			server.StartTLS()
+		})
+
+		It("finds the groups the server accepts", func() {
+			host, port := hostport(server.URL)
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.KeyExchange.Groups).To(ConsistOf("secp384r1", "x25519"))
+			Expect(result.KeyExchange.DHBits).To(BeZero())
+			Expect(*result.ServerCipherPreference).To(BeTrue())
+		})
+	})
+
+	Context("scanning a TLS 1.3 server", func() {
+		BeforeEach(func() {
+			server.TLS = &tls.Config{
+				MinVersion: tls.VersionTLS13,
+				CurvePreferences: []tls.CurveID{
+					tls.CurveP256,
+				},
+			}
+			server.StartTLS()
+		})
+
+		It("finds the groups the server accepts", func() {
+			host, port := hostport(server.URL)
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.KeyExchange.Groups).To(Equal([]string{"secp256r1"}))
+		})
+	})
+
+	Context("scanning a server which speaks HTTP/2", func() {
+		BeforeEach(func() {
+			server.EnableHTTP2 = true
+			server.StartTLS()
+		})
+
+		It("finds the ALPN protocols", func() {
+			host, port := hostport(server.URL)
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.ALPNProtocols).To(ContainElement("h2"))
+		})
+	})
+
+	Context("scanning a server without ALPN", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			listener = listenTLS12(func(offered []uint16) []byte {
+				if containsSuite(offered, 0x002F) {
+					return serverHello(0x002F)
+				}
+
+				return nil
+			})
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("does not find any", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.ALPNProtocols).To(BeEmpty())
+		})
+	})
+
+	Context("scanning a server which only listens on IPv6", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp6", "[::1]:0")
+			if err != nil {
+				Skip("IPv6 loopback is not available: " + err.Error())
+			}
+
+			ca, err := certtest.BuildCA("tlsscan")
+			Expect(err).NotTo(HaveOccurred())
+
+			cert, err := ca.BuildSignedCertificate("server")
+			Expect(err).NotTo(HaveOccurred())
+
+			tlsCert, err := cert.TLSCertificate()
+			Expect(err).NotTo(HaveOccurred())
+
+			server.Listener = listener
+			server.TLS = &tls.Config{
+				Certificates: []tls.Certificate{tlsCert},
+				MinVersion:   tls.VersionTLS12,
+			}
+			server.StartTLS()
+		})
+
+		It("scans it and fetches its certificate", func() {
+			host, port, err := net.SplitHostPort(listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(host).To(Equal("::1"))
+
+			result, err := subject.Scan(logger, host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.CipherInformation["VersionTLS12"]).NotTo(BeEmpty())
+
+			cert, _, err := subject.FetchTLSInformation(host, port, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cert.Subject.CommonName).To(Equal("server"))
 		})
 	})
 
@@ -77,14 +589,15 @@ var _ = Describe("TLS Scan", func() {
 
 		It("performs a scan", func() {
 			host, port := hostport(server.URL)
-			result, err := subject.Scan(logger, host, port)
+			result, err := subject.Scan(logger, host, port, "")
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.HasTLS()).To(BeFalse())
+			Expect(result.CipherInformation.HasTLS()).To(BeFalse())
 
-			Expect(result).To(HaveKeyWithValue("VersionTLS10", []string{}))
-			Expect(result).To(HaveKeyWithValue("VersionTLS11", []string{}))
-			Expect(result).To(HaveKeyWithValue("VersionTLS12", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS10", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS11", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS12", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS13", []string{}))
 		})
 	})
 
@@ -123,16 +636,17 @@ var _ = Describe("TLS Scan", func() {
 		It("performs a scan", func() {
 			host, port := hostport(server.URL)
 
-			result, err := subject.Scan(logger, host, port)
+			result, err := subject.Scan(logger, host, port, "")
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.HasTLS()).To(BeTrue())
+			Expect(result.CipherInformation.HasTLS()).To(BeTrue())
 
-			Expect(result).To(HaveKeyWithValue("VersionTLS10", []string{}))
-			Expect(result).To(HaveKeyWithValue("VersionTLS11", []string{}))
-			Expect(result).To(HaveKeyWithValue("VersionTLS12", []string{
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS10", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS11", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS12", []string{
 				"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
 			}))
+			Expect(result.CipherInformation["VersionTLS13"]).To(ContainElement("TLS_AES_256_GCM_SHA384"))
 		})
 	})
 
@@ -165,14 +679,15 @@ var _ = Describe("TLS Scan", func() {
 			host, port, err := net.SplitHostPort(listener.Addr().String())
 			Expect(err).NotTo(HaveOccurred())
 
-			result, err := subject.Scan(logger, host, port)
+			result, err := subject.Scan(logger, host, port, "")
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.HasTLS()).To(BeFalse())
+			Expect(result.CipherInformation.HasTLS()).To(BeFalse())
 
-			Expect(result).To(HaveKeyWithValue("VersionTLS10", []string{}))
-			Expect(result).To(HaveKeyWithValue("VersionTLS11", []string{}))
-			Expect(result).To(HaveKeyWithValue("VersionTLS12", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS10", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS11", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS12", []string{}))
+			Expect(result.CipherInformation).To(HaveKeyWithValue("VersionTLS13", []string{}))
 		})
 	})
 })
@@ -186,3 +701,97 @@ func hostport(uri string) (string, string) {
 
 	return host, port
 }
+
+// listenTLS12 starts a fake server which only speaks TLS 1.2. respond is
+// given the cipher suites which the client offered, in order, and returns the
+// server's first flight, or nil to refuse them.
+func listenTLS12(respond func(offered []uint16) []byte) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				version, offered, err := readClientHello(conn)
+				if err != nil {
+					return
+				}
+
+				if version != tlsscan.VersionTLS12 {
+					conn.Write([]byte{0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 0x46}) // protocol_version
+					return
+				}
+
+				response := respond(offered)
+				if response == nil {
+					conn.Write([]byte{0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 0x28}) // handshake_failure
+					return
+				}
+
+				conn.Write(response)
+			}()
+		}
+	}()
+
+	return listener
+}
+
+func containsSuite(suites []uint16, suite uint16) bool {
+	for _, s := range suites {
+		if s == suite {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readClientHello returns the client's protocol version and the cipher suites
+// it offered.
+func readClientHello(r io.Reader) (uint16, []uint16, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	record := make([]byte, binary.BigEndian.Uint16(header[3:5]))
+	if _, err := io.ReadFull(r, record); err != nil {
+		return 0, nil, err
+	}
+
+	// handshake header(4) version(2) random(32)
+	hello := record[4:]
+	version := binary.BigEndian.Uint16(hello[0:2])
+	hello = hello[34:]
+	hello = hello[1+int(hello[0]):]
+
+	suitesLength := int(binary.BigEndian.Uint16(hello[0:2]))
+	offered := []uint16{}
+	for i := 2; i < 2+suitesLength; i += 2 {
+		offered = append(offered, binary.BigEndian.Uint16(hello[i:i+2]))
+	}
+
+	return version, offered, nil
+}
+
+func serverHello(suite uint16) []byte {
+	body := []byte{0x03, 0x03}
+	body = append(body, make([]byte, 32)...) // random
+	body = append(body, 0)                   // session ID
+	body = append(body, byte(suite>>8), byte(suite), 0)
+
+	return handshakeRecord(0x02, body)
+}
+
+func handshakeRecord(msgType byte, body []byte) []byte {
+	handshake := append([]byte{msgType, 0, byte(len(body) >> 8), byte(len(body))}, body...)
+
+	return append([]byte{0x16, 0x03, 0x03, byte(len(handshake) >> 8), byte(len(handshake))}, handshake...)
+}
@@ -6,6 +6,6 @@ import (
 )
 
 type TlsScanner interface {
-	Scan(logger scanlog.Logger, host string, port string) (scantron.CipherInformation, error)
-	FetchTLSInformation(host, port string) (*scantron.Certificate, bool, error)
+	Scan(logger scanlog.Logger, host string, port string, process string) (ScanResult, error)
+	FetchTLSInformation(host, port, process string) (*scantron.Certificate, bool, error)
 }
@@ -1,9 +1,15 @@
 package tlsscan_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"log"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"time"
@@ -60,7 +66,7 @@ var _ = Describe("TLS", func() {
 			It("should show TLS certificate details", func() {
 				host, port := hostport(server.URL)
 
-				cert, mutual, err := subject.FetchTLSInformation(host, port)
+				cert, mutual, err := subject.FetchTLSInformation(host, port, "")
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(mutual).To(BeFalse())
 				Expect(cert).ShouldNot(BeNil())
@@ -77,6 +83,94 @@ var _ = Describe("TLS", func() {
 			})
 		})
 
+		Context("with a certificate chain", func() {
+			var ca *certtest.Authority
+
+			BeforeEach(func() {
+				var err error
+				ca, err = certtest.BuildCA("scantron")
+				Expect(err).NotTo(HaveOccurred())
+
+				cert, err := ca.BuildSignedCertificate("server", certtest.WithDomains("server.example.com"))
+				Expect(err).NotTo(HaveOccurred())
+
+				tlsCert, err := cert.TLSCertificate()
+				Expect(err).NotTo(HaveOccurred())
+
+				caPEM, err := ca.CertificatePEM()
+				Expect(err).NotTo(HaveOccurred())
+
+				block, _ := pem.Decode(caPEM)
+				tlsCert.Certificate = append(tlsCert.Certificate, block.Bytes)
+
+				tlsConfig = tlsconfig.Build(tlsconfig.WithIdentity(tlsCert)).Server()
+			})
+
+			It("should show the details of every certificate in the chain", func() {
+				host, port := hostport(server.URL)
+
+				cert, _, err := subject.FetchTLSInformation(host, port, "")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(cert.Chain).To(HaveLen(2))
+
+				leaf := cert.Chain[0]
+				Expect(leaf.Subject).To(ContainSubstring("CN=server"))
+				Expect(leaf.Issuer).To(ContainSubstring("CN=scantron"))
+				Expect(leaf.SerialNumber).NotTo(BeEmpty())
+				Expect(leaf.NotBefore).To(BeTemporally("<", time.Now()))
+				Expect(leaf.NotAfter).To(Equal(cert.Expiration))
+				Expect(leaf.SANs).To(ContainElement("server.example.com"))
+				Expect(leaf.SignatureAlgorithm).To(Equal("SHA256-RSA"))
+				Expect(leaf.KeyType).To(Equal("RSA"))
+				Expect(cert.KeyType).To(Equal("RSA"))
+				Expect(leaf.KeyBits).To(Equal(cert.Bits))
+				Expect(leaf.SHA256Fingerprint).To(MatchRegexp("^[0-9a-f]{64}$"))
+				Expect(leaf.SelfSigned).To(BeFalse())
+
+				root := cert.Chain[1]
+				Expect(root.Subject).To(ContainSubstring("CN=scantron"))
+				Expect(root.Issuer).To(Equal(root.Subject))
+				Expect(root.SHA256Fingerprint).NotTo(Equal(leaf.SHA256Fingerprint))
+				Expect(root.SelfSigned).To(BeTrue())
+			})
+		})
+
+		Context("with an Ed25519 certificate", func() {
+			BeforeEach(func() {
+				pub, priv, err := ed25519.GenerateKey(rand.Reader)
+				Expect(err).NotTo(HaveOccurred())
+
+				template := &x509.Certificate{
+					SerialNumber: big.NewInt(1),
+					Subject:      pkix.Name{CommonName: "server"},
+					NotBefore:    time.Now().Add(-time.Hour),
+					NotAfter:     time.Now().AddDate(1, 0, 0),
+				}
+
+				der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+				Expect(err).NotTo(HaveOccurred())
+
+				tlsConfig = &tls.Config{
+					Certificates: []tls.Certificate{{
+						Certificate: [][]byte{der},
+						PrivateKey:  priv,
+					}},
+				}
+			})
+
+			It("should show the key type and size", func() {
+				host, port := hostport(server.URL)
+
+				cert, _, err := subject.FetchTLSInformation(host, port, "")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(cert.KeyType).To(Equal("Ed25519"))
+				Expect(cert.Bits).To(Equal(256))
+				Expect(cert.Chain[0].KeyType).To(Equal("Ed25519"))
+			})
+		})
+
 		Context("with mutual TLS", func() {
 			BeforeEach(func() {
 				ca, err := certtest.BuildCA("scantron")
@@ -101,7 +195,7 @@ var _ = Describe("TLS", func() {
 			It("should show TLS certificate details", func() {
 				host, port := hostport(server.URL)
 
-				cert, mutual, err := subject.FetchTLSInformation(host, port)
+				cert, mutual, err := subject.FetchTLSInformation(host, port, "")
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(mutual).To(BeTrue())
 				Expect(cert).ShouldNot(BeNil())
@@ -117,6 +211,37 @@ var _ = Describe("TLS", func() {
 				Expect(cert.Subject.CommonName).To(Equal("server"))
 			})
 		})
+		Context("with a server which only speaks protocol versions crypto/tls refuses", func() {
+			BeforeEach(func() {
+				ca, err := certtest.BuildCA("scantron")
+				Expect(err).NotTo(HaveOccurred())
+
+				cert, err := ca.BuildSignedCertificate("server")
+				Expect(err).NotTo(HaveOccurred())
+
+				tlsCert, err := cert.TLSCertificate()
+				Expect(err).NotTo(HaveOccurred())
+
+				tlsConfig = &tls.Config{
+					Certificates: []tls.Certificate{tlsCert},
+					MinVersion:   tls.VersionTLS10,
+					MaxVersion:   tls.VersionTLS11,
+					ClientAuth:   tls.RequestClientCert,
+				}
+			})
+
+			It("reads the certificate from the handshake itself", func() {
+				host, port := hostport(server.URL)
+
+				cert, mutual, err := subject.FetchTLSInformation(host, port, "")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(mutual).To(BeTrue())
+
+				Expect(cert.Bits).To(Equal(1024))
+				Expect(cert.Subject.CommonName).To(Equal("server"))
+				Expect(cert.Chain).To(HaveLen(1))
+			})
+		})
 	})
 
 	Describe("Certificate Subject", func() {
@@ -29,7 +29,12 @@ type Port struct {
 	ForeignNumber  int    `json:"foreignNumber"`
 	State          string `json:"state"`
 
-	TLSInformation *TLSInformation `json:"tls_information"`
+	// ProbedAddress is the address the TLS and HTTP probes connected to. It
+	// is the bound address, or loopback for wildcard binds.
+	ProbedAddress string `json:"probed_address"`
+
+	TLSInformation  *TLSInformation  `json:"tls_information"`
+	HTTPInformation *HTTPInformation `json:"http_information"`
 }
 
 type TLSInformation struct {
@@ -37,7 +42,21 @@ type TLSInformation struct {
 	CipherInformation CipherInformation `json:"cipher_information"`
 	Mutual            bool              `json:"mutual_tls"`
 
-	ScanError error `json:"scan_error,omitempty"`
+	// ServerCipherPreference is whether the server picks the cipher suite
+	// rather than following the client's order. It is nil when the order
+	// could not be checked.
+	ServerCipherPreference *bool       `json:"server_cipher_preference"`
+	KeyExchange            KeyExchange `json:"key_exchange"`
+
+	// ALPNProtocols are the application protocols, such as h2 and
+	// http/1.1, which the server agreed to use.
+	ALPNProtocols []string `json:"alpn_protocols"`
+
+	// ProbeErrors are the problems which cut the cipher suite scan short. The
+	// cipher information for these protocol versions may be incomplete.
+	ProbeErrors []ProbeError `json:"probe_errors"`
+
+	ScanError string `json:"scan_error,omitempty"`
 }
 
 type FileMatch struct {
@@ -46,6 +65,15 @@ type FileMatch struct {
 	MaxRegexFileSize int64    `long:"max" description:"Max file size to check content against regexes" default:"1048576"` // default 1 MB
 }
 
+type TLSScanOptions struct {
+	ProtocolTimeout time.Duration `long:"tls-protocol-timeout" description:"Time to wait for the first handshake of each TLS version" default:"1s"`
+	CipherTimeout   time.Duration `long:"tls-cipher-timeout" description:"Time to wait for every other TLS handshake" default:"10s"`
+	Concurrency     int           `long:"tls-concurrency" description:"Max TLS handshakes in flight for each port, and HTTP requests in flight at once" default:"20"`
+	Retries         int           `long:"tls-retries" description:"Times to retry a TLS handshake which timed out" default:"0"`
+}
+
+// CipherInformation lists the cipher suites for each protocol version in the
+// order that the server picked them.
 type CipherInformation map[string][]string
 
 func (c CipherInformation) HasTLS() bool {
@@ -61,7 +89,55 @@ func (c CipherInformation) HasTLS() bool {
 type Certificate struct {
 	Expiration time.Time          `json:"expiration"`
 	Bits       int                `json:"bits"`
+	KeyType    string             `json:"key_type"`
 	Subject    CertificateSubject `json:"subject"`
+
+	// Chain holds every certificate the server presented, starting with its
+	// own.
+	Chain []ChainCertificate `json:"chain"`
+}
+
+type ChainCertificate struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SerialNumber       string    `json:"serial_number"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	SANs               []string  `json:"sans"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	KeyType            string    `json:"key_type"`
+	KeyBits            int       `json:"key_bits"`
+	SHA256Fingerprint  string    `json:"sha256_fingerprint"`
+	SelfSigned         bool      `json:"self_signed"`
+}
+
+type ProbeError struct {
+	Protocol string `json:"protocol"`
+	Error    string `json:"error"`
+}
+
+// HTTPInformation is the reply to a HEAD request for / on a port which speaks
+// HTTP or, if TLS is true, HTTPS.
+type HTTPInformation struct {
+	TLS        bool `json:"tls"`
+	StatusCode int  `json:"status_code"`
+
+	HSTS      string `json:"hsts"`
+	Server    string `json:"server"`
+	PoweredBy string `json:"powered_by"`
+
+	// RedirectsToHTTPS is whether a plain HTTP request is redirected to an
+	// https:// URL.
+	RedirectsToHTTPS bool `json:"redirects_to_https"`
+}
+
+type KeyExchange struct {
+	// Groups are the named groups (curves) the server accepts.
+	Groups []string `json:"groups"`
+
+	// DHBits is the size of the server's DHE prime, or 0 if it does not
+	// accept any DHE cipher suites.
+	DHBits int `json:"dh_bits"`
 }
 
 type CertificateSubject struct {
@@ -78,13 +154,58 @@ func (cs CertificateSubject) String() string {
 }
 
 type Process struct {
-	CommandName string   `json:"name"`
-	PID         int      `json:"pid"`
-	User        string   `json:"user"`
-	Cmdline     []string `json:"cmdline"`
-	Env         []string `json:"env"`
+	CommandName string    `json:"name"`
+	PID         int       `json:"pid"`
+	PPID        int       `json:"ppid"`
+	User        string    `json:"user"`
+	Cmdline     []string  `json:"cmdline"`
+	Env         []string  `json:"env"`
+	StartTime   time.Time `json:"start_time"`
+
+	// Executable is the path of the binary the process is running. It was
+	// deleted or replaced on disk after the process started if
+	// ExecutableDeleted is set; ExecutableSHA256 is of the running binary.
+	Executable        string `json:"executable"`
+	ExecutableSHA256  string `json:"executable_sha256"`
+	ExecutableDeleted bool   `json:"executable_deleted"`
+
+	// The capabilities are named as in capabilities(7), such as CAP_NET_ADMIN.
+	// Seccomp is disabled, strict or filter.
+	EffectiveCapabilities []string   `json:"effective_capabilities"`
+	PermittedCapabilities []string   `json:"permitted_capabilities"`
+	NoNewPrivs            bool       `json:"no_new_privs"`
+	Seccomp               string     `json:"seccomp"`
+	Namespaces            Namespaces `json:"namespaces"`
+
+	Ports       []Port       `json:"ports"`
+	UnixSockets []UnixSocket `json:"unix_sockets"`
+	RawSockets  []RawSocket  `json:"raw_sockets"`
+}
 
-	Ports []Port `json:"ports"`
+// Namespaces are the inode numbers of the Linux namespaces a process is in.
+// Processes which share a namespace have the same number for it.
+type Namespaces struct {
+	Net   uint64 `json:"net"`
+	PID   uint64 `json:"pid"`
+	Mount uint64 `json:"mnt"`
+}
+
+// UnixSocket is a Unix domain socket which a process is listening on. The
+// path of an abstract socket starts with @ and it has no permissions or owner.
+type UnixSocket struct {
+	Path        string      `json:"path"`
+	Type        string      `json:"type"`
+	Permissions os.FileMode `json:"permissions"`
+	User        string      `json:"user"`
+	Group       string      `json:"group"`
+}
+
+// RawSocket is a raw IP socket (family raw or raw6), whose protocol is an IP
+// protocol number, or a packet socket (family packet), whose protocol is an
+// EtherType.
+type RawSocket struct {
+	Family   string `json:"family"`
+	Protocol int    `json:"protocol"`
 }
 
 type SSHKey struct {
@@ -92,10 +213,21 @@ type SSHKey struct {
 	Key  string `json:"key"`
 }
 
+// SSHConfiguration is the version banner of an SSH server and the algorithms
+// it accepts, in its order of preference.
+type SSHConfiguration struct {
+	Banner       string   `json:"banner"`
+	KeyExchanges []string `json:"key_exchanges"`
+	HostKeys     []string `json:"host_keys"`
+	Ciphers      []string `json:"ciphers"`
+	MACs         []string `json:"macs"`
+}
+
 type SystemInfo struct {
-	Processes []Process `json:"processes"`
-	Files     []File    `json:"files"`
-	SSHKeys   []SSHKey  `json:"ssh_keys"`
+	Processes        []Process         `json:"processes"`
+	Files            []File            `json:"files"`
+	SSHKeys          []SSHKey          `json:"ssh_keys"`
+	SSHConfiguration *SSHConfiguration `json:"ssh_configuration"`
 }
 
 func (p Process) HasFileWithPort(number int) bool {